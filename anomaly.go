@@ -0,0 +1,66 @@
+package pds
+
+import (
+	"math"
+)
+
+// estimateHistory keeps the last few estimates handed out by EstimateCardinality
+type estimateHistory struct {
+	estimates []int64
+	next      int
+	size      int
+}
+
+// record adds an estimate, dropping the oldest once the history is full
+func (h *estimateHistory) record(estimate int64) {
+	if len(h.estimates) < h.size {
+		h.estimates = append(h.estimates, estimate)
+		return
+	}
+
+	h.estimates[h.next] = estimate
+	h.next = (h.next + 1) % h.size
+}
+
+// latest returns the most recent estimate and the ones before it, oldest first
+func (h *estimateHistory) latest() (int64, []int64) {
+	ordered := append(append([]int64{}, h.estimates[h.next:]...), h.estimates[:h.next]...)
+	last := len(ordered) - 1
+
+	return ordered[last], ordered[:last]
+}
+
+// AnomalyScore returns how many standard deviations the latest estimate is from the mean of
+// the estimates before it in the history, positive for a spike and negative for a drop.
+// It needs history tracking turned on with WithEstimateHistory and returns 0 until there
+// are at least three estimates to compare
+func (hll *HyperLogLog) AnomalyScore() float64 {
+	if hll.history == nil || len(hll.history.estimates) < 3 {
+		return 0
+	}
+
+	latest, previous := hll.history.latest()
+
+	var mean float64
+	for _, estimate := range previous {
+		mean += float64(estimate)
+	}
+	mean /= float64(len(previous))
+
+	var variance float64
+	for _, estimate := range previous {
+		variance += math.Pow(float64(estimate)-mean, 2)
+	}
+	stddev := math.Sqrt(variance / float64(len(previous)))
+
+	deviation := float64(latest) - mean
+	if stddev == 0 {
+		if deviation == 0 {
+			return 0
+		}
+
+		return math.Copysign(math.Inf(1), deviation)
+	}
+
+	return deviation / stddev
+}
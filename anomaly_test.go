@@ -0,0 +1,48 @@
+package pds
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAnomalyScoreFlagsSpike(t *testing.T) {
+	hll, err := NewHyperLogLog(14, WithEstimateHistory(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hll.AnomalyScore() != 0 {
+		t.Fatalf("got score %f with no history", hll.AnomalyScore())
+	}
+
+	// Steady traffic of about 100 new items between readings
+	added := 0
+	for step := 0; step < 10; step++ {
+		for end := added + 100; added < end; added++ {
+			hll.Add(fmt.Sprintf("item-%d", added))
+		}
+		hll.EstimateCardinality()
+
+		if step >= 2 && hll.AnomalyScore() > 3 {
+			t.Fatalf("steady growth scored %f at step %d", hll.AnomalyScore(), step)
+		}
+	}
+
+	for end := added + 5000; added < end; added++ {
+		hll.Add(fmt.Sprintf("item-%d", added))
+	}
+	hll.EstimateCardinality()
+
+	if score := hll.AnomalyScore(); score < 3 {
+		t.Fatalf("spike of 5000 items scored %f, wanted above 3", score)
+	}
+}
+
+func TestAnomalyScoreWithoutHistory(t *testing.T) {
+	hll := filledSketch(t, 10, 1000)
+	hll.EstimateCardinality()
+
+	if hll.AnomalyScore() != 0 {
+		t.Fatalf("got score %f without history tracking", hll.AnomalyScore())
+	}
+}
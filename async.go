@@ -0,0 +1,102 @@
+package pds
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// asyncEstimate recomputes the estimate on a background goroutine
+type asyncEstimate struct {
+	interval time.Duration
+	mu       sync.Mutex
+	value    atomic.Int64
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+
+	// sketch is the sketch as last changed, NewHyperLogLog returns it by value so the
+	// goroutine only learns where it lives once it is changed through a pointer
+	sketch *HyperLogLog
+}
+
+// start publishes the initial estimate and begins recomputing the estimate of the sketch
+// every interval until closed
+func (ae *asyncEstimate) start(initial int64) {
+	ae.stop = make(chan struct{})
+	ae.done = make(chan struct{})
+	ae.value.Store(initial)
+
+	recompute := func() {
+		ae.mu.Lock()
+		defer ae.mu.Unlock()
+
+		if ae.sketch == nil {
+			return
+		}
+
+		// The cached estimate belongs to whoever is calling EstimateCardinality, so skip it
+		estimate := int64(len(ae.sketch.exact))
+		if ae.sketch.exact == nil {
+			estimate = ae.sketch.computeEstimate()
+		}
+
+		ae.value.Store(estimate)
+	}
+
+	go func() {
+		defer close(ae.done)
+
+		ticker := time.NewTicker(ae.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				recompute()
+			case <-ae.stop:
+				return
+			}
+		}
+	}()
+}
+
+// lockAsync holds off the background estimate while the sketch changes, pointing it at the
+// sketch so it reads the changes. It does nothing for sketches without one
+func (hll *HyperLogLog) lockAsync() {
+	if hll.async != nil {
+		hll.async.mu.Lock()
+		hll.async.sketch = hll
+	}
+}
+
+// unlockAsync lets the background estimate run again after lockAsync
+func (hll *HyperLogLog) unlockAsync() {
+	if hll.async != nil {
+		hll.async.mu.Unlock()
+	}
+}
+
+// Count returns the estimate. With WithAsyncEstimate this is the last estimate worked out in
+// the background, which can be up to one interval plus the time a recompute takes behind the
+// latest Add, otherwise it is worked out on the spot
+func (hll *HyperLogLog) Count() int64 {
+	if hll.async == nil {
+		return hll.estimate()
+	}
+
+	return hll.async.value.Load()
+}
+
+// Close stops the background estimate started by WithAsyncEstimate, it is safe to call more
+// than once and does nothing for sketches without one
+func (hll *HyperLogLog) Close() {
+	if hll.async == nil {
+		return
+	}
+
+	hll.async.once.Do(func() {
+		close(hll.async.stop)
+	})
+	<-hll.async.done
+}
@@ -0,0 +1,83 @@
+package pds
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// waitForCount waits for the background estimate to reach want
+func waitForCount(t *testing.T, hll *HyperLogLog, want int64) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for hll.Count() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("background estimate stuck at %d, wanted %d", hll.Count(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAsyncEstimateUpdates(t *testing.T) {
+	hll, err := NewHyperLogLog(10, WithAsyncEstimate(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hll.Close()
+
+	if count := hll.Count(); count != 0 {
+		t.Fatalf("got %d before any adds, wanted 0", count)
+	}
+
+	for i := 0; i < 10000; i++ {
+		hll.Add(fmt.Sprint(i))
+	}
+	waitForCount(t, &hll, hll.EstimateCardinality())
+
+	for i := 10000; i < 20000; i++ {
+		hll.Add(fmt.Sprint(i))
+	}
+	waitForCount(t, &hll, hll.EstimateCardinality())
+}
+
+func TestAsyncEstimateCountsExactly(t *testing.T) {
+	hll, err := NewHyperLogLog(10, WithAsyncEstimate(time.Millisecond), WithExactThreshold(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hll.Close()
+
+	for i := 0; i < 50; i++ {
+		hll.Add(fmt.Sprint(i))
+	}
+	waitForCount(t, &hll, 50)
+
+	// Growing past the threshold switches to the buckets' estimate in the background too
+	for i := 50; i < 5000; i++ {
+		hll.Add(fmt.Sprint(i))
+	}
+	waitForCount(t, &hll, hll.EstimateCardinality())
+}
+
+func TestAsyncEstimateClose(t *testing.T) {
+	hll, err := NewHyperLogLog(10, WithAsyncEstimate(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hll.Close()
+	hll.Close()
+
+	select {
+	case <-hll.async.done:
+	default:
+		t.Fatalf("background goroutine still running after Close")
+	}
+
+	// Adds still go into the buckets, only the background estimate stops
+	hll.Add("item")
+	if hll.EstimateCardinality() != 1 {
+		t.Fatalf("got estimate %d after Close, wanted 1", hll.EstimateCardinality())
+	}
+}
@@ -0,0 +1,21 @@
+// Code generated by betadata_gen.go; DO NOT EDIT.
+
+package pds
+
+// betaCoefficients holds the LogLog-Beta polynomial b0 z + b1 zl + ... + b7 zl^7, where z is
+// the number of empty buckets and zl = ln(z+1), for every valid number of index bits
+var betaCoefficients = map[uint32][8]float64{
+	4:  {21.9383257, -26.70788381, 6.223621133, -33.85309759, 26.44149159, -13.66466101, 3.373302014, -0.3518873168},
+	5:  {38.47622213, -40.3204276, -15.54336662, -11.29028601, 1.834074329, -1.784669062, 0.3051397969, -0.05123107754},
+	6:  {-18.87004994, 17.72931907, 10.63873578, 2.25351605, 0.842126861, 0.3503040196, -0.07214083758, 0.02022046174},
+	7:  {-21.52226554, 22.30055752, 5.189565895, 12.08504865, -5.456933329, 2.686889928, -0.4931441325, 0.05242267924},
+	8:  {-3.37980081, 3.158620238, 0.3202223825, 2.736088884, -1.462042989, 0.6146630674, -0.1078389249, 0.009865172773},
+	9:  {-1.343983671, 1.242536868, -0.9265470967, 2.457323929, -1.373129338, 0.4616251619, -0.07185552495, 0.005243964658},
+	10: {-0.9248760436, 1.582448902, -2.568206145, 3.409038754, -1.694533665, 0.4829109528, -0.06715014052, 0.00425223318},
+	11: {-0.5014744687, 1.622067671, -2.967257818, 2.728227842, -1.094266607, 0.2534739965, -0.02986925068, 0.001629449193},
+	12: {-0.3040980903, -3.904941346, 8.032400557, -6.10544053, 2.332094289, -0.4596802529, 0.04627787044, -0.001849181763},
+	13: {-0.3922951821, -2.058377578, 4.199164058, -2.91299576, 1.01223467, -0.1590589561, 0.01129010014, -0.0001098190336},
+	14: {-0.4206418104, 5.253345266, -9.717032164, 7.426005249, -2.714045968, 0.5425950605, -0.05502839526, 0.002434734252},
+	15: {-0.3460914941, -4.469496033, 10.67027249, -7.215269294, 2.345907226, -0.37964296, 0.03026770198, -0.0007849044367},
+	16: {-0.3987820707, 0.2192080988, -6.057233823, 7.361525114, -3.362337795, 0.7413038474, -0.07756864313, 0.003363028386},
+}
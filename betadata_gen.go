@@ -0,0 +1,211 @@
+//go:build ignore
+
+// betadata_gen.go simulates sketches over random hashes and fits the LogLog-Beta correction
+// polynomial in betadata.go to them, run it with go generate
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"os"
+)
+
+const (
+	minIndexBits = 4
+	maxIndexBits = 16
+
+	// terms is the number of coefficients in beta(z) = b0 z + b1 zl + ... + b7 zl^7 where
+	// zl = ln(z+1)
+	terms = 8
+
+	// points is how many cardinalities are sampled per trial, spaced evenly in log space
+	// up to maxMultiple times the number of buckets
+	points      = 100
+	maxMultiple = 20
+
+	// budget is roughly how many hashes are simulated for each number of index bits, split
+	// across as many trials as it takes
+	budget = 1 << 26
+)
+
+// constant matches biasConstant in hyperloglog.go
+func constant(m float64) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	default:
+		return 0.7213 / (1 + 1.079/m)
+	}
+}
+
+// features returns the terms of the beta polynomial for z empty buckets
+func features(z float64) [terms]float64 {
+	var f [terms]float64
+	f[0] = z
+
+	zl := math.Log(z + 1)
+	power := 1.0
+	for i := 1; i < terms; i++ {
+		power *= zl
+		f[i] = power
+	}
+
+	return f
+}
+
+// fit simulates sketches and least squares fits the beta polynomial, weighting every sample
+// so the fit minimises the relative error of the estimate rather than of beta itself
+func fit(rng *rand.Rand, indexBits uint32) [terms]float64 {
+	m := 1 << indexBits
+	maxN := maxMultiple * m
+	trials := max(1, budget/maxN)
+	q := 32 - indexBits
+	alpha := constant(float64(m))
+
+	var cardinalities []int
+	for i := 0; i < points; i++ {
+		n := int(math.Round(math.Pow(float64(maxN), float64(i+1)/points)))
+		if len(cardinalities) == 0 || n > cardinalities[len(cardinalities)-1] {
+			cardinalities = append(cardinalities, n)
+		}
+	}
+
+	powers := make([]float64, q+2)
+	for i := range powers {
+		powers[i] = math.Pow(2, -float64(i))
+	}
+
+	var normal [terms][terms]float64
+	var target [terms]float64
+
+	registers := make([]uint8, m)
+	for t := 0; t < trials; t++ {
+		clear(registers)
+		total := float64(m)
+		zeros := m
+
+		next := 0
+		for n := 1; n <= maxN; n++ {
+			h := rng.Uint32()
+			index := h & uint32(m-1)
+			rank := uint8(min(bits.TrailingZeros32(h>>indexBits), int(q)) + 1)
+			if rank > registers[index] {
+				if registers[index] == 0 {
+					zeros--
+				}
+
+				total += powers[rank] - powers[registers[index]]
+				registers[index] = rank
+			}
+
+			if n != cardinalities[next] {
+				continue
+			}
+			next++
+
+			// Solve n = alpha m (m - z) / (beta + total) for the beta that would have
+			// been exact, its error moves the estimate by roughly error * n / (alpha m (m - z))
+			z := float64(zeros)
+			beta := alpha*float64(m)*(float64(m)-z)/float64(n) - total
+			weight := float64(n) / (alpha * float64(m) * (float64(m) - z))
+			weight *= weight
+
+			f := features(z)
+			for i := 0; i < terms; i++ {
+				target[i] += weight * f[i] * beta
+				for j := 0; j < terms; j++ {
+					normal[i][j] += weight * f[i] * f[j]
+				}
+			}
+		}
+	}
+
+	return solve(normal, target)
+}
+
+// solve solves the normal equations by gaussian elimination, scaling them first as the
+// powers of zl leave them badly conditioned
+func solve(a [terms][terms]float64, b [terms]float64) [terms]float64 {
+	var scale [terms]float64
+	for i := range scale {
+		scale[i] = 1 / math.Sqrt(a[i][i])
+	}
+
+	for i := 0; i < terms; i++ {
+		for j := 0; j < terms; j++ {
+			a[i][j] *= scale[i] * scale[j]
+		}
+		b[i] *= scale[i]
+	}
+
+	for col := 0; col < terms; col++ {
+		pivot := col
+		for row := col + 1; row < terms; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+
+		for row := col + 1; row < terms; row++ {
+			factor := a[row][col] / a[col][col]
+			for j := col; j < terms; j++ {
+				a[row][j] -= factor * a[col][j]
+			}
+			b[row] -= factor * b[col]
+		}
+	}
+
+	var x [terms]float64
+	for row := terms - 1; row >= 0; row-- {
+		sum := b[row]
+		for j := row + 1; j < terms; j++ {
+			sum -= a[row][j] * x[j]
+		}
+		x[row] = sum / a[row][row]
+	}
+
+	for i := range x {
+		x[i] *= scale[i]
+	}
+
+	return x
+}
+
+func main() {
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by betadata_gen.go; DO NOT EDIT.\n\npackage pds\n\n")
+	buf.WriteString("// betaCoefficients holds the LogLog-Beta polynomial b0 z + b1 zl + ... + b7 zl^7, where z is\n")
+	buf.WriteString("// the number of empty buckets and zl = ln(z+1), for every valid number of index bits\n")
+	buf.WriteString("var betaCoefficients = map[uint32][8]float64{\n")
+	for indexBits := uint32(minIndexBits); indexBits <= maxIndexBits; indexBits++ {
+		coefficients := fit(rng, indexBits)
+		fmt.Fprintf(&buf, "%d: {", indexBits)
+		for _, c := range coefficients {
+			fmt.Fprintf(&buf, "%.10g, ", c)
+		}
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("}\n")
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile("betadata.go", source, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,76 @@
+package pds
+
+//go:generate go run biasdata_gen.go
+//go:generate go run betadata_gen.go
+
+import (
+	"sort"
+)
+
+// biasNeighbours is how many of the closest simulated raw estimates are averaged over
+const biasNeighbours = 6
+
+// biasThresholds holds the cardinality below which linear counting beats the bias corrected
+// estimate for every valid number of index bits, from the HyperLogLog++ paper
+var biasThresholds = map[uint32]float64{
+	4:  10,
+	5:  20,
+	6:  40,
+	7:  80,
+	8:  220,
+	9:  400,
+	10: 900,
+	11: 1800,
+	12: 3100,
+	13: 6500,
+	14: 11500,
+	15: 20000,
+	16: 50000,
+}
+
+// estimateBias returns how far a raw estimate is likely to be above the true cardinality by
+// averaging the bias of the closest raw estimates in the simulated tables
+func estimateBias(indexBits uint32, raw float64) float64 {
+	estimates, biases := rawEstimateData[indexBits], biasData[indexBits]
+
+	// Grow a window out from where raw would sit, taking whichever side is closer each time
+	high := sort.SearchFloat64s(estimates, raw)
+	low := high
+	for high-low < biasNeighbours && (low > 0 || high < len(estimates)) {
+		switch {
+		case low == 0:
+			high++
+		case high == len(estimates):
+			low--
+		case raw-estimates[low-1] <= estimates[high]-raw:
+			low--
+		default:
+			high++
+		}
+	}
+
+	var total float64
+	for _, bias := range biases[low:high] {
+		total += bias
+	}
+
+	return total / float64(high-low)
+}
+
+// biasCorrectedEstimate turns the harmonic sum over the buckets into an estimate the
+// HyperLogLog++ way, taking the simulated bias off raw estimates up to 5m and using linear
+// counting instead while it gives less than the threshold for the index bits
+func biasCorrectedEstimate(indexBits uint32, constant float64, totalBuckets float64, total float64, zeros float64) float64 {
+	estimate := (constant * totalBuckets * totalBuckets) / total
+	if estimate <= 5*totalBuckets {
+		estimate -= estimateBias(indexBits, estimate)
+	}
+
+	if zeros > 0 {
+		if linear := smallRangeCorrection(totalBuckets, zeros); linear <= biasThresholds[indexBits] {
+			return linear
+		}
+	}
+
+	return estimate
+}
@@ -0,0 +1,46 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestBiasCorrectionTightensMidRange(t *testing.T) {
+	// Averaged over seeds just past 2.5m, where the plain estimate has stopped linear
+	// counting and overshoots the most
+	var plainBias, correctedBias float64
+	for seed := uint64(0); seed < 16; seed++ {
+		plain := filledSketch(t, 12, 0)
+		corrected := filledSketch(t, 12, 0, WithBiasCorrection())
+
+		// Random keys so each seed gives a different sketch
+		rng := rand.New(rand.NewPCG(seed, 0))
+		added := 0
+		for _, n := range []int{10500, 11500, 12500} {
+			for ; added < n; added++ {
+				key := fmt.Sprintf("%x", rng.Uint64())
+				plain.Add(key)
+				corrected.Add(key)
+			}
+
+			plainBias += float64(plain.EstimateCardinality())/float64(n) - 1
+			correctedBias += float64(corrected.EstimateCardinality())/float64(n) - 1
+		}
+	}
+
+	if math.Abs(correctedBias) > math.Abs(plainBias)/2 {
+		t.Fatalf("got total bias %.3f with bias correction, wanted well under the %.3f without", correctedBias, plainBias)
+	}
+}
+
+func TestBiasCorrectionKeepsLinearCounting(t *testing.T) {
+	// Well under the threshold both fall back to linear counting over the same buckets
+	plain := filledSketch(t, 10, 300)
+	corrected := filledSketch(t, 10, 300, WithBiasCorrection())
+
+	if plain.EstimateCardinality() != corrected.EstimateCardinality() {
+		t.Fatalf("got %d with bias correction, wanted the linear counting estimate %d", corrected.EstimateCardinality(), plain.EstimateCardinality())
+	}
+}
@@ -0,0 +1,675 @@
+// Code generated by biasdata_gen.go; DO NOT EDIT.
+
+package pds
+
+// rawEstimateData holds the mean raw estimate at evenly spaced cardinalities up to
+// 5m for every valid number of index bits
+var rawEstimateData = map[uint32][]float64{
+	4: {
+		11.2, 11.7, 12.2, 12.7, 13.3, 13.8, 14.4, 15.0,
+		15.6, 16.2, 16.8, 17.4, 18.1, 18.8, 19.5, 20.2,
+		20.9, 21.6, 22.4, 23.1, 23.9, 24.7, 25.5, 26.3,
+		27.1, 27.9, 28.8, 29.6, 30.5, 31.4, 32.2, 33.1,
+		34.0, 34.9, 35.9, 36.8, 37.7, 38.6, 39.6, 40.5,
+		41.5, 42.4, 43.4, 44.3, 45.3, 46.3, 47.2, 48.2,
+		49.2, 50.2, 51.2, 52.1, 53.1, 54.1, 55.1, 56.1,
+		57.1, 58.1, 59.0, 60.0, 61.0, 62.0, 63.0, 64.0,
+		65.0, 66.0, 67.0, 68.0, 69.0, 70.0, 71.0, 72.0,
+		73.0, 74.0, 75.0, 76.0, 76.9, 78.0, 79.0, 79.9,
+	},
+	5: {
+		22.8, 23.3, 23.8, 24.2, 24.8, 25.3, 25.8, 26.3,
+		26.9, 27.4, 27.9, 28.5, 29.1, 29.6, 30.2, 30.8,
+		31.4, 32.0, 32.6, 33.2, 33.9, 34.5, 35.1, 35.8,
+		36.4, 37.1, 37.8, 38.4, 39.1, 39.8, 40.5, 41.2,
+		41.9, 42.6, 43.4, 44.1, 44.8, 45.6, 46.3, 47.1,
+		47.8, 48.6, 49.4, 50.2, 50.9, 51.7, 52.5, 53.3,
+		54.1, 55.0, 55.8, 56.6, 57.4, 58.3, 59.1, 60.0,
+		60.8, 61.7, 62.5, 63.4, 64.2, 65.1, 66.0, 66.9,
+		67.8, 68.7, 69.6, 70.5, 71.4, 72.3, 73.2, 74.1,
+		75.0, 75.9, 76.8, 77.7, 78.7, 79.6, 80.5, 81.5,
+		82.4, 83.3, 84.3, 85.2, 86.2, 87.1, 88.1, 89.0,
+		90.0, 90.9, 91.9, 92.8, 93.8, 94.8, 95.7, 96.7,
+		97.7, 98.6, 99.6, 100.6, 101.5, 102.5, 103.5, 104.5,
+		105.4, 106.4, 107.4, 108.4, 109.4, 110.3, 111.3, 112.3,
+		113.3, 114.3, 115.3, 116.3, 117.2, 118.2, 119.2, 120.2,
+		121.2, 122.2, 123.2, 124.2, 125.1, 126.1, 127.1, 128.1,
+		129.1, 130.1, 131.1, 132.1, 133.1, 134.1, 135.1, 136.1,
+		137.1, 138.1, 139.0, 140.0, 141.0, 142.0, 143.0, 144.0,
+		145.0, 146.0, 147.0, 148.0, 149.0, 150.0, 151.0, 152.0,
+		153.0, 154.0, 155.0, 156.0, 157.0, 158.0, 159.0, 160.0,
+	},
+	6: {
+		45.9, 46.8, 47.3, 48.3, 49.3, 49.8, 50.8, 51.4,
+		52.4, 53.5, 54.0, 55.1, 55.6, 56.7, 57.9, 58.4,
+		59.6, 60.1, 61.3, 62.5, 63.1, 64.3, 64.9, 66.1,
+		67.4, 68.0, 69.2, 69.9, 71.2, 72.4, 73.1, 74.4,
+		75.1, 76.4, 77.8, 78.5, 79.8, 80.5, 81.9, 83.3,
+		84.0, 85.4, 86.2, 87.6, 89.1, 89.8, 91.3, 92.0,
+		93.5, 95.0, 95.8, 97.3, 98.1, 99.6, 101.2, 101.9,
+		103.5, 104.3, 105.9, 107.5, 108.3, 109.9, 110.7, 112.3,
+		114.0, 114.8, 116.5, 117.3, 119.0, 120.6, 121.5, 123.2,
+		124.0, 125.7, 127.5, 128.3, 130.0, 130.9, 132.6, 134.4,
+		135.3, 137.0, 137.9, 139.7, 141.5, 142.3, 144.1, 145.0,
+		146.8, 148.6, 149.6, 151.4, 152.3, 154.1, 155.9, 156.9,
+		158.7, 159.6, 161.5, 163.3, 164.2, 166.1, 167.1, 168.9,
+		170.8, 171.7, 173.6, 174.6, 176.5, 178.4, 179.3, 181.2,
+		182.2, 184.1, 186.0, 186.9, 188.9, 189.8, 191.7, 193.7,
+		194.6, 196.6, 197.5, 199.5, 201.4, 202.4, 204.3, 205.3,
+		207.2, 209.1, 210.1, 212.1, 213.0, 215.0, 216.9, 217.9,
+		219.9, 220.9, 222.8, 224.8, 225.8, 227.8, 228.8, 230.7,
+		232.7, 233.7, 235.6, 236.6, 238.6, 240.6, 241.6, 243.5,
+		244.5, 246.5, 248.5, 249.5, 251.4, 252.4, 254.4, 256.4,
+		257.4, 259.4, 260.4, 262.3, 264.3, 265.3, 267.3, 268.3,
+		270.3, 272.3, 273.3, 275.3, 276.3, 278.3, 280.3, 281.3,
+		283.3, 284.3, 286.3, 288.2, 289.2, 291.2, 292.2, 294.2,
+		296.2, 297.2, 299.2, 300.2, 302.2, 304.2, 305.2, 307.2,
+		308.2, 310.1, 312.1, 313.1, 315.1, 316.1, 318.1, 320.1,
+	},
+	7: {
+		93.0, 94.5, 95.9, 97.4, 99.4, 101.0, 102.5, 104.1,
+		105.7, 107.8, 109.4, 111.1, 112.7, 114.4, 116.6, 118.3,
+		120.0, 121.8, 123.5, 125.9, 127.7, 129.5, 131.3, 133.2,
+		135.7, 137.5, 139.4, 141.3, 143.3, 145.8, 147.8, 149.8,
+		151.8, 153.8, 156.5, 158.5, 160.6, 162.6, 164.7, 167.6,
+		169.7, 171.8, 174.0, 176.1, 179.0, 181.2, 183.5, 185.7,
+		187.9, 190.9, 193.2, 195.5, 197.8, 200.1, 203.2, 205.6,
+		207.9, 210.3, 212.7, 215.9, 218.3, 220.7, 223.1, 225.6,
+		228.8, 231.3, 233.8, 236.3, 238.8, 242.1, 244.6, 247.1,
+		249.7, 252.3, 255.7, 258.3, 260.9, 263.5, 266.1, 269.6,
+		272.2, 274.8, 277.5, 280.1, 283.7, 286.3, 289.0, 291.7,
+		294.4, 298.0, 300.7, 303.4, 306.1, 308.9, 312.5, 315.3,
+		318.0, 320.8, 323.6, 327.3, 330.0, 332.8, 335.6, 338.4,
+		342.2, 345.0, 347.8, 350.6, 353.4, 357.2, 360.0, 362.9,
+		365.8, 368.6, 372.4, 375.2, 378.1, 381.0, 383.8, 387.7,
+		390.6, 393.5, 396.3, 399.2, 403.1, 406.0, 408.9, 411.8,
+		414.7, 418.6, 421.5, 424.5, 427.4, 430.3, 434.2, 437.1,
+		440.1, 443.0, 445.9, 449.8, 452.8, 455.8, 458.7, 461.6,
+		465.6, 468.5, 471.5, 474.4, 477.3, 481.2, 484.2, 487.2,
+		490.1, 493.1, 497.0, 500.0, 502.9, 505.9, 508.9, 512.9,
+		515.8, 518.8, 521.8, 524.8, 528.7, 531.7, 534.7, 537.7,
+		540.7, 544.6, 547.6, 550.6, 553.6, 556.5, 560.5, 563.5,
+		566.5, 569.4, 572.5, 576.4, 579.4, 582.4, 585.3, 588.3,
+		592.3, 595.3, 598.3, 601.3, 604.2, 608.2, 611.2, 614.2,
+		617.2, 620.2, 624.1, 627.1, 630.1, 633.1, 636.1, 640.1,
+	},
+	8: {
+		186.8, 189.7, 193.2, 196.2, 199.7, 202.8, 205.9, 209.5,
+		212.7, 216.4, 219.7, 223.0, 226.8, 230.2, 234.1, 237.5,
+		241.0, 245.1, 248.6, 252.7, 256.3, 259.9, 264.2, 267.9,
+		272.2, 276.0, 279.8, 284.2, 288.1, 292.6, 296.6, 300.5,
+		305.1, 309.2, 313.9, 318.0, 322.1, 326.9, 331.1, 336.0,
+		340.2, 344.5, 349.5, 353.9, 359.0, 363.4, 367.8, 373.0,
+		377.5, 382.7, 387.2, 391.8, 397.2, 401.8, 407.2, 411.9,
+		416.6, 422.1, 426.8, 432.4, 437.2, 442.0, 447.7, 452.6,
+		458.3, 463.2, 468.2, 474.0, 479.0, 484.8, 489.8, 494.9,
+		500.8, 505.9, 511.9, 517.1, 522.2, 528.3, 533.5, 539.6,
+		544.8, 550.1, 556.3, 561.6, 567.8, 573.1, 578.4, 584.7,
+		590.0, 596.4, 601.8, 607.2, 613.6, 619.0, 625.4, 630.9,
+		636.4, 642.8, 648.4, 654.8, 660.3, 665.9, 672.4, 678.1,
+		684.6, 690.2, 695.8, 702.4, 708.0, 714.6, 720.3, 726.0,
+		732.6, 738.3, 745.0, 750.7, 756.4, 763.1, 768.9, 775.6,
+		781.4, 787.1, 793.8, 799.6, 806.3, 812.1, 818.0, 824.7,
+		830.5, 837.3, 843.2, 849.0, 855.8, 861.7, 868.5, 874.3,
+		880.2, 887.0, 892.9, 899.8, 905.6, 911.5, 918.4, 924.2,
+		931.0, 936.9, 942.8, 949.7, 955.6, 962.5, 968.4, 974.3,
+		981.3, 987.2, 994.1, 1000.0, 1005.9, 1012.9, 1018.8, 1025.7,
+		1031.6, 1037.5, 1044.4, 1050.4, 1057.3, 1063.2, 1069.2, 1076.1,
+		1082.1, 1089.0, 1095.0, 1101.0, 1108.0, 1114.0, 1121.0, 1126.9,
+		1132.9, 1139.9, 1145.9, 1153.0, 1158.9, 1164.9, 1171.8, 1177.9,
+		1184.8, 1190.7, 1196.8, 1203.8, 1209.7, 1216.6, 1222.6, 1228.6,
+		1235.6, 1241.6, 1248.6, 1254.6, 1260.6, 1267.6, 1273.6, 1280.6,
+	},
+	9: {
+		374.3, 380.7, 387.1, 393.6, 400.2, 406.3, 413.1, 419.9,
+		426.7, 433.7, 440.2, 447.3, 454.5, 461.7, 469.1, 475.9,
+		483.4, 490.9, 498.6, 506.3, 513.4, 521.3, 529.2, 537.2,
+		545.3, 552.8, 561.0, 569.3, 577.7, 586.1, 594.0, 602.5,
+		611.2, 619.9, 628.7, 636.8, 645.8, 654.7, 663.8, 672.9,
+		681.4, 690.7, 700.0, 709.4, 718.8, 727.6, 737.2, 746.9,
+		756.6, 766.4, 775.5, 785.3, 795.3, 805.3, 815.3, 824.7,
+		834.9, 845.1, 855.5, 865.8, 875.5, 885.9, 896.4, 907.0,
+		917.6, 927.4, 938.1, 948.9, 959.7, 970.6, 980.7, 991.7,
+		1002.7, 1013.7, 1024.8, 1035.1, 1046.2, 1057.5, 1068.7, 1080.0,
+		1090.4, 1101.8, 1113.3, 1124.8, 1136.2, 1146.9, 1158.5, 1170.1,
+		1181.8, 1193.5, 1204.4, 1216.2, 1228.0, 1239.8, 1251.6, 1262.6,
+		1274.5, 1286.4, 1298.4, 1310.3, 1321.3, 1333.4, 1345.5, 1357.7,
+		1369.8, 1381.0, 1393.2, 1405.3, 1417.5, 1429.8, 1441.0, 1453.4,
+		1465.7, 1478.0, 1490.3, 1501.7, 1514.1, 1526.5, 1539.0, 1551.5,
+		1563.0, 1575.5, 1588.0, 1600.5, 1613.0, 1624.4, 1637.0, 1649.5,
+		1662.0, 1674.5, 1686.2, 1698.8, 1711.4, 1724.0, 1736.7, 1748.3,
+		1760.9, 1773.6, 1786.3, 1799.0, 1810.7, 1823.4, 1836.1, 1848.8,
+		1861.6, 1873.4, 1886.1, 1898.9, 1911.7, 1924.5, 1936.2, 1949.1,
+		1962.0, 1974.8, 1987.6, 1999.4, 2012.2, 2024.9, 2037.8, 2050.7,
+		2062.6, 2075.4, 2088.3, 2101.2, 2114.1, 2126.0, 2138.9, 2151.8,
+		2164.6, 2177.5, 2189.3, 2202.2, 2215.0, 2228.0, 2241.0, 2253.0,
+		2265.9, 2278.7, 2291.6, 2304.5, 2316.4, 2329.3, 2342.2, 2355.1,
+		2368.0, 2379.9, 2392.8, 2405.7, 2418.8, 2431.7, 2443.7, 2456.6,
+		2469.6, 2482.4, 2495.5, 2507.5, 2520.5, 2533.5, 2546.4, 2559.3,
+	},
+	10: {
+		749.9, 762.6, 775.0, 788.0, 801.2, 813.9, 827.4, 840.5,
+		854.3, 868.2, 881.7, 895.9, 909.7, 924.3, 939.0, 953.2,
+		968.1, 982.7, 998.0, 1013.4, 1028.4, 1044.1, 1059.4, 1075.4,
+		1091.5, 1107.2, 1123.6, 1139.5, 1156.3, 1173.1, 1189.5, 1206.6,
+		1223.2, 1240.6, 1258.3, 1275.3, 1293.1, 1310.4, 1328.5, 1346.8,
+		1364.5, 1383.1, 1401.1, 1419.8, 1438.7, 1457.1, 1476.2, 1494.8,
+		1514.2, 1533.7, 1552.7, 1572.5, 1591.7, 1611.7, 1631.8, 1651.3,
+		1671.8, 1691.5, 1712.1, 1732.8, 1752.9, 1773.8, 1793.9, 1815.0,
+		1836.1, 1856.7, 1878.1, 1898.8, 1920.4, 1942.2, 1963.3, 1985.2,
+		2006.3, 2028.3, 2050.6, 2071.9, 2094.3, 2116.0, 2138.6, 2161.3,
+		2183.1, 2205.9, 2228.0, 2250.9, 2273.8, 2296.1, 2319.3, 2341.8,
+		2365.1, 2388.4, 2410.9, 2434.4, 2457.0, 2480.7, 2504.3, 2527.2,
+		2551.2, 2574.0, 2598.0, 2621.9, 2645.1, 2669.2, 2692.4, 2716.7,
+		2741.0, 2764.4, 2788.7, 2812.3, 2836.8, 2861.2, 2884.9, 2909.4,
+		2933.2, 2957.7, 2982.3, 3006.0, 3030.7, 3054.4, 3079.3, 3103.9,
+		3127.8, 3152.7, 3176.8, 3201.8, 3226.9, 3250.9, 3276.1, 3300.2,
+		3325.4, 3350.5, 3374.7, 3400.2, 3424.2, 3449.6, 3475.0, 3499.3,
+		3524.8, 3549.4, 3574.8, 3600.2, 3624.5, 3650.0, 3674.5, 3699.8,
+		3725.5, 3750.0, 3775.6, 3800.2, 3825.7, 3851.3, 3876.1, 3901.6,
+		3926.4, 3952.1, 3977.7, 4002.2, 4027.9, 4052.5, 4078.3, 4104.2,
+		4129.1, 4154.6, 4179.4, 4205.0, 4230.7, 4255.5, 4281.4, 4306.2,
+		4331.9, 4357.8, 4382.6, 4408.3, 4432.9, 4458.8, 4484.6, 4509.4,
+		4535.4, 4560.5, 4586.3, 4612.3, 4637.2, 4663.0, 4688.2, 4714.1,
+		4740.2, 4765.1, 4791.0, 4816.0, 4842.1, 4868.0, 4892.8, 4918.6,
+		4943.4, 4969.3, 4995.4, 5020.4, 5046.3, 5071.0, 5096.8, 5122.6,
+	},
+	11: {
+		1501.1, 1526.1, 1551.3, 1576.9, 1603.2, 1629.4, 1655.8, 1682.5,
+		1709.5, 1737.4, 1765.0, 1792.8, 1821.0, 1849.5, 1878.9, 1908.0,
+		1937.3, 1967.0, 1996.9, 2027.7, 2058.1, 2089.0, 2120.0, 2151.4,
+		2183.8, 2215.8, 2248.0, 2280.5, 2313.2, 2347.0, 2380.4, 2414.0,
+		2447.9, 2482.1, 2517.2, 2552.0, 2587.0, 2622.2, 2657.7, 2694.2,
+		2730.4, 2766.8, 2803.4, 2840.3, 2878.1, 2915.6, 2953.2, 2990.9,
+		3029.0, 3068.2, 3106.8, 3145.5, 3184.5, 3223.9, 3264.1, 3303.8,
+		3343.5, 3383.6, 3424.0, 3465.4, 3506.2, 3547.3, 3588.5, 3630.0,
+		3672.8, 3714.6, 3756.6, 3798.9, 3841.5, 3885.0, 3927.8, 3971.0,
+		4014.0, 4057.3, 4101.6, 4145.3, 4189.2, 4233.3, 4277.5, 4322.6,
+		4367.1, 4411.6, 4456.7, 4501.6, 4547.4, 4592.3, 4637.7, 4683.5,
+		4729.1, 4775.6, 4821.8, 4867.9, 4914.3, 4960.6, 5007.7, 5054.1,
+		5100.7, 5147.4, 5194.5, 5242.4, 5289.6, 5336.5, 5383.9, 5431.3,
+		5479.3, 5527.0, 5574.8, 5622.7, 5670.4, 5719.2, 5767.3, 5815.4,
+		5863.8, 5912.0, 5961.0, 6009.3, 6057.8, 6106.4, 6155.0, 6205.0,
+		6253.7, 6302.4, 6351.5, 6400.6, 6450.6, 6499.7, 6548.8, 6598.1,
+		6647.4, 6698.0, 6747.3, 6796.5, 6846.2, 6895.4, 6945.8, 6995.5,
+		7045.0, 7095.0, 7144.9, 7195.9, 7245.5, 7295.2, 7345.2, 7395.5,
+		7446.6, 7496.6, 7546.5, 7596.7, 7646.9, 7698.0, 7748.1, 7798.7,
+		7848.6, 7899.0, 7950.5, 8000.8, 8051.1, 8101.1, 8151.2, 8202.9,
+		8253.2, 8303.8, 8354.4, 8404.4, 8455.4, 8505.9, 8556.5, 8606.9,
+		8657.9, 8709.5, 8760.1, 8810.6, 8861.2, 8912.0, 8964.4, 9015.2,
+		9065.8, 9116.7, 9167.6, 9219.4, 9270.0, 9320.7, 9371.2, 9422.1,
+		9474.1, 9525.3, 9576.2, 9627.3, 9678.2, 9730.2, 9781.1, 9831.9,
+		9882.9, 9933.8, 9986.1, 10036.4, 10087.4, 10138.1, 10188.8, 10240.3,
+	},
+	12: {
+		3003.0, 3052.9, 3103.9, 3155.0, 3207.2, 3259.4, 3312.3, 3366.4,
+		3420.3, 3475.5, 3530.6, 3586.5, 3643.4, 3700.3, 3758.5, 3816.8,
+		3875.6, 3935.5, 3995.4, 4056.4, 4117.3, 4178.9, 4241.8, 4304.5,
+		4368.4, 4432.3, 4496.8, 4562.6, 4628.1, 4694.8, 4761.7, 4828.9,
+		4897.3, 4965.6, 5035.2, 5104.6, 5174.7, 5245.9, 5317.1, 5389.4,
+		5461.5, 5534.1, 5607.8, 5681.6, 5756.5, 5831.6, 5906.9, 5983.3,
+		6059.1, 6136.6, 6213.7, 6291.4, 6370.2, 6448.6, 6528.1, 6607.3,
+		6687.1, 6768.0, 6848.6, 6930.7, 7012.6, 7094.7, 7178.3, 7261.4,
+		7345.7, 7429.4, 7513.8, 7599.4, 7684.0, 7769.8, 7855.3, 7940.9,
+		8028.1, 8115.0, 8203.2, 8290.6, 8378.5, 8467.0, 8555.3, 8644.4,
+		8733.9, 8823.4, 8913.9, 9003.9, 9095.3, 9186.0, 9276.7, 9368.1,
+		9459.6, 9552.5, 9644.0, 9736.5, 9829.4, 9922.0, 10016.1, 10109.3,
+		10202.9, 10297.5, 10391.3, 10486.2, 10580.6, 10675.0, 10770.5, 10866.0,
+		10961.5, 11057.0, 11152.4, 11249.2, 11345.1, 11441.8, 11538.1, 11634.5,
+		11732.5, 11828.7, 11926.7, 12024.1, 12121.4, 12218.9, 12315.7, 12414.7,
+		12512.6, 12610.1, 12709.3, 12807.4, 12906.7, 13005.6, 13104.0, 13203.7,
+		13302.2, 13402.1, 13500.9, 13599.4, 13699.6, 13798.9, 13899.7, 13998.8,
+		14098.0, 14198.4, 14298.8, 14399.7, 14499.4, 14599.3, 14700.9, 14800.2,
+		14902.0, 15002.1, 15102.6, 15203.9, 15304.1, 15406.0, 15505.8, 15606.4,
+		15707.1, 15807.0, 15908.0, 16008.2, 16108.4, 16211.1, 16311.6, 16413.7,
+		16515.1, 16616.1, 16719.0, 16819.9, 16921.2, 17022.2, 17123.3, 17225.7,
+		17326.3, 17428.2, 17529.3, 17630.0, 17732.4, 17832.9, 17935.0, 18036.3,
+		18138.2, 18240.8, 18341.8, 18444.1, 18546.0, 18647.6, 18750.3, 18851.9,
+		18953.9, 19054.6, 19156.2, 19258.4, 19359.2, 19462.5, 19564.1, 19665.7,
+		19767.3, 19869.1, 19971.6, 20072.2, 20173.2, 20274.9, 20376.1, 20478.2,
+	},
+	13: {
+		6006.8, 6107.1, 6208.5, 6311.2, 6415.0, 6519.5, 6625.8, 6733.2,
+		6841.9, 6951.7, 7062.2, 7174.4, 7287.6, 7402.0, 7517.6, 7633.9,
+		7751.7, 7870.9, 7991.3, 8113.0, 8235.3, 8358.9, 8483.9, 8610.1,
+		8737.6, 8865.5, 8995.2, 9125.9, 9257.8, 9390.6, 9524.1, 9659.5,
+		9796.1, 9933.5, 10072.5, 10211.3, 10352.0, 10493.9, 10636.7, 10780.6,
+		10924.6, 11070.2, 11217.1, 11364.9, 11513.9, 11662.7, 11813.5, 11966.0,
+		12119.6, 12273.8, 12428.4, 12584.6, 12741.9, 12898.9, 13057.6, 13216.6,
+		13377.4, 13539.3, 13702.5, 13866.1, 14030.0, 14195.0, 14361.1, 14528.0,
+		14695.5, 14863.9, 15032.9, 15202.9, 15373.4, 15544.8, 15716.6, 15889.5,
+		16062.0, 16236.1, 16411.1, 16584.9, 16761.6, 16938.7, 17115.8, 17295.7,
+		17473.6, 17653.1, 17832.1, 18012.5, 18193.5, 18374.8, 18557.6, 18741.4,
+		18924.6, 19109.5, 19293.5, 19478.3, 19664.8, 19850.3, 20036.4, 20222.8,
+		20411.4, 20599.3, 20789.4, 20979.3, 21168.2, 21358.9, 21549.2, 21739.9,
+		21931.3, 22121.1, 22313.2, 22505.4, 22697.1, 22890.1, 23082.2, 23276.1,
+		23470.6, 23665.0, 23859.1, 24053.0, 24248.0, 24443.8, 24640.2, 24835.6,
+		25030.6, 25226.8, 25424.2, 25620.9, 25819.5, 26016.3, 26213.2, 26411.4,
+		26609.7, 26807.3, 27003.3, 27200.5, 27398.9, 27599.9, 27800.3, 28000.5,
+		28199.5, 28398.9, 28598.5, 28799.5, 28998.5, 29200.0, 29402.0, 29603.6,
+		29804.4, 30005.4, 30206.6, 30409.9, 30610.4, 30811.4, 31013.0, 31213.9,
+		31415.6, 31617.3, 31819.8, 32021.4, 32224.5, 32427.6, 32631.6, 32835.8,
+		33037.9, 33241.5, 33445.8, 33649.2, 33850.6, 34052.9, 34257.0, 34458.3,
+		34661.7, 34865.0, 35067.1, 35271.8, 35474.9, 35677.9, 35881.7, 36083.7,
+		36286.1, 36489.5, 36693.6, 36898.5, 37101.2, 37304.8, 37508.3, 37711.8,
+		37915.7, 38118.3, 38323.4, 38529.7, 38734.4, 38939.5, 39144.7, 39348.8,
+		39555.7, 39761.1, 39965.7, 40168.5, 40373.9, 40576.8, 40781.1, 40987.1,
+	},
+	14: {
+		12014.7, 12215.5, 12418.2, 12623.4, 12831.2, 13041.0, 13253.0, 13467.2,
+		13684.6, 13904.3, 14125.5, 14349.4, 14575.5, 14804.7, 15035.9, 15268.8,
+		15504.8, 15742.7, 15983.0, 16225.9, 16470.5, 16717.8, 16966.1, 17218.2,
+		17472.7, 17729.2, 17988.5, 18249.2, 18512.8, 18778.4, 19046.5, 19317.6,
+		19589.4, 19864.7, 20141.4, 20420.3, 20701.1, 20984.4, 21269.8, 21559.1,
+		21848.7, 22142.0, 22435.4, 22730.7, 23029.1, 23328.2, 23630.5, 23933.7,
+		24240.1, 24547.4, 24856.2, 25168.0, 25481.3, 25796.8, 26113.5, 26433.1,
+		26754.6, 27077.1, 27401.6, 27729.5, 28057.5, 28387.2, 28718.3, 29052.0,
+		29385.9, 29722.4, 30060.3, 30399.1, 30738.7, 31081.8, 31425.3, 31769.9,
+		32115.1, 32463.4, 32814.2, 33163.9, 33516.4, 33870.3, 34224.8, 34580.6,
+		34937.1, 35295.6, 35654.7, 36015.4, 36376.6, 36740.0, 37106.0, 37470.3,
+		37839.2, 38208.6, 38576.8, 38948.1, 39319.7, 39690.6, 40064.3, 40438.5,
+		40813.3, 41188.6, 41565.0, 41942.4, 42321.1, 42701.1, 43080.6, 43459.9,
+		43841.9, 44223.7, 44609.4, 44991.8, 45377.9, 45764.6, 46150.6, 46540.3,
+		46929.9, 47320.6, 47711.3, 48099.3, 48488.1, 48879.3, 49270.3, 49665.4,
+		50058.4, 50452.8, 50844.3, 51235.9, 51633.0, 52027.7, 52422.8, 52819.1,
+		53216.5, 53614.0, 54008.9, 54408.1, 54803.1, 55199.6, 55600.6, 55998.9,
+		56401.3, 56800.0, 57199.9, 57600.9, 58000.4, 58401.7, 58801.8, 59207.0,
+		59605.3, 60007.4, 60410.8, 60811.8, 61213.0, 61614.4, 62015.8, 62419.6,
+		62820.8, 63223.2, 63631.2, 64034.4, 64437.5, 64840.3, 65248.1, 65653.6,
+		66057.6, 66462.5, 66867.2, 67273.9, 67680.9, 68086.6, 68493.6, 68900.3,
+		69305.9, 69709.0, 70118.2, 70523.0, 70926.9, 71330.2, 71736.5, 72138.0,
+		72546.0, 72955.1, 73359.4, 73767.1, 74174.7, 74582.5, 74990.1, 75398.0,
+		75807.1, 76211.7, 76617.2, 77019.8, 77428.7, 77837.6, 78244.1, 78654.4,
+		79058.3, 79468.2, 79877.5, 80285.3, 80693.6, 81101.2, 81508.1, 81916.1,
+	},
+	15: {
+		24030.9, 24431.2, 24836.4, 25246.6, 25661.5, 26081.2, 26505.3, 26934.1,
+		27368.0, 27807.7, 28251.3, 28699.6, 29152.2, 29609.7, 30072.3, 30539.2,
+		31010.9, 31487.5, 31968.6, 32455.4, 32946.0, 33440.7, 33940.3, 34444.3,
+		34953.5, 35468.3, 35985.8, 36508.4, 37035.9, 37567.0, 38103.6, 38644.9,
+		39190.2, 39739.6, 40293.6, 40850.9, 41412.6, 41979.0, 42550.0, 43127.8,
+		43707.5, 44293.5, 44881.0, 45473.2, 46068.8, 46670.7, 47273.9, 47881.1,
+		48491.7, 49107.2, 49726.9, 50349.3, 50976.0, 51607.0, 52243.5, 52879.4,
+		53521.1, 54166.7, 54815.5, 55467.9, 56120.9, 56778.9, 57441.5, 58104.1,
+		58774.0, 59445.0, 60120.5, 60799.2, 61480.7, 62161.1, 62851.1, 63542.8,
+		64236.5, 64930.9, 65633.2, 66335.4, 67042.3, 67749.3, 68457.4, 69173.7,
+		69891.0, 70605.5, 71324.9, 72046.8, 72769.9, 73492.6, 74223.5, 74954.3,
+		75685.1, 76419.5, 77155.8, 77897.7, 78639.5, 79384.0, 80138.2, 80888.1,
+		81638.6, 82395.6, 83152.4, 83907.2, 84664.3, 85423.9, 86185.8, 86947.7,
+		87715.0, 88486.7, 89252.4, 90018.5, 90788.2, 91559.0, 92332.0, 93111.3,
+		93888.4, 94663.4, 95445.0, 96219.1, 96999.6, 97776.1, 98558.1, 99341.8,
+		100128.4, 100912.6, 101711.3, 102496.6, 103280.7, 104069.4, 104862.6, 105657.7,
+		106451.2, 107241.1, 108035.0, 108826.2, 109623.8, 110420.1, 111216.0, 112005.4,
+		112810.2, 113610.7, 114411.2, 115208.0, 116002.5, 116807.1, 117612.9, 118412.2,
+		119211.3, 120017.6, 120821.1, 121624.8, 122434.8, 123254.3, 124063.3, 124868.6,
+		125678.8, 126485.5, 127301.6, 128108.2, 128916.4, 129723.8, 130539.4, 131350.7,
+		132157.0, 132965.7, 133778.6, 134585.5, 135396.4, 136210.1, 137022.2, 137843.0,
+		138662.8, 139471.7, 140279.8, 141099.5, 141910.0, 142728.4, 143539.1, 144357.5,
+		145175.9, 145975.1, 146790.7, 147598.8, 148408.3, 149227.3, 150041.8, 150853.9,
+		151672.7, 152490.2, 153301.7, 154123.9, 154935.0, 155743.4, 156556.1, 157368.6,
+		158174.2, 158993.6, 159816.9, 160634.7, 161452.0, 162270.4, 163091.3, 163906.0,
+	},
+	16: {
+		48062.3, 48864.9, 49677.3, 50497.5, 51327.3, 52167.8, 53016.7, 53875.7,
+		54742.1, 55620.9, 56507.8, 57403.8, 58309.2, 59222.9, 60146.0, 61079.0,
+		62023.7, 62977.0, 63940.2, 64912.7, 65892.2, 66881.8, 67881.9, 68889.5,
+		69908.0, 70936.9, 71973.4, 73017.8, 74068.8, 75132.4, 76205.1, 77286.2,
+		78375.9, 79474.7, 80581.7, 81699.0, 82824.6, 83959.5, 85100.2, 86252.2,
+		87410.2, 88578.9, 89756.8, 90940.4, 92131.3, 93331.9, 94541.8, 95754.7,
+		96978.3, 98210.9, 99444.0, 100694.4, 101947.9, 103212.2, 104487.5, 105761.6,
+		107045.4, 108331.1, 109625.9, 110929.6, 112243.6, 113561.9, 114888.7, 116218.5,
+		117555.1, 118904.7, 120256.8, 121610.1, 122964.5, 124336.0, 125712.0, 127092.0,
+		128477.9, 129878.3, 131277.8, 132680.4, 134087.4, 135498.2, 136918.1, 138345.0,
+		139777.2, 141215.8, 142654.6, 144091.5, 145537.4, 146992.0, 148451.0, 149912.9,
+		151375.7, 152847.5, 154330.8, 155810.6, 157298.7, 158793.9, 160287.8, 161776.2,
+		163282.5, 164788.6, 166288.1, 167801.2, 169312.4, 170841.1, 172358.2, 173882.6,
+		175410.7, 176937.1, 178469.5, 180010.2, 181560.9, 183102.6, 184646.2, 186202.3,
+		187764.6, 189323.5, 190876.5, 192435.5, 193987.5, 195543.3, 197109.3, 198689.7,
+		200263.9, 201822.7, 203402.6, 204977.8, 206567.0, 208137.6, 209722.0, 211301.9,
+		212874.7, 214467.3, 216057.5, 217651.2, 219259.5, 220854.6, 222449.5, 224037.3,
+		225632.4, 227235.0, 228832.8, 230439.5, 232044.5, 233648.5, 235243.5, 236858.1,
+		238467.5, 240082.5, 241677.5, 243296.5, 244916.6, 246518.0, 248138.6, 249742.6,
+		251354.0, 252963.7, 254576.9, 256192.7, 257801.5, 259421.9, 261046.4, 262655.9,
+		264289.8, 265904.8, 267528.0, 269140.9, 270772.6, 272392.7, 274010.2, 275628.3,
+		277251.3, 278879.6, 280503.8, 282136.0, 283779.3, 285419.2, 287042.3, 288671.7,
+		290299.9, 291938.5, 293571.0, 295191.3, 296822.5, 298449.3, 300084.7, 301705.3,
+		303351.7, 304979.2, 306610.7, 308234.9, 309878.7, 311517.4, 313143.5, 314772.3,
+		316419.5, 318051.8, 319687.3, 321325.6, 322966.4, 324593.3, 326222.6, 327863.0,
+	},
+}
+
+// biasData holds how far each raw estimate in rawEstimateData is above the
+// cardinality it was simulated at
+var biasData = map[uint32][]float64{
+	4: {
+		10.2, 9.7, 9.2, 8.7, 8.3, 7.8, 7.4, 7.0,
+		6.6, 6.2, 5.8, 5.4, 5.1, 4.8, 4.5, 4.2,
+		3.9, 3.6, 3.4, 3.1, 2.9, 2.7, 2.5, 2.3,
+		2.1, 1.9, 1.8, 1.6, 1.5, 1.4, 1.2, 1.1,
+		1.0, 0.9, 0.9, 0.8, 0.7, 0.6, 0.6, 0.5,
+		0.5, 0.4, 0.4, 0.3, 0.3, 0.3, 0.2, 0.2,
+		0.2, 0.2, 0.2, 0.1, 0.1, 0.1, 0.1, 0.1,
+		0.1, 0.1, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0,
+		0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0,
+		0.0, 0.0, 0.0, 0.0, -0.1, 0.0, 0.0, -0.1,
+	},
+	5: {
+		21.8, 21.3, 20.8, 20.2, 19.8, 19.3, 18.8, 18.3,
+		17.9, 17.4, 16.9, 16.5, 16.1, 15.6, 15.2, 14.8,
+		14.4, 14.0, 13.6, 13.2, 12.9, 12.5, 12.1, 11.8,
+		11.4, 11.1, 10.8, 10.4, 10.1, 9.8, 9.5, 9.2,
+		8.9, 8.6, 8.4, 8.1, 7.8, 7.6, 7.3, 7.1,
+		6.8, 6.6, 6.4, 6.2, 5.9, 5.7, 5.5, 5.3,
+		5.1, 5.0, 4.8, 4.6, 4.4, 4.3, 4.1, 4.0,
+		3.8, 3.7, 3.5, 3.4, 3.2, 3.1, 3.0, 2.9,
+		2.8, 2.7, 2.6, 2.5, 2.4, 2.3, 2.2, 2.1,
+		2.0, 1.9, 1.8, 1.7, 1.7, 1.6, 1.5, 1.5,
+		1.4, 1.3, 1.3, 1.2, 1.2, 1.1, 1.1, 1.0,
+		1.0, 0.9, 0.9, 0.8, 0.8, 0.8, 0.7, 0.7,
+		0.7, 0.6, 0.6, 0.6, 0.5, 0.5, 0.5, 0.5,
+		0.4, 0.4, 0.4, 0.4, 0.4, 0.3, 0.3, 0.3,
+		0.3, 0.3, 0.3, 0.3, 0.2, 0.2, 0.2, 0.2,
+		0.2, 0.2, 0.2, 0.2, 0.1, 0.1, 0.1, 0.1,
+		0.1, 0.1, 0.1, 0.1, 0.1, 0.1, 0.1, 0.1,
+		0.1, 0.1, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0,
+		0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0,
+		0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0,
+	},
+	6: {
+		44.9, 43.8, 43.3, 42.3, 41.3, 40.8, 39.8, 39.4,
+		38.4, 37.5, 37.0, 36.1, 35.6, 34.7, 33.9, 33.4,
+		32.6, 32.1, 31.3, 30.5, 30.1, 29.3, 28.9, 28.1,
+		27.4, 27.0, 26.2, 25.9, 25.2, 24.4, 24.1, 23.4,
+		23.1, 22.4, 21.8, 21.5, 20.8, 20.5, 19.9, 19.3,
+		19.0, 18.4, 18.2, 17.6, 17.1, 16.8, 16.3, 16.0,
+		15.5, 15.0, 14.8, 14.3, 14.1, 13.6, 13.2, 12.9,
+		12.5, 12.3, 11.9, 11.5, 11.3, 10.9, 10.7, 10.3,
+		10.0, 9.8, 9.5, 9.3, 9.0, 8.6, 8.5, 8.2,
+		8.0, 7.7, 7.5, 7.3, 7.0, 6.9, 6.6, 6.4,
+		6.3, 6.0, 5.9, 5.7, 5.5, 5.3, 5.1, 5.0,
+		4.8, 4.6, 4.6, 4.4, 4.3, 4.1, 3.9, 3.9,
+		3.7, 3.6, 3.5, 3.3, 3.2, 3.1, 3.1, 2.9,
+		2.8, 2.7, 2.6, 2.6, 2.5, 2.4, 2.3, 2.2,
+		2.2, 2.1, 2.0, 1.9, 1.9, 1.8, 1.7, 1.7,
+		1.6, 1.6, 1.5, 1.5, 1.4, 1.4, 1.3, 1.3,
+		1.2, 1.1, 1.1, 1.1, 1.0, 1.0, 0.9, 0.9,
+		0.9, 0.9, 0.8, 0.8, 0.8, 0.8, 0.8, 0.7,
+		0.7, 0.7, 0.6, 0.6, 0.6, 0.6, 0.6, 0.5,
+		0.5, 0.5, 0.5, 0.5, 0.4, 0.4, 0.4, 0.4,
+		0.4, 0.4, 0.4, 0.3, 0.3, 0.3, 0.3, 0.3,
+		0.3, 0.3, 0.3, 0.3, 0.3, 0.3, 0.3, 0.3,
+		0.3, 0.3, 0.3, 0.2, 0.2, 0.2, 0.2, 0.2,
+		0.2, 0.2, 0.2, 0.2, 0.2, 0.2, 0.2, 0.2,
+		0.2, 0.1, 0.1, 0.1, 0.1, 0.1, 0.1, 0.1,
+	},
+	7: {
+		90.0, 88.5, 86.9, 85.4, 83.4, 82.0, 80.5, 79.1,
+		77.7, 75.8, 74.4, 73.1, 71.7, 70.4, 68.6, 67.3,
+		66.0, 64.8, 63.5, 61.9, 60.7, 59.5, 58.3, 57.2,
+		55.7, 54.5, 53.4, 52.3, 51.3, 49.8, 48.8, 47.8,
+		46.8, 45.8, 44.5, 43.5, 42.6, 41.6, 40.7, 39.6,
+		38.7, 37.8, 37.0, 36.1, 35.0, 34.2, 33.5, 32.7,
+		31.9, 30.9, 30.2, 29.5, 28.8, 28.1, 27.2, 26.6,
+		25.9, 25.3, 24.7, 23.9, 23.3, 22.7, 22.1, 21.6,
+		20.8, 20.3, 19.8, 19.3, 18.8, 18.1, 17.6, 17.1,
+		16.7, 16.3, 15.7, 15.3, 14.9, 14.5, 14.1, 13.6,
+		13.2, 12.8, 12.5, 12.1, 11.7, 11.3, 11.0, 10.7,
+		10.4, 10.0, 9.7, 9.4, 9.1, 8.9, 8.5, 8.3,
+		8.0, 7.8, 7.6, 7.3, 7.0, 6.8, 6.6, 6.4,
+		6.2, 6.0, 5.8, 5.6, 5.4, 5.2, 5.0, 4.9,
+		4.8, 4.6, 4.4, 4.2, 4.1, 4.0, 3.8, 3.7,
+		3.6, 3.5, 3.3, 3.2, 3.1, 3.0, 2.9, 2.8,
+		2.7, 2.6, 2.5, 2.5, 2.4, 2.3, 2.2, 2.1,
+		2.1, 2.0, 1.9, 1.8, 1.8, 1.8, 1.7, 1.6,
+		1.6, 1.5, 1.5, 1.4, 1.3, 1.2, 1.2, 1.2,
+		1.1, 1.1, 1.0, 1.0, 0.9, 0.9, 0.9, 0.9,
+		0.8, 0.8, 0.8, 0.8, 0.7, 0.7, 0.7, 0.7,
+		0.7, 0.6, 0.6, 0.6, 0.6, 0.5, 0.5, 0.5,
+		0.5, 0.4, 0.5, 0.4, 0.4, 0.4, 0.3, 0.3,
+		0.3, 0.3, 0.3, 0.3, 0.2, 0.2, 0.2, 0.2,
+		0.2, 0.2, 0.1, 0.1, 0.1, 0.1, 0.1, 0.1,
+	},
+	8: {
+		180.8, 177.7, 174.2, 171.2, 167.7, 164.8, 161.9, 158.5,
+		155.7, 152.4, 149.7, 147.0, 143.8, 141.2, 138.1, 135.5,
+		133.0, 130.1, 127.6, 124.7, 122.3, 119.9, 117.2, 114.9,
+		112.2, 110.0, 107.8, 105.2, 103.1, 100.6, 98.6, 96.5,
+		94.1, 92.2, 89.9, 88.0, 86.1, 83.9, 82.1, 80.0,
+		78.2, 76.5, 74.5, 72.9, 71.0, 69.4, 67.8, 66.0,
+		64.5, 62.7, 61.2, 59.8, 58.2, 56.8, 55.2, 53.9,
+		52.6, 51.1, 49.8, 48.4, 47.2, 46.0, 44.7, 43.6,
+		42.3, 41.2, 40.2, 39.0, 38.0, 36.8, 35.8, 34.9,
+		33.8, 32.9, 31.9, 31.1, 30.2, 29.3, 28.5, 27.6,
+		26.8, 26.1, 25.3, 24.6, 23.8, 23.1, 22.4, 21.7,
+		21.0, 20.4, 19.8, 19.2, 18.6, 18.0, 17.4, 16.9,
+		16.4, 15.8, 15.4, 14.8, 14.3, 13.9, 13.4, 13.1,
+		12.6, 12.2, 11.8, 11.4, 11.0, 10.6, 10.3, 10.0,
+		9.6, 9.3, 9.0, 8.7, 8.4, 8.1, 7.9, 7.6,
+		7.4, 7.1, 6.8, 6.6, 6.3, 6.1, 6.0, 5.7,
+		5.5, 5.3, 5.2, 5.0, 4.8, 4.7, 4.5, 4.3,
+		4.2, 4.0, 3.9, 3.8, 3.6, 3.5, 3.4, 3.2,
+		3.0, 2.9, 2.8, 2.7, 2.6, 2.5, 2.4, 2.3,
+		2.3, 2.2, 2.1, 2.0, 1.9, 1.9, 1.8, 1.7,
+		1.6, 1.5, 1.4, 1.4, 1.3, 1.2, 1.2, 1.1,
+		1.1, 1.0, 1.0, 1.0, 1.0, 1.0, 1.0, 0.9,
+		0.9, 0.9, 0.9, 1.0, 0.9, 0.9, 0.8, 0.9,
+		0.8, 0.7, 0.8, 0.8, 0.7, 0.6, 0.6, 0.6,
+		0.6, 0.6, 0.6, 0.6, 0.6, 0.6, 0.6, 0.6,
+	},
+	9: {
+		362.3, 355.7, 349.1, 342.6, 336.2, 330.3, 324.1, 317.9,
+		311.7, 305.7, 300.2, 294.3, 288.5, 282.7, 277.1, 271.9,
+		266.4, 260.9, 255.6, 250.3, 245.4, 240.3, 235.2, 230.2,
+		225.3, 220.8, 216.0, 211.3, 206.7, 202.1, 198.0, 193.5,
+		189.2, 184.9, 180.7, 176.8, 172.8, 168.7, 164.8, 160.9,
+		157.4, 153.7, 150.0, 146.4, 142.8, 139.6, 136.2, 132.9,
+		129.6, 126.4, 123.5, 120.3, 117.3, 114.3, 111.3, 108.7,
+		105.9, 103.1, 100.5, 97.8, 95.5, 92.9, 90.4, 88.0,
+		85.6, 83.4, 81.1, 78.9, 76.7, 74.6, 72.7, 70.7,
+		68.7, 66.7, 64.8, 63.1, 61.2, 59.5, 57.7, 56.0,
+		54.4, 52.8, 51.3, 49.8, 48.2, 46.9, 45.5, 44.1,
+		42.8, 41.5, 40.4, 39.2, 38.0, 36.8, 35.6, 34.6,
+		33.5, 32.4, 31.4, 30.3, 29.3, 28.4, 27.5, 26.7,
+		25.8, 25.0, 24.2, 23.3, 22.5, 21.8, 21.0, 20.4,
+		19.7, 19.0, 18.3, 17.7, 17.1, 16.5, 16.0, 15.5,
+		15.0, 14.5, 14.0, 13.5, 13.0, 12.4, 12.0, 11.5,
+		11.0, 10.5, 10.2, 9.8, 9.4, 9.0, 8.7, 8.3,
+		7.9, 7.6, 7.3, 7.0, 6.7, 6.4, 6.1, 5.8,
+		5.6, 5.4, 5.1, 4.9, 4.7, 4.5, 4.2, 4.1,
+		4.0, 3.8, 3.6, 3.4, 3.2, 2.9, 2.8, 2.7,
+		2.6, 2.4, 2.3, 2.2, 2.1, 2.0, 1.9, 1.8,
+		1.6, 1.5, 1.3, 1.2, 1.0, 1.0, 1.0, 1.0,
+		0.9, 0.7, 0.6, 0.5, 0.4, 0.3, 0.2, 0.1,
+		0.0, -0.1, -0.2, -0.3, -0.2, -0.3, -0.3, -0.4,
+		-0.4, -0.6, -0.5, -0.5, -0.5, -0.5, -0.6, -0.7,
+	},
+	10: {
+		724.9, 711.6, 699.0, 686.0, 673.2, 660.9, 648.4, 636.5,
+		624.3, 612.2, 600.7, 588.9, 577.7, 566.3, 555.0, 544.2,
+		533.1, 522.7, 512.0, 501.4, 491.4, 481.1, 471.4, 461.4,
+		451.5, 442.2, 432.6, 423.5, 414.3, 405.1, 396.5, 387.6,
+		379.2, 370.6, 362.3, 354.3, 346.1, 338.4, 330.5, 322.8,
+		315.5, 308.1, 301.1, 293.8, 286.7, 280.1, 273.2, 266.8,
+		260.2, 253.7, 247.7, 241.5, 235.7, 229.7, 223.8, 218.3,
+		212.8, 207.5, 202.1, 196.8, 191.9, 186.8, 181.9, 177.0,
+		172.1, 167.7, 163.1, 158.8, 154.4, 150.2, 146.3, 142.2,
+		138.3, 134.3, 130.6, 126.9, 123.3, 120.0, 116.6, 113.3,
+		110.1, 106.9, 104.0, 100.9, 97.8, 95.1, 92.3, 89.8,
+		87.1, 84.4, 81.9, 79.4, 77.0, 74.7, 72.3, 70.2,
+		68.2, 66.0, 64.0, 61.9, 60.1, 58.2, 56.4, 54.7,
+		53.0, 51.4, 49.7, 48.3, 46.8, 45.2, 43.9, 42.4,
+		41.2, 39.7, 38.3, 37.0, 35.7, 34.4, 33.3, 31.9,
+		30.8, 29.7, 28.8, 27.8, 26.9, 25.9, 25.1, 24.2,
+		23.4, 22.5, 21.7, 21.2, 20.2, 19.6, 19.0, 18.3,
+		17.8, 17.4, 16.8, 16.2, 15.5, 15.0, 14.5, 13.8,
+		13.5, 13.0, 12.6, 12.2, 11.7, 11.3, 11.1, 10.6,
+		10.4, 10.1, 9.7, 9.2, 8.9, 8.5, 8.3, 8.2,
+		8.1, 7.6, 7.4, 7.0, 6.7, 6.5, 6.4, 6.2,
+		5.9, 5.8, 5.6, 5.3, 4.9, 4.8, 4.6, 4.4,
+		4.4, 4.5, 4.3, 4.3, 4.2, 4.0, 4.2, 4.1,
+		4.2, 4.1, 4.0, 4.0, 4.1, 4.0, 3.8, 3.6,
+		3.4, 3.3, 3.4, 3.4, 3.3, 3.0, 2.8, 2.6,
+	},
+	11: {
+		1450.1, 1424.1, 1398.3, 1372.9, 1347.2, 1322.4, 1297.8, 1273.5,
+		1249.5, 1225.4, 1202.0, 1178.8, 1156.0, 1133.5, 1110.9, 1089.0,
+		1067.3, 1046.0, 1024.9, 1003.7, 983.1, 963.0, 943.0, 923.4,
+		903.8, 884.8, 866.0, 847.5, 829.2, 811.0, 793.4, 776.0,
+		758.9, 742.1, 725.2, 709.0, 693.0, 677.2, 661.7, 646.2,
+		631.4, 616.8, 602.4, 588.3, 574.1, 560.6, 547.2, 533.9,
+		521.0, 508.2, 495.8, 483.5, 471.5, 459.9, 448.1, 436.8,
+		425.5, 414.6, 404.0, 393.4, 383.2, 373.3, 363.5, 354.0,
+		344.8, 335.6, 326.6, 317.9, 309.5, 301.0, 292.8, 285.0,
+		277.0, 269.3, 261.6, 254.3, 247.2, 240.3, 233.5, 226.6,
+		220.1, 213.6, 207.7, 201.6, 195.4, 189.3, 183.7, 178.5,
+		173.1, 167.6, 162.8, 157.9, 153.3, 148.6, 143.7, 139.1,
+		134.7, 130.4, 126.5, 122.4, 118.6, 114.5, 110.9, 107.3,
+		103.3, 100.0, 96.8, 93.7, 90.4, 87.2, 84.3, 81.4,
+		78.8, 76.0, 73.0, 70.3, 67.8, 65.4, 63.0, 61.0,
+		58.7, 56.4, 54.5, 52.6, 50.6, 48.7, 46.8, 45.1,
+		43.4, 42.0, 40.3, 38.5, 37.2, 35.4, 33.8, 32.5,
+		31.0, 30.0, 28.9, 27.9, 26.5, 25.2, 24.2, 23.5,
+		22.6, 21.6, 20.5, 19.7, 18.9, 18.0, 17.1, 16.7,
+		15.6, 15.0, 14.5, 13.8, 13.1, 12.1, 11.2, 10.9,
+		10.2, 9.8, 9.4, 8.4, 7.4, 6.9, 6.5, 5.9,
+		5.9, 5.5, 5.1, 4.6, 4.2, 4.0, 4.4, 4.2,
+		3.8, 3.7, 3.6, 3.4, 3.0, 2.7, 2.2, 2.1,
+		2.1, 2.3, 2.2, 2.3, 2.2, 2.2, 2.1, 1.9,
+		1.9, 1.8, 2.1, 1.4, 1.4, 1.1, 0.8, 0.3,
+	},
+	12: {
+		2901.0, 2848.9, 2796.9, 2746.0, 2695.2, 2645.4, 2596.3, 2547.4,
+		2499.3, 2451.5, 2404.6, 2358.5, 2312.4, 2267.3, 2222.5, 2178.8,
+		2135.6, 2092.5, 2050.4, 2008.4, 1967.3, 1926.9, 1886.8, 1847.5,
+		1808.4, 1770.3, 1732.8, 1695.6, 1659.1, 1622.8, 1587.7, 1552.9,
+		1518.3, 1484.6, 1451.2, 1418.6, 1386.7, 1354.9, 1324.1, 1293.4,
+		1263.5, 1234.1, 1204.8, 1176.6, 1148.5, 1121.6, 1094.9, 1068.3,
+		1042.1, 1016.6, 991.7, 967.4, 943.2, 919.6, 896.1, 873.3,
+		851.1, 829.0, 807.6, 786.7, 766.6, 746.7, 727.3, 708.4,
+		689.7, 671.4, 653.8, 636.4, 619.0, 601.8, 585.3, 568.9,
+		553.1, 538.0, 523.2, 508.6, 494.5, 480.0, 466.3, 452.4,
+		439.9, 427.4, 414.9, 402.9, 391.3, 380.0, 368.7, 357.1,
+		346.6, 336.5, 326.0, 316.5, 306.4, 297.0, 288.1, 279.3,
+		270.9, 262.5, 254.3, 246.2, 238.6, 231.0, 223.5, 217.0,
+		209.5, 203.0, 196.4, 190.2, 184.1, 177.8, 172.1, 166.5,
+		161.5, 155.7, 150.7, 146.1, 141.4, 135.9, 130.7, 126.7,
+		122.6, 118.1, 114.3, 110.4, 106.7, 103.6, 100.0, 96.7,
+		93.2, 90.1, 86.9, 83.4, 80.6, 77.9, 75.7, 72.8,
+		70.0, 67.4, 65.8, 63.7, 61.4, 59.3, 57.9, 55.2,
+		54.0, 52.1, 50.6, 48.9, 47.1, 46.0, 43.8, 42.4,
+		40.1, 38.0, 36.0, 34.2, 32.4, 32.1, 30.6, 29.7,
+		29.1, 28.1, 28.0, 26.9, 25.2, 24.2, 23.3, 22.7,
+		21.3, 20.2, 19.3, 18.0, 17.4, 15.9, 15.0, 14.3,
+		14.2, 13.8, 12.8, 12.1, 12.0, 11.6, 11.3, 10.9,
+		9.9, 8.6, 8.2, 7.4, 6.2, 6.5, 6.1, 5.7,
+		4.3, 4.1, 3.6, 2.2, 1.2, -0.1, -0.9, -1.8,
+	},
+	13: {
+		5802.8, 5698.1, 5594.5, 5492.2, 5391.0, 5291.5, 5192.8, 5095.2,
+		4998.9, 4903.7, 4810.2, 4717.4, 4625.6, 4535.0, 4445.6, 4357.9,
+		4270.7, 4184.9, 4100.3, 4017.0, 3935.3, 3853.9, 3773.9, 3695.1,
+		3617.6, 3541.5, 3466.2, 3391.9, 3318.8, 3246.6, 3176.1, 3106.5,
+		3038.1, 2970.5, 2904.5, 2839.3, 2775.0, 2711.9, 2649.7, 2588.6,
+		2528.6, 2469.2, 2411.1, 2353.9, 2297.9, 2242.7, 2188.5, 2136.0,
+		2084.6, 2033.8, 1984.4, 1935.6, 1887.9, 1839.9, 1793.6, 1748.6,
+		1704.4, 1661.3, 1619.5, 1578.1, 1538.0, 1498.0, 1459.1, 1421.0,
+		1383.5, 1347.9, 1311.9, 1276.9, 1242.4, 1208.8, 1176.6, 1144.5,
+		1112.0, 1081.1, 1051.1, 1020.9, 992.6, 964.7, 936.8, 911.7,
+		885.6, 860.1, 834.1, 809.5, 785.5, 762.8, 740.6, 719.4,
+		697.6, 677.5, 657.5, 637.3, 618.8, 599.3, 580.4, 562.8,
+		546.4, 529.3, 514.4, 499.3, 484.2, 469.9, 455.2, 440.9,
+		427.3, 413.1, 400.2, 387.4, 374.1, 362.1, 350.2, 339.1,
+		328.6, 318.0, 307.1, 297.0, 287.0, 277.8, 269.2, 259.6,
+		250.6, 241.8, 234.2, 225.9, 219.5, 212.3, 204.2, 197.4,
+		190.7, 183.3, 175.3, 167.5, 160.9, 156.9, 152.3, 148.5,
+		142.5, 136.9, 131.5, 127.5, 122.5, 119.0, 116.0, 112.6,
+		108.4, 105.4, 101.6, 99.9, 95.4, 91.4, 89.0, 84.9,
+		81.6, 78.3, 75.8, 73.4, 71.5, 69.6, 68.6, 67.8,
+		65.9, 64.5, 63.8, 62.2, 58.6, 56.9, 56.0, 52.3,
+		50.7, 49.0, 47.1, 46.8, 44.9, 42.9, 41.7, 39.7,
+		37.1, 35.5, 34.6, 34.5, 33.2, 31.8, 30.3, 28.8,
+		27.7, 26.3, 26.4, 27.7, 27.4, 27.5, 28.7, 27.8,
+		29.7, 30.1, 29.7, 28.5, 28.9, 26.8, 26.1, 27.1,
+	},
+	14: {
+		11605.7, 11396.5, 11190.2, 10985.4, 10783.2, 10584.0, 10386.0, 10191.2,
+		9998.6, 9808.3, 9620.5, 9434.4, 9251.5, 9070.7, 8891.9, 8715.8,
+		8541.8, 8370.7, 8201.0, 8033.9, 7869.5, 7706.8, 7546.1, 7388.2,
+		7232.7, 7080.2, 6929.5, 6781.2, 6634.8, 6490.4, 6349.5, 6210.6,
+		6073.4, 5938.7, 5805.4, 5675.3, 5546.1, 5420.4, 5295.8, 5175.1,
+		5055.7, 4939.0, 4823.4, 4708.7, 4597.1, 4487.2, 4379.5, 4273.7,
+		4170.1, 4067.4, 3967.2, 3869.0, 3773.3, 3678.8, 3585.5, 3496.1,
+		3407.6, 3321.1, 3235.6, 3153.5, 3072.5, 2992.2, 2914.3, 2838.0,
+		2761.9, 2689.4, 2617.3, 2547.1, 2476.7, 2409.8, 2344.3, 2278.9,
+		2215.1, 2153.4, 2094.2, 2034.9, 1977.4, 1922.3, 1866.8, 1812.6,
+		1760.1, 1708.6, 1658.7, 1609.4, 1560.6, 1515.0, 1471.0, 1426.3,
+		1385.2, 1344.6, 1303.8, 1265.1, 1227.7, 1188.6, 1152.3, 1117.5,
+		1082.3, 1048.6, 1015.0, 982.4, 952.1, 922.1, 892.6, 861.9,
+		833.9, 806.7, 782.4, 755.8, 731.9, 708.6, 685.6, 665.3,
+		645.9, 626.6, 607.3, 586.3, 565.1, 547.3, 528.3, 513.4,
+		497.4, 481.8, 464.3, 445.9, 433.0, 418.7, 403.8, 391.1,
+		378.5, 366.0, 351.9, 341.1, 327.1, 313.6, 304.6, 293.9,
+		286.3, 276.0, 265.9, 256.9, 247.4, 238.7, 229.8, 225.0,
+		213.3, 206.4, 199.8, 191.8, 183.0, 174.4, 166.8, 160.6,
+		152.8, 145.2, 143.2, 137.4, 130.5, 124.3, 122.1, 117.6,
+		112.6, 107.5, 103.2, 99.9, 96.9, 93.6, 90.6, 88.3,
+		83.9, 77.0, 77.2, 72.0, 66.9, 60.2, 56.5, 49.0,
+		47.0, 47.1, 41.4, 39.1, 37.7, 35.5, 34.1, 32.0,
+		31.1, 26.7, 22.2, 15.8, 14.7, 13.6, 11.1, 11.4,
+		6.3, 6.2, 5.5, 4.3, 2.6, 1.2, -1.9, -3.9,
+	},
+	15: {
+		23211.9, 22793.2, 22379.4, 21970.6, 21565.5, 21166.2, 20771.3, 20381.1,
+		19996.0, 19615.7, 19240.3, 18869.6, 18503.2, 18141.7, 17784.3, 17432.2,
+		17084.9, 16742.5, 16404.6, 16071.4, 15743.0, 15418.7, 15099.3, 14784.3,
+		14473.5, 14169.3, 13867.8, 13571.4, 13279.9, 12991.0, 12708.6, 12430.9,
+		12157.2, 11887.6, 11621.6, 11359.9, 11102.6, 10850.0, 10602.0, 10359.8,
+		10120.5, 9887.5, 9656.0, 9429.2, 9204.8, 8987.7, 8771.9, 8560.1,
+		8351.7, 8147.2, 7947.9, 7751.3, 7559.0, 7371.0, 7187.5, 7004.4,
+		6827.1, 6653.7, 6483.5, 6315.9, 6149.9, 5988.9, 5832.5, 5676.1,
+		5526.0, 5378.0, 5234.5, 5094.2, 4956.7, 4817.1, 4688.1, 4560.8,
+		4435.5, 4310.9, 4193.2, 4076.4, 3964.3, 3852.3, 3741.4, 3637.7,
+		3536.0, 3431.5, 3331.9, 3234.8, 3137.9, 3041.6, 2953.5, 2865.3,
+		2777.1, 2691.5, 2608.8, 2531.7, 2454.5, 2380.0, 2314.2, 2245.1,
+		2176.6, 2114.6, 2052.4, 1987.2, 1925.3, 1865.9, 1808.8, 1751.7,
+		1699.0, 1651.7, 1598.4, 1545.5, 1496.2, 1447.0, 1401.0, 1361.3,
+		1319.4, 1275.4, 1237.0, 1192.1, 1153.6, 1111.1, 1074.1, 1037.8,
+		1005.4, 970.6, 950.3, 916.6, 880.7, 850.4, 824.6, 800.7,
+		775.2, 745.1, 720.0, 692.2, 670.8, 648.1, 624.0, 594.4,
+		580.2, 561.7, 543.2, 520.0, 495.5, 481.1, 467.9, 448.2,
+		427.3, 414.6, 399.1, 383.8, 374.8, 374.3, 364.3, 350.6,
+		341.8, 329.5, 325.6, 313.2, 302.4, 290.8, 287.4, 278.7,
+		266.0, 255.7, 249.6, 237.5, 228.4, 223.1, 216.2, 218.0,
+		218.8, 207.7, 196.8, 197.5, 189.0, 188.4, 179.1, 178.5,
+		177.9, 158.1, 154.7, 142.8, 133.3, 133.3, 128.8, 121.9,
+		120.7, 119.2, 111.7, 114.9, 107.0, 95.4, 89.1, 82.6,
+		69.2, 69.6, 72.9, 71.7, 70.0, 69.4, 71.3, 66.0,
+	},
+	16: {
+		46424.3, 45588.9, 44762.3, 43944.5, 43135.3, 42337.8, 41548.7, 40768.7,
+		39997.1, 39236.9, 38485.8, 37743.8, 37010.2, 36285.9, 35570.0, 34865.0,
+		34171.7, 33486.0, 32811.2, 32144.7, 31486.2, 30837.8, 30198.9, 29568.5,
+		28948.0, 28338.9, 27737.4, 27142.8, 26555.8, 25980.4, 25415.1, 24858.2,
+		24308.9, 23769.7, 23237.7, 22717.0, 22204.6, 21700.5, 21203.2, 20716.2,
+		20236.2, 19766.9, 19305.8, 18851.4, 18403.3, 17965.9, 17537.8, 17111.7,
+		16697.3, 16290.9, 15886.0, 15498.4, 15112.9, 14739.2, 14375.5, 14011.6,
+		13657.4, 13304.1, 12960.9, 12625.6, 12301.6, 11981.9, 11669.7, 11361.5,
+		11059.1, 10770.7, 10484.8, 10199.1, 9915.5, 9648.0, 9386.0, 9128.0,
+		8874.9, 8637.3, 8397.8, 8162.4, 7931.4, 7703.2, 7485.1, 7273.0,
+		7067.2, 6867.8, 6667.6, 6466.5, 6273.4, 6090.0, 5911.0, 5733.9,
+		5558.7, 5391.5, 5236.8, 5078.6, 4927.7, 4784.9, 4639.8, 4490.2,
+		4358.5, 4225.6, 4087.1, 3961.2, 3834.4, 3725.1, 3603.2, 3489.6,
+		3378.7, 3267.1, 3161.5, 3063.2, 2975.9, 2878.6, 2784.2, 2702.3,
+		2625.6, 2546.5, 2460.5, 2381.5, 2295.5, 2212.3, 2140.3, 2081.7,
+		2017.9, 1938.7, 1879.6, 1816.8, 1767.0, 1699.6, 1646.0, 1586.9,
+		1521.7, 1475.3, 1427.5, 1383.2, 1352.5, 1309.6, 1265.5, 1215.3,
+		1172.4, 1136.0, 1095.8, 1063.5, 1030.5, 996.5, 952.5, 929.1,
+		899.5, 876.5, 833.5, 813.5, 795.6, 758.0, 740.6, 706.6,
+		679.0, 650.7, 624.9, 602.7, 573.5, 554.9, 541.4, 511.9,
+		507.8, 484.8, 469.0, 443.9, 436.6, 418.7, 398.2, 377.3,
+		362.3, 351.6, 337.8, 332.0, 336.3, 338.2, 322.3, 313.7,
+		303.9, 303.5, 298.0, 279.3, 272.5, 261.3, 257.7, 240.3,
+		247.7, 237.2, 230.7, 215.9, 221.7, 221.4, 209.5, 200.3,
+		208.5, 202.8, 199.3, 199.6, 202.4, 190.3, 181.6, 183.0,
+	},
+}
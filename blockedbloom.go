@@ -0,0 +1,110 @@
+package pds
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// bloomBlockBits is the number of bits in each block of a BlockedBloomFilter, one cache line
+const bloomBlockBits = cacheLineSize * 8
+
+// bloomBlock is one cache line of bits of a BlockedBloomFilter
+type bloomBlock [bloomBlockBits / 64]uint64
+
+// BlockedBloomFilter is a BloomFilter that keeps all of an item's bits within one cache line
+// sized block, so each add or lookup touches one cache line instead of one per hash, after
+// Putze et al.'s "Cache-, Hash- and Space-Efficient Bloom Filters". Blocks fill unevenly, so
+// the false positive rate comes out somewhat above a BloomFilter of the same size. Filters
+// over 32KB are allocated page aligned by the runtime, keeping every block on its own line
+type BlockedBloomFilter struct {
+	blocks  []bloomBlock
+	kHashes uint32
+	keys    keyHasher
+}
+
+// NewBlockedBloomFilter builds a new BlockedBloomFilter sized like NewBloomFilter for n items
+// at a false positive rate of fp, rounded up to whole blocks. Options set how keys are hashed
+func NewBlockedBloomFilter(n uint, fp float64, options ...KeyOption) (*BlockedBloomFilter, error) {
+	mBits, kHashes, err := bloomSize(n, fp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BlockedBloomFilter{
+		blocks:  make([]bloomBlock, (mBits+bloomBlockBits-1)/bloomBlockBits),
+		kHashes: kHashes,
+		keys:    newKeyHasher(options),
+	}, nil
+}
+
+// locate picks the item's block from the high bits of its hash times the number of blocks,
+// avoiding a division, and the pair of hashes its bits within the block are built from
+func (bbf *BlockedBloomFilter) locate(h uint64) (*bloomBlock, uint32, uint32) {
+	h = mix64(h)
+	index, _ := bits.Mul64(h, uint64(len(bbf.blocks)))
+	g := mix64(h)
+
+	return &bbf.blocks[index], uint32(g), uint32(g>>32) | 1
+}
+
+// add sets each of the item's bits within its block
+func (bbf *BlockedBloomFilter) add(h uint64) {
+	block, h1, h2 := bbf.locate(h)
+	for i := uint32(0); i < bbf.kHashes; i++ {
+		position := (h1 + i*h2) % bloomBlockBits
+		block[position/64] |= 1 << (position % 64)
+	}
+}
+
+// contains reports whether every one of the item's bits within its block is set
+func (bbf *BlockedBloomFilter) contains(h uint64) bool {
+	block, h1, h2 := bbf.locate(h)
+	for i := uint32(0); i < bbf.kHashes; i++ {
+		position := (h1 + i*h2) % bloomBlockBits
+		if block[position/64]&(1<<(position%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Add hashes and puts some string into the filter
+func (bbf *BlockedBloomFilter) Add(s string) {
+	bbf.add(hashKey(&bbf.keys, s))
+}
+
+// AddBytes hashes and puts a byte slice into the filter
+func (bbf *BlockedBloomFilter) AddBytes(b []byte) {
+	bbf.add(hashKey(&bbf.keys, b))
+}
+
+// Contains reports whether some string might have been added, false means it definitely wasn't
+func (bbf *BlockedBloomFilter) Contains(s string) bool {
+	return bbf.contains(hashKey(&bbf.keys, s))
+}
+
+// ContainsBytes reports whether a byte slice might have been added
+func (bbf *BlockedBloomFilter) ContainsBytes(b []byte) bool {
+	return bbf.contains(hashKey(&bbf.keys, b))
+}
+
+// Union adds every item in other into this filter, both need the same number of blocks and
+// hashes and the same seed
+func (bbf *BlockedBloomFilter) Union(other *BlockedBloomFilter) error {
+	if len(bbf.blocks) != len(other.blocks) || bbf.kHashes != other.kHashes {
+		return fmt.Errorf("cannot union %d blocks and %d hashes into %d blocks and %d hashes", len(other.blocks), other.kHashes, len(bbf.blocks), bbf.kHashes)
+	}
+
+	if err := bbf.keys.compatible(&other.keys); err != nil {
+		return err
+	}
+
+	for i := range other.blocks {
+		for j, word := range other.blocks[i] {
+			bbf.blocks[i][j] |= word
+		}
+	}
+
+	return nil
+}
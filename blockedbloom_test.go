@@ -0,0 +1,55 @@
+package pds
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBlockedBloomFilterFalsePositiveRate(t *testing.T) {
+	bbf, err := NewBlockedBloomFilter(10000, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		bbf.Add(fmt.Sprintf("item-%d", i))
+	}
+
+	for i := 0; i < 10000; i++ {
+		if !bbf.ContainsBytes([]byte(fmt.Sprintf("item-%d", i))) {
+			t.Fatalf("item-%d went missing", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < 100000; i++ {
+		if bbf.Contains(fmt.Sprintf("other-%d", i)) {
+			falsePositives++
+		}
+	}
+
+	// Uneven blocks cost some accuracy over a plain BloomFilter
+	if rate := float64(falsePositives) / 100000; rate > 0.02 {
+		t.Fatalf("got false positive rate %.4f, wanted a little over 0.01", rate)
+	}
+}
+
+func TestBlockedBloomFilterUnion(t *testing.T) {
+	a, _ := NewBlockedBloomFilter(1000, 0.01, WithKeySeed(5))
+	b, _ := NewBlockedBloomFilter(1000, 0.01, WithKeySeed(5))
+	a.Add("a")
+	b.Add("b")
+
+	if err := a.Union(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if !a.Contains("a") || !a.Contains("b") {
+		t.Fatalf("union is missing items")
+	}
+
+	other, _ := NewBlockedBloomFilter(1000, 0.01)
+	if err := a.Union(other); err == nil {
+		t.Fatalf("Union accepted a differently seeded filter")
+	}
+}
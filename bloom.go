@@ -0,0 +1,194 @@
+package pds
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// bloomBinaryVersion is the version byte written at the front of BloomFilter.MarshalBinary output
+const bloomBinaryVersion = 1
+
+// bloomHeaderSize is the version byte, the number of hashes, the number of bits and the seed
+const bloomHeaderSize = 1 + 4 + 8 + 8
+
+// BloomFilter tests whether items might have been added, never missing one that was but
+// wrongly claiming some were that weren't at around the false positive rate it was sized for
+type BloomFilter struct {
+	bits    []uint64
+	mBits   uint64
+	kHashes uint32
+	keys    keyHasher
+}
+
+// NewBloomFilter builds a new BloomFilter sized to hold n items with a false positive rate of
+// fp, using -n ln(fp) / ln(2)^2 bits and the number of hashes that minimises false positives.
+// Keys are hashed with FNV-1a unless options say otherwise
+func NewBloomFilter(n uint, fp float64, options ...KeyOption) (*BloomFilter, error) {
+	mBits, kHashes, err := bloomSize(n, fp)
+	if err != nil {
+		return nil, err
+	}
+
+	bf := newBloomFilter(mBits, kHashes)
+	bf.keys = newKeyHasher(options)
+
+	return bf, nil
+}
+
+// bloomSize works out the number of bits, or counters, and hashes for n items at a false
+// positive rate of fp
+func bloomSize(n uint, fp float64) (uint64, uint32, error) {
+	if n == 0 {
+		return 0, 0, fmt.Errorf("need to hold at least one item")
+	}
+
+	if fp <= 0 || fp >= 1 {
+		return 0, 0, fmt.Errorf("false positive rate needs to be in interval 0<x<1")
+	}
+
+	mBits := uint64(math.Ceil(-float64(n) * math.Log(fp) / (math.Ln2 * math.Ln2)))
+	kHashes := uint32(max(math.Round(float64(mBits)/float64(n)*math.Ln2), 1))
+
+	return mBits, kHashes, nil
+}
+
+// newBloomFilter builds an empty BloomFilter with mBits bits and kHashes hashes
+func newBloomFilter(mBits uint64, kHashes uint32) *BloomFilter {
+	return &BloomFilter{
+		bits:    make([]uint64, (mBits+63)/64),
+		mBits:   mBits,
+		kHashes: kHashes,
+	}
+}
+
+// bloomHashes derives the pair of hashes an item's positions are built from. The second is
+// forced odd, which only stops an item repeating a position early when mBits is a power of
+// two and so shares no factor with it. With other sizes an item now and then lands on the same
+// position twice, setting fewer than kHashes bits, which is rare enough to leave the false
+// positive rate about where it was sized
+func bloomHashes(h uint64) (uint64, uint64) {
+	h = mix64(h)
+
+	return h, mix64(h) | 1
+}
+
+// add sets the bit of each of the item's positions, from the double hashing h1 + i*h2 of
+// Kirsch and Mitzenmacher's "Less Hashing, Same Performance"
+func (bf *BloomFilter) add(h uint64) {
+	h1, h2 := bloomHashes(h)
+	for i := uint64(0); i < uint64(bf.kHashes); i++ {
+		position := (h1 + i*h2) % bf.mBits
+		bf.bits[position/64] |= 1 << (position % 64)
+	}
+}
+
+// contains reports whether the bit of every one of the item's positions is set
+func (bf *BloomFilter) contains(h uint64) bool {
+	h1, h2 := bloomHashes(h)
+	for i := uint64(0); i < uint64(bf.kHashes); i++ {
+		position := (h1 + i*h2) % bf.mBits
+		if bf.bits[position/64]&(1<<(position%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Add hashes and puts some string into the filter
+func (bf *BloomFilter) Add(s string) {
+	bf.add(hashKey(&bf.keys, s))
+}
+
+// AddBytes hashes and puts a byte slice into the filter, matching the same bytes added as a string
+func (bf *BloomFilter) AddBytes(b []byte) {
+	bf.add(hashKey(&bf.keys, b))
+}
+
+// Contains reports whether some string might have been added, false means it definitely wasn't
+func (bf *BloomFilter) Contains(s string) bool {
+	return bf.contains(hashKey(&bf.keys, s))
+}
+
+// ContainsBytes reports whether a byte slice might have been added
+func (bf *BloomFilter) ContainsBytes(b []byte) bool {
+	return bf.contains(hashKey(&bf.keys, b))
+}
+
+// Union adds every item in other into this filter by setting each bit set in either, giving
+// the filter of both sets. Both need the same size, number of hashes and seed
+func (bf *BloomFilter) Union(other *BloomFilter) error {
+	if bf.mBits != other.mBits || bf.kHashes != other.kHashes {
+		return fmt.Errorf("cannot union %d bits and %d hashes into %d bits and %d hashes", other.mBits, other.kHashes, bf.mBits, bf.kHashes)
+	}
+
+	if err := bf.keys.compatible(&other.keys); err != nil {
+		return err
+	}
+
+	for i, word := range other.bits {
+		bf.bits[i] |= word
+	}
+
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, writing a version byte, the number of
+// hashes, the number of bits and the seed followed by the bits 64 at a time, all big endian
+func (bf *BloomFilter) MarshalBinary() ([]byte, error) {
+	data := make([]byte, bloomHeaderSize, bloomHeaderSize+8*len(bf.bits))
+	data[0] = bloomBinaryVersion
+	binary.BigEndian.PutUint32(data[1:5], bf.kHashes)
+	binary.BigEndian.PutUint64(data[5:13], bf.mBits)
+	binary.BigEndian.PutUint64(data[13:21], bf.keys.seed)
+
+	for _, word := range bf.bits {
+		data = binary.BigEndian.AppendUint64(data, word)
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing the filter with one read from
+// MarshalBinary output. The filter keeps its own Hasher, and once built its seed has to match
+func (bf *BloomFilter) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return fmt.Errorf("binary data is too short")
+	}
+
+	if data[0] != bloomBinaryVersion {
+		return fmt.Errorf("%w %d", ErrUnsupportedVersion, data[0])
+	}
+
+	if len(data) < bloomHeaderSize {
+		return fmt.Errorf("binary data is too short")
+	}
+
+	kHashes := binary.BigEndian.Uint32(data[1:5])
+	mBits := binary.BigEndian.Uint64(data[5:13])
+	if kHashes == 0 || mBits == 0 {
+		return fmt.Errorf("bloom filter needs at least one bit and one hash")
+	}
+
+	// Checked against the bytes there are before rounding mBits up, which could overflow
+	words := data[bloomHeaderSize:]
+	if mBits > uint64(len(words))*8 || uint64(len(words)) != (mBits+63)/64*8 {
+		return fmt.Errorf("got %d bytes of bits but needed %d bits in whole words", len(words), mBits)
+	}
+
+	keys := bf.keys
+	if err := keys.decodeSeed(binary.BigEndian.Uint64(data[13:21]), bf.mBits != 0); err != nil {
+		return err
+	}
+
+	decoded := newBloomFilter(mBits, kHashes)
+	decoded.keys = keys
+	for i := range decoded.bits {
+		decoded.bits[i] = binary.BigEndian.Uint64(words[8*i:])
+	}
+
+	*bf = *decoded
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package pds
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// countingHasher counts the keys it hashes
+type countingHasher struct {
+	FNVHasher
+	calls *int
+}
+
+// Hash64 counts the call then hashes like FNVHasher
+func (h countingHasher) Hash64(data []byte, seed uint64) uint64 {
+	*h.calls++
+	return h.FNVHasher.Hash64(data, seed)
+}
+
+func TestBloomFilterFalsePositiveRate(t *testing.T) {
+	bf, err := NewBloomFilter(10000, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		bf.Add(fmt.Sprintf("item-%d", i))
+	}
+
+	for i := 0; i < 10000; i++ {
+		if !bf.ContainsBytes([]byte(fmt.Sprintf("item-%d", i))) {
+			t.Fatalf("item-%d went missing", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < 100000; i++ {
+		if bf.Contains(fmt.Sprintf("other-%d", i)) {
+			falsePositives++
+		}
+	}
+
+	if rate := float64(falsePositives) / 100000; rate > 0.015 {
+		t.Fatalf("got false positive rate %.4f, wanted about 0.01", rate)
+	}
+}
+
+func TestBloomFilterKeyOptions(t *testing.T) {
+	calls := 0
+	bf, err := NewBloomFilter(100, 0.01, WithKeyHasher(countingHasher{calls: &calls}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bf.Add("a")
+	if !bf.ContainsBytes([]byte("a")) || calls != 2 {
+		t.Fatalf("got %d calls to the hasher, wanted 2", calls)
+	}
+
+	// Differently seeded filters set different bits for the same key
+	a, _ := NewBloomFilter(100, 0.01, WithKeySeed(1))
+	b, _ := NewBloomFilter(100, 0.01, WithKeySeed(2))
+	a.Add("a")
+	b.Add("a")
+
+	if fmt.Sprint(a.bits) == fmt.Sprint(b.bits) {
+		t.Fatalf("seed made no difference to the bits set")
+	}
+
+	if err := a.Union(b); err == nil {
+		t.Fatalf("Union accepted a differently seeded filter")
+	}
+}
+
+func TestBloomFilterBinaryRoundTrip(t *testing.T) {
+	for _, options := range [][]KeyOption{nil, {WithKeySeed(42)}} {
+		bf, err := NewBloomFilter(1000, 0.01, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 1000; i++ {
+			bf.Add(fmt.Sprintf("item-%d", i))
+		}
+
+		data, err := bf.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded BloomFilter
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded.keys.seed != bf.keys.seed {
+			t.Fatalf("got seed %d, wanted %d", decoded.keys.seed, bf.keys.seed)
+		}
+
+		for i := 0; i < 1000; i++ {
+			if !decoded.Contains(fmt.Sprintf("item-%d", i)) {
+				t.Fatalf("item-%d went missing after decoding", i)
+			}
+		}
+
+		unseeded, _ := NewBloomFilter(1000, 0.01)
+		if err := unseeded.UnmarshalBinary(data); (err != nil) != (bf.keys.seed != 0) {
+			t.Fatalf("got error %v decoding seed %d into an unseeded filter", err, bf.keys.seed)
+		}
+	}
+}
+
+func TestBloomFilterUnmarshalRejectsBadData(t *testing.T) {
+	header := func(mBits uint64) []byte {
+		data := []byte{bloomBinaryVersion, 0, 0, 0, 1}
+		data = binary.BigEndian.AppendUint64(data, mBits)
+		return binary.BigEndian.AppendUint64(data, 0)
+	}
+
+	for _, test := range []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty"},
+		{name: "unknown version", data: []byte{99}},
+		{name: "cut short", data: header(64)[:15]},
+		{name: "too few words", data: header(128)},
+		{name: "bits rounding past 2^64", data: header(math.MaxUint64 - 10)},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var bf BloomFilter
+			if err := bf.UnmarshalBinary(test.data); err == nil {
+				t.Fatalf("wanted an error decoding %v", test.data)
+			}
+		})
+	}
+}
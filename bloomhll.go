@@ -0,0 +1,52 @@
+package pds
+
+// BloomFilteredHLL counts the distinct items of a stream that a BloomFilter is also
+// deduplicating, so items only go through the HyperLogLog the first time the filter sees
+// them. An item whose first sighting is a false positive of the filter is never counted,
+// so the count falls short by about the false positive rate times the distinct items, less
+// while the filter is still filling up, on top of the HyperLogLog's own error
+type BloomFilteredHLL struct {
+	filter *BloomFilter
+	hll    HyperLogLog
+}
+
+// NewBloomFilteredHLL builds a new BloomFilteredHLL with a BloomFilter sized for n items at a
+// false positive rate of fp and a HyperLogLog of indexBits index bits
+func NewBloomFilteredHLL(indexBits uint32, n uint, fp float64, options ...Option) (*BloomFilteredHLL, error) {
+	filter, err := NewBloomFilter(n, fp)
+	if err != nil {
+		return nil, err
+	}
+
+	hll, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BloomFilteredHLL{filter: filter, hll: hll}, nil
+}
+
+// Add puts some string into the filter and counts it, unless the filter says it has probably
+// been added already. It reports whether the item was new to the filter
+func (b *BloomFilteredHLL) Add(s string) bool {
+	h := hashKey(&b.filter.keys, s)
+	if b.filter.contains(h) {
+		return false
+	}
+
+	b.filter.add(h)
+	b.hll.Add(s)
+
+	return true
+}
+
+// MembershipContains reports whether some string might have been added, false means it
+// definitely wasn't
+func (b *BloomFilteredHLL) MembershipContains(s string) bool {
+	return b.filter.Contains(s)
+}
+
+// Count returns the estimated number of distinct items added
+func (b *BloomFilteredHLL) Count() int64 {
+	return b.hll.EstimateCardinality()
+}
@@ -0,0 +1,55 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestBloomFilteredHLLUndercount(t *testing.T) {
+	for _, fp := range []float64{0.001, 0.01, 0.1} {
+		t.Run(fmt.Sprint(fp), func(t *testing.T) {
+			const distinct = 50000
+
+			b, err := NewBloomFilteredHLL(14, distinct, fp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The same stream into a plain sketch gives the count without the filter
+			plain, err := NewHyperLogLog(14)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dropped := 0
+			for round := 0; round < 3; round++ {
+				for i := 0; i < distinct; i++ {
+					key := fmt.Sprintf("item-%d", i)
+					if !b.Add(key) && round == 0 {
+						dropped++
+					}
+					plain.Add(key)
+				}
+			}
+
+			for i := 0; i < distinct; i++ {
+				if !b.MembershipContains(fmt.Sprintf("item-%d", i)) {
+					t.Fatalf("item-%d went missing from the filter", i)
+				}
+			}
+
+			// First sightings taken for repeats are the undercount, at most about fp of them
+			if rate := float64(dropped) / distinct; rate > fp {
+				t.Fatalf("filter dropped %.4f of first sightings at a false positive rate of %v", rate, fp)
+			}
+
+			// The sketch counts a subset of the plain one's items, so falls short by about as
+			// many as were dropped
+			undercount := float64(plain.EstimateCardinality() - b.Count())
+			if undercount < 0 || math.Abs(undercount-float64(dropped)) > 0.2*float64(dropped)+20 {
+				t.Fatalf("count fell %.0f short of the plain sketch, wanted about the %d dropped", undercount, dropped)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package pds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"iter"
+)
+
+// chunkCountersSize is the sequence number and chunk count following each chunk's header
+const chunkCountersSize = 4 + 4
+
+// MarshalChunks splits the buckets into chunks of up to chunkSize buckets each, every chunk
+// carrying the index bits, flags and seed of MarshalBinary, its sequence number and the total
+// number of chunks so they can be sent separately over size limited transports. A chunkSize
+// below 1 gives a single chunk
+func (hll *HyperLogLog) MarshalChunks(chunkSize int) iter.Seq[[]byte] {
+	buckets := hll.registers()
+	if chunkSize < 1 {
+		chunkSize = len(buckets)
+	}
+
+	total := (len(buckets) + chunkSize - 1) / chunkSize
+	header := hll.appendHeader(nil)
+
+	return func(yield func([]byte) bool) {
+		for seq := 0; seq < total; seq++ {
+			start := seq * chunkSize
+			end := min(start+chunkSize, len(buckets))
+
+			chunk := make([]byte, 0, len(header)+chunkCountersSize+end-start)
+			chunk = append(chunk, header...)
+			chunk = binary.BigEndian.AppendUint32(chunk, uint32(seq))
+			chunk = binary.BigEndian.AppendUint32(chunk, uint32(total))
+
+			chunk = append(chunk, buckets[start:end]...)
+
+			if !yield(chunk) {
+				return
+			}
+		}
+	}
+}
+
+// ApplyChunks loads the sketch reassembled from MarshalChunks output like UnmarshalBinary,
+// erroring if chunks are missing, out of order or don't belong to the same sketch
+func (hll *HyperLogLog) ApplyChunks(chunks iter.Seq[[]byte]) error {
+	var header []byte
+	var indexBits uint32
+	var options []Option
+	var total, seq uint32
+	var values []byte
+
+	for chunk := range chunks {
+		chunkIndexBits, chunkOptions, rest, err := readHeader(chunk)
+		if err != nil {
+			return fmt.Errorf("chunk %d: %w", seq, err)
+		}
+
+		if len(rest) < chunkCountersSize {
+			return fmt.Errorf("chunk %d is too short", seq)
+		}
+
+		chunkHeader := chunk[:len(chunk)-len(rest)]
+		chunkSeq := binary.BigEndian.Uint32(rest[0:4])
+		chunkTotal := binary.BigEndian.Uint32(rest[4:8])
+
+		if seq == 0 {
+			header, indexBits, options, total = chunkHeader, chunkIndexBits, chunkOptions, chunkTotal
+		} else if !bytes.Equal(chunkHeader, header) || chunkTotal != total {
+			return fmt.Errorf("chunk %d belongs to a different sketch", chunkSeq)
+		}
+
+		if chunkSeq != seq {
+			return fmt.Errorf("expected chunk %d but got chunk %d", seq, chunkSeq)
+		}
+
+		values = append(values, rest[chunkCountersSize:]...)
+		seq++
+	}
+
+	if seq == 0 || seq != total {
+		return fmt.Errorf("got %d of %d chunks", seq, total)
+	}
+
+	decoded, err := decodeBuckets(indexBits, values, options...)
+	if err != nil {
+		return err
+	}
+
+	return hll.load(&decoded)
+}
@@ -0,0 +1,61 @@
+package pds
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestChunksRoundTrip(t *testing.T) {
+	for _, test := range encodingTests {
+		t.Run(test.name, func(t *testing.T) {
+			hll := filledSketch(t, 16, 200000, test.options...)
+
+			chunks := slices.Collect(hll.MarshalChunks(1000))
+			if len(chunks) != 66 {
+				t.Fatalf("got %d chunks, wanted 66", len(chunks))
+			}
+
+			var decoded HyperLogLog
+			if err := decoded.ApplyChunks(slices.Values(chunks)); err != nil {
+				t.Fatal(err)
+			}
+
+			assertSameSketch(t, &decoded, &hll)
+		})
+	}
+}
+
+func TestApplyChunksRejectsMixedSketches(t *testing.T) {
+	narrow := filledSketch(t, 8, 100)
+	wide := filledSketch(t, 8, 100, With64BitHash())
+
+	chunks := slices.Collect(narrow.MarshalChunks(100))
+	chunks[1] = slices.Collect(wide.MarshalChunks(100))[1]
+
+	var decoded HyperLogLog
+	if err := decoded.ApplyChunks(slices.Values(chunks)); err == nil {
+		t.Fatalf("wanted an error applying chunks of differently hashed sketches")
+	}
+}
+
+func TestApplyChunksRejectsBadChunks(t *testing.T) {
+	hll := filledSketch(t, 8, 100)
+	chunks := slices.Collect(hll.MarshalChunks(100))
+
+	for _, test := range []struct {
+		name   string
+		chunks [][]byte
+	}{
+		{name: "none"},
+		{name: "missing", chunks: chunks[:2]},
+		{name: "out of order", chunks: [][]byte{chunks[1], chunks[0], chunks[2]}},
+		{name: "cut short", chunks: [][]byte{chunks[0][:4]}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var decoded HyperLogLog
+			if err := decoded.ApplyChunks(slices.Values(test.chunks)); err == nil {
+				t.Fatalf("wanted an error applying %d chunks", len(test.chunks))
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package pds
+
+import (
+	"sync/atomic"
+)
+
+// ConcurrentHyperLogLog is a HyperLogLog that is safe to Add to and estimate from many
+// goroutines at once. Every bucket is its own atomic value raised with compare and swap, so
+// adds never wait on a lock
+type ConcurrentHyperLogLog struct {
+	config  HyperLogLog
+	buckets []atomic.Uint32
+}
+
+// NewConcurrentHyperLogLog builds a new ConcurrentHyperLogLog. Only options about hashing and
+// estimating apply, the ones tracking adds such as WithEstimateHistory or WithExactThreshold
+// are left out
+func NewConcurrentHyperLogLog(indexBits uint32, options ...Option) (*ConcurrentHyperLogLog, error) {
+	hll, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		return nil, err
+	}
+	hll.Close()
+
+	return &ConcurrentHyperLogLog{
+		config:  hll.emptyCopy(),
+		buckets: make([]atomic.Uint32, hll.mBuckets),
+	}, nil
+}
+
+// Add hashes and puts some string into the data structure
+func (c *ConcurrentHyperLogLog) Add(s string) {
+	c.addHash(c.config.hash(c.config.normalize(s)))
+}
+
+// AddBytes hashes and puts a byte slice into the data structure
+func (c *ConcurrentHyperLogLog) AddBytes(b []byte) {
+	c.addHash(c.config.hashBytes(b))
+}
+
+// addHash raises the hash's bucket, retrying whenever another goroutine changed it first
+func (c *ConcurrentHyperLogLog) addHash(h uint64) {
+	binaryIndex, unusedBinary := c.config.splitBinary(h)
+	value := uint32(c.config.rank(unusedBinary))
+
+	bucket := &c.buckets[binaryIndex]
+	for {
+		current := bucket.Load()
+		if current >= value || bucket.CompareAndSwap(current, value) {
+			return
+		}
+	}
+}
+
+// Snapshot copies the buckets into a plain HyperLogLog configured the same way, eg. for
+// merging or serializing. Adds running alongside may or may not make it into the copy
+func (c *ConcurrentHyperLogLog) Snapshot() HyperLogLog {
+	snapshot := c.config.emptyCopy()
+	for i := range c.buckets {
+		snapshot.bucketGroup[i] = uint8(c.buckets[i].Load())
+	}
+
+	return snapshot
+}
+
+// EstimateCardinality returns the current cardinality estimate from a snapshot of the buckets
+func (c *ConcurrentHyperLogLog) EstimateCardinality() int64 {
+	snapshot := c.Snapshot()
+
+	return snapshot.bucketEstimate()
+}
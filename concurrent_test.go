@@ -0,0 +1,46 @@
+package pds
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentMatchesSingleSketch(t *testing.T) {
+	concurrent, err := NewConcurrentHyperLogLog(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	single := filledSketch(t, 12, 40000)
+
+	// Estimates run alongside the adds, which go run with -race checks
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := g; i < 40000; i += 8 {
+				if i%2 == 0 {
+					concurrent.Add(fmt.Sprintf("item-%d", i))
+				} else {
+					concurrent.AddBytes([]byte(fmt.Sprintf("item-%d", i)))
+				}
+
+				if i%1000 == 0 {
+					concurrent.EstimateCardinality()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	snapshot := concurrent.Snapshot()
+	if !snapshot.Equal(&single) {
+		t.Fatalf("concurrent adds filled different buckets than a single sketch of the same items")
+	}
+
+	if concurrent.EstimateCardinality() != single.EstimateCardinality() {
+		t.Fatalf("got estimate %d, wanted %d", concurrent.EstimateCardinality(), single.EstimateCardinality())
+	}
+}
@@ -0,0 +1,127 @@
+package pds
+
+// maxBloomCounter is the largest value a 4 bit counter holds, counters that reach it stay there
+const maxBloomCounter = 1<<4 - 1
+
+// CountingBloomFilter is a BloomFilter that can have items removed again, keeping a 4 bit
+// counter in place of each bit, two to a byte. A counter pushed past 15 saturates and is
+// never decremented afterwards, as how many items share it is no longer known, so the
+// filter can't forget those items but never misses an item that was added and not removed
+type CountingBloomFilter struct {
+	counters  []byte
+	mCounters uint64
+	kHashes   uint32
+	keys      keyHasher
+}
+
+// NewCountingBloomFilter builds a new CountingBloomFilter sized like NewBloomFilter, taking four
+// times the memory of the BloomFilter for the same n and fp. Options set how keys are hashed
+func NewCountingBloomFilter(n uint, fp float64, options ...KeyOption) (*CountingBloomFilter, error) {
+	mCounters, kHashes, err := bloomSize(n, fp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CountingBloomFilter{
+		counters:  make([]byte, (mCounters+1)/2),
+		mCounters: mCounters,
+		kHashes:   kHashes,
+		keys:      newKeyHasher(options),
+	}, nil
+}
+
+// counter returns the counter at position
+func (cbf *CountingBloomFilter) counter(position uint64) byte {
+	return cbf.counters[position/2] >> (4 * (position % 2)) & maxBloomCounter
+}
+
+// setCounter overwrites the counter at position
+func (cbf *CountingBloomFilter) setCounter(position uint64, value byte) {
+	shift := 4 * (position % 2)
+	cbf.counters[position/2] = cbf.counters[position/2]&^(maxBloomCounter<<shift) | value<<shift
+}
+
+// add increments each of the item's counters that hasn't saturated
+func (cbf *CountingBloomFilter) add(h uint64) {
+	h1, h2 := bloomHashes(h)
+	for i := uint64(0); i < uint64(cbf.kHashes); i++ {
+		position := (h1 + i*h2) % cbf.mCounters
+		if value := cbf.counter(position); value < maxBloomCounter {
+			cbf.setCounter(position, value+1)
+		}
+	}
+}
+
+// contains reports whether every one of the item's counters is above zero
+func (cbf *CountingBloomFilter) contains(h uint64) bool {
+	h1, h2 := bloomHashes(h)
+	for i := uint64(0); i < uint64(cbf.kHashes); i++ {
+		if cbf.counter((h1+i*h2)%cbf.mCounters) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// remove decrements each of the item's counters that hasn't saturated, if the item might be there
+func (cbf *CountingBloomFilter) remove(h uint64) bool {
+	if !cbf.contains(h) {
+		return false
+	}
+
+	h1, h2 := bloomHashes(h)
+	for i := uint64(0); i < uint64(cbf.kHashes); i++ {
+		position := (h1 + i*h2) % cbf.mCounters
+		if value := cbf.counter(position); value > 0 && value < maxBloomCounter {
+			cbf.setCounter(position, value-1)
+		}
+	}
+
+	return true
+}
+
+// Add hashes and puts some string into the filter
+func (cbf *CountingBloomFilter) Add(s string) {
+	cbf.add(hashKey(&cbf.keys, s))
+}
+
+// AddBytes hashes and puts a byte slice into the filter
+func (cbf *CountingBloomFilter) AddBytes(b []byte) {
+	cbf.add(hashKey(&cbf.keys, b))
+}
+
+// Contains reports whether some string might be in the filter, false means it definitely isn't
+func (cbf *CountingBloomFilter) Contains(s string) bool {
+	return cbf.contains(hashKey(&cbf.keys, s))
+}
+
+// ContainsBytes reports whether a byte slice might be in the filter
+func (cbf *CountingBloomFilter) ContainsBytes(b []byte) bool {
+	return cbf.contains(hashKey(&cbf.keys, b))
+}
+
+// Remove takes some string back out of the filter, returning false and leaving the filter
+// alone if it definitely wasn't there. Only remove items that were added, removing a false
+// positive takes away counts belonging to other items, which can then be missed
+func (cbf *CountingBloomFilter) Remove(s string) bool {
+	return cbf.remove(hashKey(&cbf.keys, s))
+}
+
+// RemoveBytes takes a byte slice back out of the filter like Remove
+func (cbf *CountingBloomFilter) RemoveBytes(b []byte) bool {
+	return cbf.remove(hashKey(&cbf.keys, b))
+}
+
+// Saturated returns how many counters have saturated, once a sizeable share of them have the
+// filter is overfull and removals stop freeing up space
+func (cbf *CountingBloomFilter) Saturated() int {
+	var saturated int
+	for position := uint64(0); position < cbf.mCounters; position++ {
+		if cbf.counter(position) == maxBloomCounter {
+			saturated++
+		}
+	}
+
+	return saturated
+}
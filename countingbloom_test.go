@@ -0,0 +1,77 @@
+package pds
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCountingBloomFilterRemove(t *testing.T) {
+	cbf, err := NewCountingBloomFilter(1000, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		cbf.Add(fmt.Sprintf("item-%d", i))
+	}
+
+	for i := 0; i < 500; i++ {
+		if !cbf.RemoveBytes([]byte(fmt.Sprintf("item-%d", i))) {
+			t.Fatalf("item-%d couldn't be removed", i)
+		}
+	}
+
+	for i := 500; i < 1000; i++ {
+		if !cbf.Contains(fmt.Sprintf("item-%d", i)) {
+			t.Fatalf("item-%d went missing after removing others", i)
+		}
+	}
+
+	remaining := 0
+	for i := 0; i < 500; i++ {
+		if cbf.Contains(fmt.Sprintf("item-%d", i)) {
+			remaining++
+		}
+	}
+
+	if remaining > 25 {
+		t.Fatalf("%d of 500 removed items are still there", remaining)
+	}
+}
+
+func TestCountingBloomFilterSaturates(t *testing.T) {
+	cbf, err := NewCountingBloomFilter(10, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < maxBloomCounter+5; i++ {
+		cbf.Add("hot")
+	}
+
+	if cbf.Saturated() != int(cbf.kHashes) {
+		t.Fatalf("got %d saturated counters, wanted %d", cbf.Saturated(), cbf.kHashes)
+	}
+
+	// Saturated counters are never decremented, so the item can't be forgotten
+	for i := 0; i < maxBloomCounter+5; i++ {
+		cbf.Remove("hot")
+	}
+
+	if !cbf.Contains("hot") {
+		t.Fatalf("saturated item was forgotten")
+	}
+}
+
+func TestCountingBloomFilterKeyOptions(t *testing.T) {
+	calls := 0
+	cbf, err := NewCountingBloomFilter(100, 0.01, WithKeyHasher(countingHasher{calls: &calls}), WithKeySeed(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cbf.Add("a")
+	if !cbf.Contains("a") || calls != 2 || cbf.keys.seed != 3 {
+		t.Fatalf("got %d calls to the hasher and seed %d", calls, cbf.keys.seed)
+	}
+}
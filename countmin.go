@@ -0,0 +1,246 @@
+package pds
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// countMinBinaryVersion is the version byte written at the front of CountMinSketch.MarshalBinary
+// output
+const countMinBinaryVersion = 1
+
+// countMinHeaderSize is the version byte, the depth, the width, the total count and the seed
+const countMinHeaderSize = 29
+
+// CountMinSketch estimates how often each key was added in a fixed number of counters, following
+// Cormode and Muthukrishnan's "An Improved Data Stream Summary: The Count-Min Sketch and its
+// Applications". Each of depth rows has width counters and a key adds its count to one
+// counter per row, so the smallest of its counters is never below the true count and only
+// overestimates by what other keys colliding with it added
+type CountMinSketch struct {
+	depth        uint32
+	width        uint64
+	total        uint64
+	counters     []uint64
+	conservative bool
+	keys         keyHasher
+}
+
+// CountMinOption configures a CountMinSketch when passed to NewCountMinSketch
+type CountMinOption func(*CountMinSketch)
+
+// WithConservativeUpdate makes adds only raise each of the key's counters as far as the key's
+// new estimate, Estan and Varghese's conservative update. A counter already above it is left
+// alone as other keys' counts account for the difference, so estimates stay upper bounds but
+// overestimate far less when a few heavy keys dominate the stream. Counters no longer hold
+// sums of counts, so counts could never be taken back out again without keys being missed.
+// Adds read every counter before writing them, and sketches should only be merged with
+// others using the same mode
+func WithConservativeUpdate() CountMinOption {
+	return func(cms *CountMinSketch) {
+		cms.conservative = true
+	}
+}
+
+// WithCountMinHashing sets how keys are hashed, like the KeyOptions the filters take
+func WithCountMinHashing(options ...KeyOption) CountMinOption {
+	return func(cms *CountMinSketch) {
+		cms.keys = newKeyHasher(options)
+	}
+}
+
+// NewCountMinSketch builds a new CountMinSketch whose estimates overestimate by at most epsilon
+// times the total count, with probability of at least 1-delta. Width is e/epsilon counters and
+// depth ln(1/delta) rows
+func NewCountMinSketch(epsilon float64, delta float64, options ...CountMinOption) (*CountMinSketch, error) {
+	if epsilon <= 0 || epsilon >= 1 {
+		return nil, fmt.Errorf("epsilon needs to be in interval 0<x<1")
+	}
+
+	if delta <= 0 || delta >= 1 {
+		return nil, fmt.Errorf("delta needs to be in interval 0<x<1")
+	}
+
+	width := uint64(math.Ceil(math.E / epsilon))
+	depth := uint32(math.Ceil(math.Log(1 / delta)))
+
+	cms := newCountMinSketch(max(depth, 1), width)
+	cms.keys = newKeyHasher(nil)
+	for _, option := range options {
+		option(cms)
+	}
+
+	return cms, nil
+}
+
+// newCountMinSketch builds an empty CountMinSketch of depth rows of width counters
+func newCountMinSketch(depth uint32, width uint64) *CountMinSketch {
+	return &CountMinSketch{
+		depth:    depth,
+		width:    width,
+		counters: make([]uint64, uint64(depth)*width),
+	}
+}
+
+// add adds count to the key's counter in every row, or with conservative update raises them to
+// the key's new estimate
+func (cms *CountMinSketch) add(h uint64, count uint64) {
+	cms.total += count
+	h1, h2 := bloomHashes(h)
+
+	if cms.conservative {
+		estimate := cms.estimate(h) + count
+		for row := uint64(0); row < uint64(cms.depth); row++ {
+			counter := &cms.counters[row*cms.width+(h1+row*h2)%cms.width]
+			*counter = max(*counter, estimate)
+		}
+
+		return
+	}
+
+	for row := uint64(0); row < uint64(cms.depth); row++ {
+		cms.counters[row*cms.width+(h1+row*h2)%cms.width] += count
+	}
+}
+
+// estimate returns the smallest of the key's counters
+func (cms *CountMinSketch) estimate(h uint64) uint64 {
+	h1, h2 := bloomHashes(h)
+	estimate := uint64(math.MaxUint64)
+	for row := uint64(0); row < uint64(cms.depth); row++ {
+		estimate = min(estimate, cms.counters[row*cms.width+(h1+row*h2)%cms.width])
+	}
+
+	return estimate
+}
+
+// Add hashes some string and adds count to its counters
+func (cms *CountMinSketch) Add(key string, count uint64) {
+	cms.add(hashKey(&cms.keys, key), count)
+}
+
+// AddBytes hashes a byte slice and adds count to its counters like Add
+func (cms *CountMinSketch) AddBytes(key []byte, count uint64) {
+	cms.add(hashKey(&cms.keys, key), count)
+}
+
+// Estimate returns how often some string was probably added, never less than the true count
+func (cms *CountMinSketch) Estimate(key string) uint64 {
+	return cms.estimate(hashKey(&cms.keys, key))
+}
+
+// EstimateBytes returns how often a byte slice was probably added like Estimate
+func (cms *CountMinSketch) EstimateBytes(key []byte) uint64 {
+	return cms.estimate(hashKey(&cms.keys, key))
+}
+
+// CountBatch returns the estimate of every key, the same as calling Estimate on each. The keys
+// are all hashed first and their counters then read a row at a time, so scoring many keys
+// works through one row's counters before moving on to the next
+func (cms *CountMinSketch) CountBatch(keys []string) []uint64 {
+	estimates := make([]uint64, len(keys))
+	hashes := make([][2]uint64, len(keys))
+	for i, key := range keys {
+		h1, h2 := bloomHashes(hashKey(&cms.keys, key))
+		hashes[i] = [2]uint64{h1, h2}
+		estimates[i] = math.MaxUint64
+	}
+
+	for row := uint64(0); row < uint64(cms.depth); row++ {
+		counters := cms.counters[row*cms.width : (row+1)*cms.width]
+		for i, h := range hashes {
+			estimates[i] = min(estimates[i], counters[(h[0]+row*h[1])%cms.width])
+		}
+	}
+
+	return estimates
+}
+
+// Total returns the sum of every count added
+func (cms *CountMinSketch) Total() uint64 {
+	return cms.total
+}
+
+// Merge adds every count in other into this sketch, as if this sketch had been given other's
+// items too. Both need the same width, depth and seed
+func (cms *CountMinSketch) Merge(other *CountMinSketch) error {
+	if cms.depth != other.depth || cms.width != other.width {
+		return fmt.Errorf("cannot merge %dx%d count-min sketch into %dx%d count-min sketch", other.depth, other.width, cms.depth, cms.width)
+	}
+
+	if err := cms.keys.compatible(&other.keys); err != nil {
+		return err
+	}
+
+	for i, counter := range other.counters {
+		cms.counters[i] += counter
+	}
+	cms.total += other.total
+
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, writing a version byte, the depth as a
+// uint32, the width, total count and seed as uint64s and then every counter
+func (cms *CountMinSketch) MarshalBinary() ([]byte, error) {
+	data := make([]byte, countMinHeaderSize, countMinHeaderSize+8*len(cms.counters))
+	data[0] = countMinBinaryVersion
+	binary.BigEndian.PutUint32(data[1:5], cms.depth)
+	binary.BigEndian.PutUint64(data[5:13], cms.width)
+	binary.BigEndian.PutUint64(data[13:21], cms.total)
+	binary.BigEndian.PutUint64(data[21:29], cms.keys.seed)
+
+	for _, counter := range cms.counters {
+		data = binary.BigEndian.AppendUint64(data, counter)
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing the sketch with one read from
+// MarshalBinary output. Options other than the seed aren't part of the output so the sketch
+// keeps its own, and once built its seed has to match
+func (cms *CountMinSketch) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return fmt.Errorf("binary data is too short")
+	}
+
+	if data[0] != countMinBinaryVersion {
+		return fmt.Errorf("%w %d", ErrUnsupportedVersion, data[0])
+	}
+
+	if len(data) < countMinHeaderSize {
+		return fmt.Errorf("binary data is too short")
+	}
+
+	depth := binary.BigEndian.Uint32(data[1:5])
+	width := binary.BigEndian.Uint64(data[5:13])
+	if depth == 0 || width == 0 {
+		return fmt.Errorf("count-min sketch needs at least one row and one column")
+	}
+
+	counters := data[countMinHeaderSize:]
+	if uint64(len(counters))/8/width != uint64(depth) || uint64(len(counters)) != 8*width*uint64(depth) {
+		return fmt.Errorf("got %d bytes of counters but %dx%d counters needs %d", len(counters), depth, width, 8*width*uint64(depth))
+	}
+
+	seed := binary.BigEndian.Uint64(data[21:29])
+
+	keys := cms.keys
+	if err := keys.decodeSeed(seed, cms.width != 0); err != nil {
+		return err
+	}
+
+	decoded := newCountMinSketch(depth, width)
+	decoded.total = binary.BigEndian.Uint64(data[13:21])
+	decoded.conservative = cms.conservative
+	decoded.keys = keys
+	for i := range decoded.counters {
+		decoded.counters[i] = binary.BigEndian.Uint64(counters[8*i:])
+	}
+
+	*cms = *decoded
+
+	return nil
+}
@@ -0,0 +1,244 @@
+package pds
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestCountMinSketchOverestimatesWithinBound(t *testing.T) {
+	cms, err := NewCountMinSketch(0.001, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		cms.Add(fmt.Sprintf("item-%d", i), uint64(i%10+1))
+	}
+
+	bound := uint64(0.001 * float64(cms.Total()))
+	over := 0
+	for i := 0; i < 10000; i++ {
+		want := uint64(i%10 + 1)
+		got := cms.EstimateBytes([]byte(fmt.Sprintf("item-%d", i)))
+		if got < want {
+			t.Fatalf("got estimate %d for item-%d, below its count %d", got, i, want)
+		}
+
+		if got-want > bound {
+			over++
+		}
+	}
+
+	// At most delta of the estimates are allowed past the bound
+	if over > 100 {
+		t.Fatalf("%d of 10000 estimates overshot by more than %d", over, bound)
+	}
+}
+
+func TestCountMinSketchHashing(t *testing.T) {
+	calls := 0
+	cms, err := NewCountMinSketch(0.01, 0.01, WithCountMinHashing(WithKeyHasher(countingHasher{calls: &calls}), WithKeySeed(7)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cms.Add("a", 3)
+	if cms.Estimate("a") != 3 || calls != 2 || cms.keys.seed != 7 {
+		t.Fatalf("got %d calls to the hasher and seed %d", calls, cms.keys.seed)
+	}
+
+	other, _ := NewCountMinSketch(0.01, 0.01)
+	if err := cms.Merge(other); err == nil {
+		t.Fatalf("Merge accepted a differently seeded sketch")
+	}
+}
+
+func TestCountMinSketchBinaryRoundTrip(t *testing.T) {
+	cms, err := NewCountMinSketch(0.01, 0.01, WithCountMinHashing(WithKeySeed(7)), WithConservativeUpdate())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		cms.Add(fmt.Sprintf("item-%d", i), uint64(i))
+	}
+
+	data, err := cms.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, _ := NewCountMinSketch(0.5, 0.5, WithCountMinHashing(WithKeySeed(7)), WithConservativeUpdate())
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Total() != cms.Total() || !decoded.conservative {
+		t.Fatalf("got total %d, wanted %d with conservative update kept", decoded.Total(), cms.Total())
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("item-%d", i)
+		if decoded.Estimate(key) != cms.Estimate(key) {
+			t.Fatalf("estimates of %s differ after decoding", key)
+		}
+	}
+
+	unseeded, _ := NewCountMinSketch(0.01, 0.01)
+	if err := unseeded.UnmarshalBinary(data); err == nil {
+		t.Fatalf("decoded a seeded sketch into an unseeded one")
+	}
+}
+
+func TestCountBatchMatchesEstimate(t *testing.T) {
+	cms, err := NewCountMinSketch(0.001, 0.01, WithCountMinHashing(WithKeySeed(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make([]string, 5000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("item-%d", i)
+		cms.Add(keys[i], uint64(i%7))
+	}
+
+	// Keys never added and repeated keys are answered the same too
+	keys = append(keys, "missing", "item-1", "item-1")
+
+	estimates := cms.CountBatch(keys)
+	for i, key := range keys {
+		if estimates[i] != cms.Estimate(key) {
+			t.Fatalf("got %d for %s in a batch, %d on its own", estimates[i], key, cms.Estimate(key))
+		}
+	}
+
+	if len(cms.CountBatch(nil)) != 0 {
+		t.Fatalf("got estimates for no keys")
+	}
+}
+
+// countMinBenchmark builds a sketch too big for the cache and the keys to score against it
+func countMinBenchmark(b *testing.B) (*CountMinSketch, []string) {
+	cms, err := NewCountMinSketch(0.00001, 0.001)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	keys := make([]string, 10000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("candidate-%d", i)
+		cms.Add(keys[i], 1)
+	}
+
+	return cms, keys
+}
+
+func BenchmarkCountMinEstimateLoop(b *testing.B) {
+	cms, keys := countMinBenchmark(b)
+	estimates := make([]uint64, len(keys))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, key := range keys {
+			estimates[j] = cms.Estimate(key)
+		}
+	}
+}
+
+func BenchmarkCountMinCountBatch(b *testing.B) {
+	cms, keys := countMinBenchmark(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cms.CountBatch(keys)
+	}
+}
+
+func TestConservativeUpdateTightensRareKeys(t *testing.T) {
+	standard, err := NewCountMinSketch(0.01, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conservative, err := NewCountMinSketch(0.01, 0.01, WithConservativeUpdate())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A Zipf-like stream, a few heavy keys and a long tail seen once or twice
+	counts := make([]uint64, 5000)
+	for i := range counts {
+		counts[i] = uint64(max(10000/(i+1), 1))
+		standard.Add(fmt.Sprintf("item-%d", i), counts[i])
+		conservative.Add(fmt.Sprintf("item-%d", i), counts[i])
+	}
+
+	var standardOver, conservativeOver uint64
+	for i, count := range counts {
+		key := fmt.Sprintf("item-%d", i)
+		s, c := standard.Estimate(key), conservative.Estimate(key)
+		if c < count || c > s {
+			t.Fatalf("got conservative estimate %d for %s, wanted between %d and %d", c, key, count, s)
+		}
+
+		if count <= 2 {
+			standardOver += s - count
+			conservativeOver += c - count
+		}
+	}
+
+	if conservativeOver*4 > standardOver*3 {
+		t.Fatalf("conservative update overestimated rare keys by %d in total, standard by %d", conservativeOver, standardOver)
+	}
+}
+
+// benchmarkSkewedAdds adds a Zipf distributed stream and reports how far the estimates of
+// keys seen at most twice overshoot on average
+func benchmarkSkewedAdds(b *testing.B, options ...CountMinOption) {
+	keys := make([]string, 100000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("item-%d", i)
+	}
+
+	zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.1, 1, uint64(len(keys)-1))
+	stream := make([]uint64, 1000000)
+	for i := range stream {
+		stream[i] = zipf.Uint64()
+	}
+
+	var overshoot float64
+	for i := 0; i < b.N; i++ {
+		cms, err := NewCountMinSketch(0.001, 0.01, options...)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		counts := make([]uint64, len(keys))
+		for _, key := range stream {
+			cms.Add(keys[key], 1)
+			counts[key]++
+		}
+
+		b.StopTimer()
+		var over, rare float64
+		for key, count := range counts {
+			if count > 0 && count <= 2 {
+				over += float64(cms.Estimate(keys[key]) - count)
+				rare++
+			}
+		}
+		overshoot = over / rare
+		b.StartTimer()
+	}
+
+	b.ReportMetric(overshoot, "overshoot/rare-key")
+}
+
+func BenchmarkCountMinSkewedStandard(b *testing.B) {
+	benchmarkSkewedAdds(b)
+}
+
+func BenchmarkCountMinSkewedConservative(b *testing.B) {
+	benchmarkSkewedAdds(b, WithConservativeUpdate())
+}
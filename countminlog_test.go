@@ -0,0 +1,118 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestCountMinLogSketchEstimates(t *testing.T) {
+	cmls, err := NewCountMinLogSketch(0.001, 0.01, 1.08)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		for j := 0; j < 100; j++ {
+			cmls.AddBytes([]byte(fmt.Sprintf("item-%d", i)), 1)
+		}
+	}
+
+	// Each counter is off by about sqrt((base-1)/2), the mean over many keys far less
+	total := 0.0
+	for i := 0; i < 1000; i++ {
+		total += cmls.Estimate(fmt.Sprintf("item-%d", i))
+	}
+
+	if mean := total / 1000; math.Abs(mean-100) > 10 {
+		t.Fatalf("got mean estimate %.1f, wanted about 100", mean)
+	}
+}
+
+func TestCountMinLogSketchBinaryRoundTrip(t *testing.T) {
+	cmls, err := NewCountMinLogSketch(0.01, 0.01, 1.5, WithKeySeed(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		cmls.Add(fmt.Sprintf("item-%d", i), uint64(i))
+	}
+
+	data, err := cmls.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded CountMinLogSketch
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("item-%d", i)
+		if decoded.EstimateBytes([]byte(key)) != cmls.Estimate(key) {
+			t.Fatalf("estimates of %s differ after decoding", key)
+		}
+	}
+
+	unseeded, _ := NewCountMinLogSketch(0.01, 0.01, 1.5)
+	if err := unseeded.UnmarshalBinary(data); err == nil {
+		t.Fatalf("decoded a seeded sketch into an unseeded one")
+	}
+}
+
+func TestCountMinLogSketchLargeCounts(t *testing.T) {
+	cmls, err := NewCountMinLogSketch(0.01, 0.01, 1.08)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Adding a billion at once is about as quick as adding one, and matches adding one at a
+	// time in distribution
+	for i := 0; i < 200; i++ {
+		cmls.Add(fmt.Sprintf("big-%d", i), 1e9)
+	}
+
+	total := 0.0
+	for i := 0; i < 200; i++ {
+		total += cmls.Estimate(fmt.Sprintf("big-%d", i))
+	}
+
+	if mean := total / 200; math.Abs(mean-1e9)/1e9 > 0.1 {
+		t.Fatalf("got mean estimate %.3g, wanted about 1e9", mean)
+	}
+
+	batched, _ := NewCountMinLogSketch(0.001, 0.01, 1.08)
+	single, _ := NewCountMinLogSketch(0.001, 0.01, 1.08)
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("item-%d", i)
+		batched.Add(key, 1000)
+		for j := 0; j < 1000; j++ {
+			single.Add(key, 1)
+		}
+	}
+
+	var batchedTotal, singleTotal float64
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("item-%d", i)
+		batchedTotal += batched.Estimate(key)
+		singleTotal += single.Estimate(key)
+	}
+
+	if math.Abs(batchedTotal-singleTotal)/singleTotal > 0.05 {
+		t.Fatalf("adding counts at once gave a mean of %.1f, one at a time %.1f", batchedTotal/500, singleTotal/500)
+	}
+}
+
+func BenchmarkCountMinLogSketchAddLargeCount(b *testing.B) {
+	cmls, err := NewCountMinLogSketch(0.001, 0.01, 1.08)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < b.N; i++ {
+		clear(cmls.counters)
+		cmls.Add("key", 1e6)
+	}
+}
@@ -0,0 +1,189 @@
+package pds
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// countSketchBinaryVersion is the version byte written at the front of CountSketch.MarshalBinary
+// output
+const countSketchBinaryVersion = 1
+
+// countSketchHeaderSize is the version byte, the depth, the width and the seed
+const countSketchHeaderSize = 21
+
+// CountSketch estimates each key's net count like a CountMinSketch, but counts can go down as
+// well as up, following Charikar, Chen and Farach-Colton's "Finding Frequent Items in Data
+// Streams". Every row also hashes the key to a sign, adding its count to one counter times
+// the sign, so other keys colliding with it cancel out on average. The estimate is the median
+// over rows of counter times sign, unbiased rather than an upper bound, with an error that
+// scales with the square root of the sum of squared counts rather than the total count
+type CountSketch struct {
+	depth    uint32
+	width    uint64
+	counters []int64
+	keys     keyHasher
+}
+
+// NewCountSketch builds a new CountSketch whose estimates are within epsilon times the square
+// root of the sum of every key's squared count, with probability of about 1-delta. Width is
+// 3/epsilon^2 counters and depth ln(1/delta) rows, rounded up to an odd number for the median.
+// Options set how keys are hashed
+func NewCountSketch(epsilon float64, delta float64, options ...KeyOption) (*CountSketch, error) {
+	if epsilon <= 0 || epsilon >= 1 {
+		return nil, fmt.Errorf("epsilon needs to be in interval 0<x<1")
+	}
+
+	if delta <= 0 || delta >= 1 {
+		return nil, fmt.Errorf("delta needs to be in interval 0<x<1")
+	}
+
+	width := uint64(math.Ceil(3 / (epsilon * epsilon)))
+	depth := uint32(math.Ceil(math.Log(1 / delta)))
+
+	cs := newCountSketch(max(depth, 1)|1, width)
+	cs.keys = newKeyHasher(options)
+
+	return cs, nil
+}
+
+// newCountSketch builds an empty CountSketch of depth rows of width counters
+func newCountSketch(depth uint32, width uint64) *CountSketch {
+	return &CountSketch{
+		depth:    depth,
+		width:    width,
+		counters: make([]int64, uint64(depth)*width),
+	}
+}
+
+// cell returns the index of the key's counter in a row and the sign it adds with
+func (cs *CountSketch) cell(h1, h2 uint64, row uint64) (uint64, int64) {
+	index := row*cs.width + (h1+row*h2)%cs.width
+	if mix64(h1^row)>>63 == 1 {
+		return index, -1
+	}
+
+	return index, 1
+}
+
+// add adds count times the key's sign to its counter in every row
+func (cs *CountSketch) add(h uint64, count int64) {
+	h1, h2 := bloomHashes(h)
+	for row := uint64(0); row < uint64(cs.depth); row++ {
+		index, sign := cs.cell(h1, h2, row)
+		cs.counters[index] += sign * count
+	}
+}
+
+// estimate returns the median of the key's counters times their signs
+func (cs *CountSketch) estimate(h uint64) int64 {
+	h1, h2 := bloomHashes(h)
+	estimates := make([]int64, cs.depth)
+	for row := range estimates {
+		index, sign := cs.cell(h1, h2, uint64(row))
+		estimates[row] = sign * cs.counters[index]
+	}
+	slices.Sort(estimates)
+
+	return estimates[len(estimates)/2]
+}
+
+// Add hashes some string and adds count to its net count, negative counts take away
+func (cs *CountSketch) Add(key string, count int64) {
+	cs.add(hashKey(&cs.keys, key), count)
+}
+
+// AddBytes hashes a byte slice and adds count to its net count like Add
+func (cs *CountSketch) AddBytes(key []byte, count int64) {
+	cs.add(hashKey(&cs.keys, key), count)
+}
+
+// Estimate returns some string's probable net count, which can be too high or too low
+func (cs *CountSketch) Estimate(key string) int64 {
+	return cs.estimate(hashKey(&cs.keys, key))
+}
+
+// EstimateBytes returns a byte slice's probable net count like Estimate
+func (cs *CountSketch) EstimateBytes(key []byte) int64 {
+	return cs.estimate(hashKey(&cs.keys, key))
+}
+
+// Merge adds every count in other into this sketch, as if this sketch had been given other's
+// items too. Both need the same width, depth and seed
+func (cs *CountSketch) Merge(other *CountSketch) error {
+	if cs.depth != other.depth || cs.width != other.width {
+		return fmt.Errorf("cannot merge %dx%d count sketch into %dx%d count sketch", other.depth, other.width, cs.depth, cs.width)
+	}
+
+	if err := cs.keys.compatible(&other.keys); err != nil {
+		return err
+	}
+
+	for i, counter := range other.counters {
+		cs.counters[i] += counter
+	}
+
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, writing a version byte, the depth as a
+// uint32, the width and seed as uint64s and then every counter in two's complement
+func (cs *CountSketch) MarshalBinary() ([]byte, error) {
+	data := make([]byte, countSketchHeaderSize, countSketchHeaderSize+8*len(cs.counters))
+	data[0] = countSketchBinaryVersion
+	binary.BigEndian.PutUint32(data[1:5], cs.depth)
+	binary.BigEndian.PutUint64(data[5:13], cs.width)
+	binary.BigEndian.PutUint64(data[13:21], cs.keys.seed)
+
+	for _, counter := range cs.counters {
+		data = binary.BigEndian.AppendUint64(data, uint64(counter))
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing the sketch with one read from
+// MarshalBinary output. The sketch keeps its own Hasher, and once built its seed has to match
+func (cs *CountSketch) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return fmt.Errorf("binary data is too short")
+	}
+
+	if data[0] != countSketchBinaryVersion {
+		return fmt.Errorf("%w %d", ErrUnsupportedVersion, data[0])
+	}
+
+	if len(data) < countSketchHeaderSize {
+		return fmt.Errorf("binary data is too short")
+	}
+
+	depth := binary.BigEndian.Uint32(data[1:5])
+	width := binary.BigEndian.Uint64(data[5:13])
+	if depth == 0 || width == 0 {
+		return fmt.Errorf("count sketch needs at least one row and one column")
+	}
+
+	counters := data[countSketchHeaderSize:]
+	if uint64(len(counters))/8/width != uint64(depth) || uint64(len(counters)) != 8*width*uint64(depth) {
+		return fmt.Errorf("got %d bytes of counters but %dx%d counters needs %d", len(counters), depth, width, 8*width*uint64(depth))
+	}
+
+	seed := binary.BigEndian.Uint64(data[13:21])
+
+	keys := cs.keys
+	if err := keys.decodeSeed(seed, cs.width != 0); err != nil {
+		return err
+	}
+
+	decoded := newCountSketch(depth, width)
+	decoded.keys = keys
+	for i := range decoded.counters {
+		decoded.counters[i] = int64(binary.BigEndian.Uint64(counters[8*i:]))
+	}
+
+	*cs = *decoded
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package pds
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCountSketchSignedCounts(t *testing.T) {
+	cs, err := NewCountSketch(0.05, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		cs.Add(fmt.Sprintf("item-%d", i), 10)
+	}
+
+	// Taking counts back out leaves the net count
+	cs.AddBytes([]byte("heavy"), 5000)
+	cs.Add("heavy", -2000)
+
+	// Within epsilon times the root of the sum of squared counts
+	bound := int64(0.05 * 3200)
+	if got := cs.EstimateBytes([]byte("heavy")); got < 3000-bound || got > 3000+bound {
+		t.Fatalf("got estimate %d for heavy, wanted 3000 within %d", got, bound)
+	}
+}
+
+func TestCountSketchBinaryRoundTrip(t *testing.T) {
+	cs, err := NewCountSketch(0.1, 0.1, WithKeySeed(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		cs.Add(fmt.Sprintf("item-%d", i), int64(i)-50)
+	}
+
+	data, err := cs.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded CountSketch
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("item-%d", i)
+		if decoded.Estimate(key) != cs.Estimate(key) {
+			t.Fatalf("estimates of %s differ after decoding", key)
+		}
+	}
+
+	if err := decoded.Merge(cs); err != nil {
+		t.Fatal(err)
+	}
+
+	unseeded, _ := NewCountSketch(0.1, 0.1)
+	if err := unseeded.Merge(cs); err == nil {
+		t.Fatalf("Merge accepted a differently seeded sketch")
+	}
+
+	if err := unseeded.UnmarshalBinary(data); err == nil {
+		t.Fatalf("decoded a seeded sketch into an unseeded one")
+	}
+}
@@ -0,0 +1,259 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// Cuckoo filter buckets hold cuckooSlots fingerprints and are kept at most cuckooLoad full
+// when sizing, past which inserts start failing. Inserts give up after cuckooMaxKicks moves
+const (
+	cuckooSlots    = 4
+	cuckooLoad     = 0.95
+	cuckooMaxKicks = 500
+)
+
+// CuckooFilter tests whether items might have been added like a BloomFilter, but keeps a short
+// fingerprint of each item so they can be deleted again, following Fan et al.'s "Cuckoo
+// Filter: Practically Better Than Bloom". Each item has two candidate buckets, the second
+// found from the first and the fingerprint alone, so fingerprints can be moved between them
+// to make room. Adding the same item more than a few times fills both its buckets
+type CuckooFilter struct {
+	table           []uint64
+	bucketMask      uint64
+	fingerprintBits uint
+	fingerprintMask uint64
+	count           uint
+	rng             splitMix64
+	keys            keyHasher
+
+	// A fingerprint that couldn't be placed when the filter filled up is kept here so it isn't
+	// lost, and no more items are taken until a delete makes room for it
+	victim       uint16
+	victimBucket uint64
+	hasVictim    bool
+}
+
+// NewCuckooFilter builds a new CuckooFilter with room for capacity items keeping fingerprints
+// of fingerprintBits bits, from 4 to 16, packed end to end so each item takes fingerprintBits
+// bits whatever the size. The false positive rate is about 8 / 2^fingerprintBits. Options set
+// how keys are hashed
+func NewCuckooFilter(capacity uint, fingerprintBits uint, options ...KeyOption) (*CuckooFilter, error) {
+	if capacity == 0 {
+		return nil, fmt.Errorf("need room for at least one item")
+	}
+
+	if fingerprintBits < 4 || fingerprintBits > 16 {
+		return nil, fmt.Errorf("fingerprint bits need to be in interval 4>=x>=16")
+	}
+
+	// The second bucket is found by xor, which needs a power of two number of buckets
+	needed := uint64(math.Ceil(float64(capacity) / cuckooSlots / cuckooLoad))
+	buckets := uint64(1) << bits.Len64(needed-1)
+
+	// One spare word so slots can always be read as a pair of words
+	return &CuckooFilter{
+		table:           make([]uint64, (uint64(fingerprintBits)*buckets*cuckooSlots+63)/64+1),
+		bucketMask:      buckets - 1,
+		fingerprintBits: fingerprintBits,
+		fingerprintMask: 1<<fingerprintBits - 1,
+		keys:            newKeyHasher(options),
+	}, nil
+}
+
+// locate returns the item's fingerprint and first bucket. Fingerprints are never 0 as that
+// marks an empty slot
+func (cf *CuckooFilter) locate(h uint64) (uint16, uint64) {
+	h = mix64(h)
+	fingerprint := uint16(max((h>>32)&cf.fingerprintMask, 1))
+
+	return fingerprint, h & cf.bucketMask
+}
+
+// altBucket returns the other bucket of a fingerprint found in bucket, working both ways
+func (cf *CuckooFilter) altBucket(bucket uint64, fingerprint uint16) uint64 {
+	return (bucket ^ mix64(uint64(fingerprint))) & cf.bucketMask
+}
+
+// slot reads the fingerprint packed into the slot'th slot of a bucket
+func (cf *CuckooFilter) slot(index uint64, slot uint64) uint16 {
+	slotBits := uint64(cf.fingerprintBits)
+	bit := (index*cuckooSlots + slot) * slotBits
+	word, offset := bit/64, bit%64
+
+	value := cf.table[word] >> offset
+	if offset+slotBits > 64 {
+		value |= cf.table[word+1] << (64 - offset)
+	}
+
+	return uint16(value & cf.fingerprintMask)
+}
+
+// setSlot overwrites the fingerprint in the slot'th slot of a bucket
+func (cf *CuckooFilter) setSlot(index uint64, slot uint64, fingerprint uint16) {
+	slotBits := uint64(cf.fingerprintBits)
+	mask := cf.fingerprintMask
+	value := uint64(fingerprint)
+	bit := (index*cuckooSlots + slot) * slotBits
+	word, offset := bit/64, bit%64
+
+	cf.table[word] = cf.table[word]&^(mask<<offset) | value<<offset
+	if offset+slotBits > 64 {
+		cf.table[word+1] = cf.table[word+1]&^(mask>>(64-offset)) | value>>(64-offset)
+	}
+}
+
+// find returns the first slot of the bucket holding the fingerprint, where a fingerprint of
+// 0 finds a free slot
+func (cf *CuckooFilter) find(index uint64, fingerprint uint16) (uint64, bool) {
+	for slot := uint64(0); slot < cuckooSlots; slot++ {
+		if cf.slot(index, slot) == fingerprint {
+			return slot, true
+		}
+	}
+
+	return 0, false
+}
+
+// insert puts the fingerprint in a free slot of the bucket, reporting whether there was one
+func (cf *CuckooFilter) insert(index uint64, fingerprint uint16) bool {
+	slot, ok := cf.find(index, 0)
+	if ok {
+		cf.setSlot(index, slot, fingerprint)
+	}
+
+	return ok
+}
+
+// add places the fingerprint in either of its buckets, moving others to their other bucket to
+// make room
+func (cf *CuckooFilter) add(h uint64) bool {
+	if cf.hasVictim {
+		return false
+	}
+
+	fingerprint, i1 := cf.locate(h)
+	i2 := cf.altBucket(i1, fingerprint)
+	if cf.insert(i1, fingerprint) || cf.insert(i2, fingerprint) {
+		cf.count++
+		return true
+	}
+
+	index := i1
+	if cf.rng.next()&1 == 1 {
+		index = i2
+	}
+
+	for kick := 0; kick < cuckooMaxKicks; kick++ {
+		slot := cf.rng.next() % cuckooSlots
+		evicted := cf.slot(index, slot)
+		cf.setSlot(index, slot, fingerprint)
+		fingerprint = evicted
+
+		index = cf.altBucket(index, fingerprint)
+		if cf.insert(index, fingerprint) {
+			cf.count++
+			return true
+		}
+	}
+
+	// The item itself is in now, whichever fingerprint was left over waits for room
+	cf.victim, cf.victimBucket, cf.hasVictim = fingerprint, index, true
+	cf.count++
+
+	return true
+}
+
+// has reports whether the bucket holds the fingerprint
+func (cf *CuckooFilter) has(index uint64, fingerprint uint16) bool {
+	_, ok := cf.find(index, fingerprint)
+
+	return ok
+}
+
+// contains reports whether either of the item's buckets, or the victim, holds its fingerprint
+func (cf *CuckooFilter) contains(h uint64) bool {
+	fingerprint, i1 := cf.locate(h)
+	i2 := cf.altBucket(i1, fingerprint)
+	if cf.has(i1, fingerprint) || cf.has(i2, fingerprint) {
+		return true
+	}
+
+	return cf.hasVictim && cf.victim == fingerprint && (cf.victimBucket == i1 || cf.victimBucket == i2)
+}
+
+// remove takes one copy of the item's fingerprint out of either of its buckets or the victim,
+// then tries placing the victim again with the room freed up
+func (cf *CuckooFilter) remove(h uint64) bool {
+	fingerprint, i1 := cf.locate(h)
+	i2 := cf.altBucket(i1, fingerprint)
+
+	removed := cf.removeFrom(i1, fingerprint) || cf.removeFrom(i2, fingerprint)
+	if !removed && cf.hasVictim && cf.victim == fingerprint && (cf.victimBucket == i1 || cf.victimBucket == i2) {
+		cf.hasVictim = false
+		removed = true
+	}
+
+	if !removed {
+		return false
+	}
+	cf.count--
+
+	if cf.hasVictim {
+		victim, index := cf.victim, cf.victimBucket
+		if cf.insert(index, victim) || cf.insert(cf.altBucket(index, victim), victim) {
+			cf.hasVictim = false
+		}
+	}
+
+	return true
+}
+
+// removeFrom clears one slot of the bucket holding the fingerprint, reporting whether there was one
+func (cf *CuckooFilter) removeFrom(index uint64, fingerprint uint16) bool {
+	slot, ok := cf.find(index, fingerprint)
+	if ok {
+		cf.setSlot(index, slot, 0)
+	}
+
+	return ok
+}
+
+// Add hashes and puts some string into the filter, returning false if the filter is too full
+// to take it
+func (cf *CuckooFilter) Add(s string) bool {
+	return cf.add(hashKey(&cf.keys, s))
+}
+
+// AddBytes hashes and puts a byte slice into the filter like Add
+func (cf *CuckooFilter) AddBytes(b []byte) bool {
+	return cf.add(hashKey(&cf.keys, b))
+}
+
+// Contains reports whether some string might be in the filter, false means it definitely isn't
+func (cf *CuckooFilter) Contains(s string) bool {
+	return cf.contains(hashKey(&cf.keys, s))
+}
+
+// ContainsBytes reports whether a byte slice might be in the filter
+func (cf *CuckooFilter) ContainsBytes(b []byte) bool {
+	return cf.contains(hashKey(&cf.keys, b))
+}
+
+// Delete takes one copy of some string back out of the filter, returning false if it
+// definitely wasn't there. Only delete items that were added, deleting a false positive
+// takes out another item's fingerprint
+func (cf *CuckooFilter) Delete(s string) bool {
+	return cf.remove(hashKey(&cf.keys, s))
+}
+
+// DeleteBytes takes one copy of a byte slice back out of the filter like Delete
+func (cf *CuckooFilter) DeleteBytes(b []byte) bool {
+	return cf.remove(hashKey(&cf.keys, b))
+}
+
+// Count returns how many items are in the filter
+func (cf *CuckooFilter) Count() uint {
+	return cf.count
+}
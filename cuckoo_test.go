@@ -0,0 +1,92 @@
+package pds
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCuckooFilterAddDelete(t *testing.T) {
+	cf, err := NewCuckooFilter(10000, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 9000; i++ {
+		if !cf.Add(fmt.Sprintf("item-%d", i)) {
+			t.Fatalf("filter filled up after %d of its 10000 items", i)
+		}
+	}
+
+	for i := 0; i < 4500; i++ {
+		if !cf.DeleteBytes([]byte(fmt.Sprintf("item-%d", i))) {
+			t.Fatalf("item-%d couldn't be deleted", i)
+		}
+	}
+
+	if cf.Count() != 4500 {
+		t.Fatalf("got count %d, wanted 4500", cf.Count())
+	}
+
+	for i := 4500; i < 9000; i++ {
+		if !cf.Contains(fmt.Sprintf("item-%d", i)) {
+			t.Fatalf("item-%d went missing after deleting others", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < 100000; i++ {
+		if cf.ContainsBytes([]byte(fmt.Sprintf("other-%d", i))) {
+			falsePositives++
+		}
+	}
+
+	if rate := float64(falsePositives) / 100000; rate > 8.0/(1<<12) {
+		t.Fatalf("got false positive rate %.4f, wanted below %.4f", rate, 8.0/(1<<12))
+	}
+}
+
+func TestCuckooFilterKeyOptions(t *testing.T) {
+	calls := 0
+	cf, err := NewCuckooFilter(100, 8, WithKeyHasher(countingHasher{calls: &calls}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cf.Add("a")
+	if !cf.Contains("a") || !cf.Delete("a") || calls != 3 {
+		t.Fatalf("got %d calls to the hasher, wanted 3", calls)
+	}
+}
+
+func TestCuckooFilterPacksFingerprints(t *testing.T) {
+	// Sizes that leave slots straddling words, each taking only its own bits
+	for _, fingerprintBits := range []uint{4, 5, 9, 13, 16} {
+		cf, err := NewCuckooFilter(1000, fingerprintBits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		slots := (cf.bucketMask + 1) * cuckooSlots
+		if words := uint64(len(cf.table)); words > (slots*uint64(fingerprintBits)+63)/64+1 {
+			t.Fatalf("got %d words for %d slots of %d bits", words, slots, fingerprintBits)
+		}
+
+		for i := 0; i < 1000; i++ {
+			if !cf.Add(fmt.Sprintf("item-%d", i)) {
+				t.Fatalf("ran out of room at item %d with %d bit fingerprints", i, fingerprintBits)
+			}
+		}
+
+		for i := 0; i < 1000; i += 2 {
+			if !cf.Delete(fmt.Sprintf("item-%d", i)) {
+				t.Fatalf("couldn't delete item %d with %d bit fingerprints", i, fingerprintBits)
+			}
+		}
+
+		for i := 1; i < 1000; i += 2 {
+			if !cf.Contains(fmt.Sprintf("item-%d", i)) {
+				t.Fatalf("lost item %d with %d bit fingerprints", i, fingerprintBits)
+			}
+		}
+	}
+}
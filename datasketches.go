@@ -0,0 +1,376 @@
+package pds
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+//go:generate go run datasketchesdata_gen.go
+
+// Preamble layout shared with the Apache DataSketches HLL sketch, all fields little endian
+const (
+	dsSerialVersion   = 1
+	dsFamilyID        = 7
+	dsListPreInts     = 2
+	dsSetPreInts      = 3
+	dsHllPreInts      = 10
+	dsListStart       = 8
+	dsSetStart        = 12
+	dsHllStart        = 40
+	dsLgInitListSize  = 3
+	dsEmptyFlag       = 4
+	dsCompactFlag     = 8
+	dsOutOfOrderFlag  = 16
+	dsListMode        = 0
+	dsSetMode         = 1
+	dsHllMode         = 2
+	dsHll4            = 0
+	dsHll6            = 1
+	dsHll8            = 2
+	dsAuxToken        = 15
+	dsCouponValueBits = 26
+	dsCouponSlotMask  = 1<<dsCouponValueBits - 1
+	dsMinLgK          = 4
+	dsMaxLgK          = 21
+	dsHashSeed        = 9001
+)
+
+// DataSketchesHasher hashes with 128 bit MurmurHash3 seeded with 9001 like the Apache
+// DataSketches HLL sketch, so a sketch with IndexBits index bits, With64BitHash and
+// WithHasher(DataSketchesHasher{IndexBits: indexBits}) fills the same buckets as a DataSketches
+// sketch with that lgK does for the same items. DataSketches picks the bucket from the first
+// half of the hash and counts the leading zeros of the second, so Hash64 puts the second half
+// reversed above the index bits of the first. DataSketches skips empty items, which this
+// package still counts. Any seed is xored into 9001
+type DataSketchesHasher struct {
+	IndexBits uint32
+}
+
+// Hash64 returns DataSketches' MurmurHash3 hash of data laid out for IndexBits index bits
+func (d DataSketchesHasher) Hash64(data []byte, seed uint64) uint64 {
+	h0, h1 := murmur3x64(data, dsHashSeed^seed)
+	mask := uint64(1)<<d.IndexBits - 1
+
+	return h0&mask | bits.Reverse64(h1)<<d.IndexBits
+}
+
+// Hash32 returns the 64 bit hash of data folded down to 32 bits
+func (d DataSketchesHasher) Hash32(data []byte, seed uint32) uint32 {
+	h := d.Hash64(data, uint64(seed))
+
+	return uint32(h ^ h>>32)
+}
+
+// murmur3x64 is the 128 bit x64 variant of Austin Appleby's MurmurHash3, returning both halves
+func murmur3x64(data []byte, seed uint64) (uint64, uint64) {
+	const c1 = 0x87c37b91114253d5
+	const c2 = 0x4cf5ad432745937f
+
+	length := uint64(len(data))
+	h1, h2 := seed, seed
+	for ; len(data) >= 16; data = data[16:] {
+		k1 := binary.LittleEndian.Uint64(data)
+		k2 := binary.LittleEndian.Uint64(data[8:])
+
+		h1 ^= bits.RotateLeft64(k1*c1, 31) * c2
+		h1 = bits.RotateLeft64(h1, 27) + h2
+		h1 = h1*5 + 0x52dce729
+
+		h2 ^= bits.RotateLeft64(k2*c2, 33) * c1
+		h2 = bits.RotateLeft64(h2, 31) + h1
+		h2 = h2*5 + 0x38495ab5
+	}
+
+	var k1, k2 uint64
+	for i := len(data) - 1; i >= 8; i-- {
+		k2 ^= uint64(data[i]) << (8 * (i - 8))
+	}
+	for i := min(len(data), 8) - 1; i >= 0; i-- {
+		k1 ^= uint64(data[i]) << (8 * i)
+	}
+
+	if len(data) > 8 {
+		h2 ^= bits.RotateLeft64(k2*c2, 33) * c1
+	}
+	if len(data) > 0 {
+		h1 ^= bits.RotateLeft64(k1*c1, 31) * c2
+	}
+
+	h1 ^= length
+	h2 ^= length
+	h1 += h2
+	h2 += h1
+	h1 = mix64(h1)
+	h2 = mix64(h2)
+	h1 += h2
+	h2 += h1
+
+	return h1, h2
+}
+
+// MarshalDataSketches writes the HyperLogLog in the Apache DataSketches HLL_8 serialization
+// so it can be read and unioned by the Java library. The historic inverse probability
+// accumulator is only estimated, so the out of order flag is set and DataSketches falls back
+// to its composite estimator. Unless the sketch hashes with DataSketchesHasher and
+// With64BitHash the same item added on both sides lands in different buckets, so estimates
+// carry over but unioning sketches of overlapping items will count the overlap twice
+func (hll *HyperLogLog) MarshalDataSketches() ([]byte, error) {
+	buckets := hll.registers()
+	zeros := buckets.countZeroBuckets()
+	if zeros == buckets.Len() {
+		return []byte{
+			dsListPreInts,
+			dsSerialVersion,
+			dsFamilyID,
+			byte(hll.indexBits),
+			dsLgInitListSize,
+			dsEmptyFlag | dsCompactFlag,
+			0,
+			dsListMode | dsHll8<<2,
+		}, nil
+	}
+
+	data := make([]byte, dsHllStart+len(buckets))
+	data[0] = dsHllPreInts
+	data[1] = dsSerialVersion
+	data[2] = dsFamilyID
+	data[3] = byte(hll.indexBits)
+	data[5] = dsOutOfOrderFlag
+	data[7] = dsHllMode | dsHll8<<2
+
+	// HLL_8 sketches keep their minimum at 0, so the count at the minimum is the empty buckets
+	var kxq0, kxq1 float64
+	for i, bucket := range buckets {
+		data[dsHllStart+i] = bucket
+
+		if bucket < 32 {
+			kxq0 += math.Pow(2, -float64(bucket))
+		} else {
+			kxq1 += math.Pow(2, -float64(bucket))
+		}
+	}
+
+	binary.LittleEndian.PutUint64(data[8:], math.Float64bits(float64(hll.bucketEstimate())))
+	binary.LittleEndian.PutUint64(data[16:], math.Float64bits(kxq0))
+	binary.LittleEndian.PutUint64(data[24:], math.Float64bits(kxq1))
+	binary.LittleEndian.PutUint32(data[32:], uint32(zeros))
+
+	return data, nil
+}
+
+// UnmarshalDataSketches loads an Apache DataSketches HLL sketch in LIST, SET or HLL mode with
+// HLL_4, HLL_6 or HLL_8 registers like UnmarshalBinary. DataSketches hashes into 64 bits so the
+// result uses 64 bit hashes too, and a configured sketch needs With64BitHash. Sketches with an
+// lgK over 16 or a register too large for a 64 bit hash of lgK index bits are rejected. See
+// DataSketchesHasher for adding to it the way DataSketches does
+func (hll *HyperLogLog) UnmarshalDataSketches(data []byte) error {
+	if len(data) < dsListStart {
+		return fmt.Errorf("datasketches data is too short")
+	}
+
+	if data[1] != dsSerialVersion {
+		return fmt.Errorf("%w %d", ErrUnsupportedVersion, data[1])
+	}
+
+	if data[2] != dsFamilyID {
+		return fmt.Errorf("datasketches family %d is not hll", data[2])
+	}
+
+	lgK := uint32(data[3])
+	if lgK < dsMinLgK || lgK > dsMaxLgK {
+		return fmt.Errorf("datasketches lgK %d out of range", lgK)
+	}
+
+	if lgK > 16 {
+		return fmt.Errorf("datasketches lgK %d is more than the 16 index bits a HyperLogLog can have", lgK)
+	}
+
+	decoded, err := NewHyperLogLog(lgK, With64BitHash())
+	if err != nil {
+		return err
+	}
+
+	if data[5]&dsEmptyFlag != 0 {
+		return hll.load(&decoded)
+	}
+
+	switch mode := data[7] & 3; mode {
+	case dsListMode:
+		err = decoded.applyCoupons(data, dsListPreInts, dsListStart, int(data[6]))
+	case dsSetMode:
+		if len(data) < dsSetStart {
+			return fmt.Errorf("datasketches data is too short")
+		}
+
+		count := int(1) << data[4]
+		if data[5]&dsCompactFlag != 0 {
+			count = int(binary.LittleEndian.Uint32(data[8:]))
+		}
+
+		err = decoded.applyCoupons(data, dsSetPreInts, dsSetStart, count)
+	case dsHllMode:
+		err = decoded.applyDataSketchesRegisters(data)
+	default:
+		err = fmt.Errorf("unknown datasketches mode %d", mode)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return hll.load(&decoded)
+}
+
+// checkDataSketchesValue returns an error unless a 64 bit hash of the sketch's index bits can
+// give the value DataSketches stored for the slot
+func (hll *HyperLogLog) checkDataSketchesValue(slot int, value uint8) error {
+	if int(value) > int(hll.runBits())+1 {
+		return fmt.Errorf("datasketches slot %d holds %d, more than %d index bits leave room for", slot, value, hll.indexBits)
+	}
+
+	return nil
+}
+
+// applyCoupons raises buckets from the 32 bit coupons of a LIST or SET mode sketch, each
+// holding a 26 bit slot with its value in the top 6 bits. Empty slots are zero
+func (hll *HyperLogLog) applyCoupons(data []byte, preInts byte, start int, count int) error {
+	if data[0] != preInts {
+		return fmt.Errorf("datasketches preamble has %d ints, expected %d", data[0], preInts)
+	}
+
+	if len(data) < start+4*count {
+		return fmt.Errorf("datasketches data is too short for %d coupons", count)
+	}
+
+	mask := uint32(hll.mBuckets - 1)
+	for i := 0; i < count; i++ {
+		coupon := binary.LittleEndian.Uint32(data[start+4*i:])
+		if coupon == 0 {
+			continue
+		}
+
+		// Coupons keep 26 bits of slot whatever lgK is, the low bits pick the bucket
+		index := coupon & dsCouponSlotMask & mask
+		value := uint8(coupon >> dsCouponValueBits)
+		if value == 0 {
+			return fmt.Errorf("datasketches coupon %d has no value", i)
+		}
+
+		if err := hll.checkDataSketchesValue(int(index), value); err != nil {
+			return err
+		}
+
+		if hll.bucketGroup[index] < value {
+			hll.bucketGroup[index] = value
+		}
+	}
+
+	return nil
+}
+
+// applyDataSketchesRegisters loads the HLL_4, HLL_6 or HLL_8 registers of an HLL mode sketch
+func (hll *HyperLogLog) applyDataSketchesRegisters(data []byte) error {
+	if data[0] != dsHllPreInts {
+		return fmt.Errorf("datasketches preamble has %d ints, expected %d", data[0], dsHllPreInts)
+	}
+
+	if len(data) < dsHllStart {
+		return fmt.Errorf("datasketches data is too short")
+	}
+
+	registers := data[dsHllStart:]
+	m := len(hll.bucketGroup)
+
+	var values []uint8
+	switch hllType := data[7] >> 2 & 3; hllType {
+	case dsHll8:
+		if len(registers) < m {
+			return fmt.Errorf("datasketches data is too short for %d registers", m)
+		}
+
+		values = registers[:m]
+	case dsHll6:
+		// Registers are packed 6 bits apiece and read two bytes at a time
+		if len(registers) < m*3/4+1 {
+			return fmt.Errorf("datasketches data is too short for %d registers", m)
+		}
+
+		values = make([]uint8, m)
+		for i := range values {
+			startBit := i * 6
+			pair := binary.LittleEndian.Uint16(registers[startBit/8:])
+			values[i] = byte(pair>>(startBit&7)) & 0x3f
+		}
+	case dsHll4:
+		var err error
+		if values, err = dsHll4Values(data, m); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown datasketches hll type %d", hllType)
+	}
+
+	for i, value := range values {
+		if err := hll.checkDataSketchesValue(i, value); err != nil {
+			return err
+		}
+
+		hll.bucketGroup[i] = value
+	}
+
+	return nil
+}
+
+// dsHll4Values unpacks HLL_4 registers, 4 bits apiece above the sketch's minimum with the low
+// nibble first. Registers too far above the minimum hold the aux token and are looked up in
+// the exception table after the registers, which holds every entry when compact and is a
+// hash table with empty slots of zero otherwise
+func dsHll4Values(data []byte, m int) ([]uint8, error) {
+	curMin := data[6]
+	auxStart := dsHllStart + m/2
+
+	auxCount := int(binary.LittleEndian.Uint32(data[36:]))
+	auxSlots := auxCount
+	if data[5]&dsCompactFlag == 0 && auxCount > 0 {
+		auxSlots = int(1) << data[4]
+	}
+
+	if len(data) < auxStart+4*auxSlots {
+		return nil, fmt.Errorf("datasketches data is too short for %d registers and %d exceptions", m, auxCount)
+	}
+
+	exceptions := make(map[int]uint8, auxCount)
+	for i := 0; i < auxSlots; i++ {
+		entry := binary.LittleEndian.Uint32(data[auxStart+4*i:])
+		if entry == 0 {
+			continue
+		}
+
+		slot := int(entry & dsCouponSlotMask)
+		if slot >= m {
+			return nil, fmt.Errorf("datasketches exception for slot %d of %d", slot, m)
+		}
+
+		exceptions[slot] = uint8(entry >> dsCouponValueBits)
+	}
+
+	values := make([]uint8, m)
+	for i := range values {
+		nibble := data[dsHllStart+i/2] >> (4 * (i & 1)) & 0xf
+		if nibble != dsAuxToken {
+			values[i] = curMin + nibble
+			continue
+		}
+
+		value, ok := exceptions[i]
+		if !ok {
+			return nil, fmt.Errorf("datasketches slot %d has no exception", i)
+		}
+
+		values[i] = value
+	}
+
+	return values, nil
+}
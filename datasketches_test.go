@@ -0,0 +1,131 @@
+package pds
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestDataSketchesRoundTrip(t *testing.T) {
+	for _, n := range []int{0, 5000} {
+		hll := filledSketch(t, 10, n)
+
+		data, err := hll.MarshalDataSketches()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded HyperLogLog
+		if err := decoded.UnmarshalDataSketches(data); err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.Equal(decoded.bucketGroup, hll.bucketGroup) {
+			t.Fatalf("buckets differ after decoding %d items", n)
+		}
+	}
+}
+
+func TestUnmarshalDataSketchesList(t *testing.T) {
+	// Two coupons, slot 3 with value 5 and slot 1027 with value 2, which wraps to bucket 3
+	// of a 10 index bit sketch and only raises it if larger
+	data := []byte{dsListPreInts, dsSerialVersion, dsFamilyID, 10, dsLgInitListSize, dsCompactFlag, 2, dsListMode | dsHll8<<2}
+	data = binary.LittleEndian.AppendUint32(data, 5<<dsCouponValueBits|3)
+	data = binary.LittleEndian.AppendUint32(data, 2<<dsCouponValueBits|1027)
+
+	var hll HyperLogLog
+	if err := hll.UnmarshalDataSketches(data); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, b := range hll.bucketGroup {
+		var want uint8
+		if i == 3 {
+			want = 5
+		}
+
+		if b != want {
+			t.Fatalf("got %d in bucket %d, wanted %d", b, i, want)
+		}
+	}
+
+	if err := hll.UnmarshalDataSketches(data[:len(data)-1]); err == nil {
+		t.Fatalf("decoded a truncated list")
+	}
+}
+
+func TestUnmarshalDataSketchesRejectsBadData(t *testing.T) {
+	hll := filledSketch(t, 10, 100)
+	data, err := hll.MarshalDataSketches()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, corrupt := range map[string]func([]byte){
+		"family":    func(d []byte) { d[2] = 3 },
+		"version":   func(d []byte) { d[1] = 9 },
+		"lgK":       func(d []byte) { d[3] = 2 },
+		"large lgK": func(d []byte) { d[3] = 17 },
+		"register":  func(d []byte) { d[dsHllStart] = 64 - 10 + 2 },
+	} {
+		bad := slices.Clone(data)
+		corrupt(bad)
+
+		var decoded HyperLogLog
+		if err := decoded.UnmarshalDataSketches(bad); err == nil {
+			t.Errorf("decoded data with a bad %s", name)
+		}
+	}
+}
+
+func TestUnmarshalDataSketchesFixtures(t *testing.T) {
+	// Generated by datasketchesdata_gen.go's port of HllSketch rather than captured from Java,
+	// the HLL_4 image has a raised minimum and a couple of exceptions
+	tests := []struct {
+		path  string
+		items int
+	}{
+		{"testdata/datasketches_list.hll", 5},
+		{"testdata/datasketches_set.hll", 100},
+		{"testdata/datasketches_hll4.hll", 200000},
+		{"testdata/datasketches_hll6.hll", 200000},
+		{"testdata/datasketches_hll8.hll", 200000},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			data, err := os.ReadFile(test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded HyperLogLog
+			if err := decoded.UnmarshalDataSketches(data); err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := NewHyperLogLog(14, With64BitHash(), WithHasher(DataSketchesHasher{IndexBits: 14}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < test.items; i++ {
+				want.Add(fmt.Sprintf("item-%d", i))
+			}
+
+			if !slices.Equal(decoded.bucketGroup, want.bucketGroup) {
+				t.Fatalf("decoded registers differ from adding the same items with DataSketchesHasher")
+			}
+		})
+	}
+}
+
+func TestMurmur3x64(t *testing.T) {
+	// The reference vector of smhasher's MurmurHash3_x64_128
+	h0, h1 := murmur3x64([]byte("The quick brown fox jumps over the lazy dog"), 0)
+	if h0 != 0xe34bbc7bbc071b6c || h1 != 0x7a433ca9c49a9347 {
+		t.Fatalf("got %x %x, wanted e34bbc7bbc071b6c 7a433ca9c49a9347", h0, h1)
+	}
+}
@@ -0,0 +1,171 @@
+package pds
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// MarshalDebug writes the HyperLogLog as human editable text, the index bits on the first
+// line followed by an "index value" line for every non empty bucket
+// eg. indexBits=10\n0 3\n5 7\n
+// Sketches using 64 bit hashes add hashBits=64 to the first line and seeded ones seed=N
+func (hll *HyperLogLog) MarshalDebug() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "indexBits=%d", hll.indexBits)
+	if hll.wideHash {
+		sb.WriteString(" hashBits=64")
+	}
+
+	if hll.seed != 0 {
+		fmt.Fprintf(&sb, " seed=%d", hll.seed)
+	}
+	sb.WriteByte('\n')
+
+	for i, bucket := range hll.registers() {
+		if bucket != 0 {
+			fmt.Fprintf(&sb, "%d %d\n", i, bucket)
+		}
+	}
+
+	return sb.String()
+}
+
+// UnmarshalDebug loads a sketch from the MarshalDebug text format like UnmarshalBinary
+func (hll *HyperLogLog) UnmarshalDebug(data string) error {
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	if !scanner.Scan() {
+		return fmt.Errorf("missing indexBits line")
+	}
+
+	indexBits, options, err := parseDebugHeader(scanner.Text())
+	if err != nil {
+		return err
+	}
+
+	decoded, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		return err
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var index int64
+		var value int
+		if _, err := fmt.Sscanf(line, "%d %d", &index, &value); err != nil {
+			return fmt.Errorf("invalid bucket line %q: %v", line, err)
+		}
+
+		if index < 0 || index >= decoded.mBuckets {
+			return fmt.Errorf("bucket index %d out of range", index)
+		}
+
+		if value < 0 || value > int(decoded.runBits())+1 {
+			return fmt.Errorf("bucket value %d out of range", value)
+		}
+
+		decoded.bucketGroup[index] = uint8(value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return hll.load(&decoded)
+}
+
+// parseDebugHeader reads the index bits, hash width and seed from the first line of
+// MarshalDebug text
+func parseDebugHeader(line string) (uint32, []Option, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return 0, nil, fmt.Errorf("invalid indexBits line %q", line)
+	}
+
+	var indexBits uint32
+	if _, err := fmt.Sscanf(fields[0], "indexBits=%d", &indexBits); err != nil {
+		return 0, nil, fmt.Errorf("invalid indexBits line %q: %v", line, err)
+	}
+
+	var options []Option
+	for _, field := range fields[1:] {
+		var seed uint64
+		switch {
+		case field == "hashBits=32":
+		case field == "hashBits=64":
+			options = append(options, With64BitHash())
+		case strings.HasPrefix(field, "seed="):
+			if _, err := fmt.Sscanf(field, "seed=%d", &seed); err != nil {
+				return 0, nil, fmt.Errorf("invalid seed %q: %v", field, err)
+			}
+
+			options = append(options, WithSeed(seed))
+		default:
+			return 0, nil, fmt.Errorf("unknown setting %q on indexBits line", field)
+		}
+	}
+
+	return indexBits, options, nil
+}
+
+// heatmapShades goes from empty buckets through to the densest characters for the largest runs
+const heatmapShades = " .:-=+*#%@"
+
+// Heatmap renders the buckets as a grid of shaded characters wrapping every width buckets,
+// the denser the character the longer the zero run seen by that bucket
+func (hll *HyperLogLog) Heatmap(width int) string {
+	buckets := hll.registers()
+	if width < 1 {
+		width = len(buckets)
+	}
+
+	maxValue := int(hll.runBits()) + 1
+	maxShade := len(heatmapShades) - 1
+
+	var sb strings.Builder
+	for i, bucket := range buckets {
+		if i > 0 && i%width == 0 {
+			sb.WriteByte('\n')
+		}
+
+		shade := (int(bucket)*maxShade + maxValue - 1) / maxValue
+		if shade > maxShade {
+			shade = maxShade
+		}
+
+		sb.WriteByte(heatmapShades[shade])
+	}
+	sb.WriteByte('\n')
+
+	return sb.String()
+}
+
+// RegisterHistogram returns how many buckets hold each value, leaving out values no bucket
+// holds. Under a good hash the counts roughly halve from one value to the next once past the
+// typical run length, lumps or gaps point at bad hashing
+func (hll *HyperLogLog) RegisterHistogram() map[int]int {
+	histogram := make(map[int]int)
+	for value, count := range hll.registers().histogram(int(hll.runBits()) + 1) {
+		if count != 0 {
+			histogram[value] = count
+		}
+	}
+
+	return histogram
+}
+
+// NonZeroRegisters returns how many buckets have been raised from empty
+func (hll *HyperLogLog) NonZeroRegisters() int {
+	var nonZero int
+	for _, bucket := range hll.registers() {
+		if bucket != 0 {
+			nonZero++
+		}
+	}
+
+	return nonZero
+}
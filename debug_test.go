@@ -0,0 +1,148 @@
+package pds
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestDebugRoundTrip(t *testing.T) {
+	for _, test := range encodingTests {
+		t.Run(test.name, func(t *testing.T) {
+			hll := filledSketch(t, 8, 500, test.options...)
+
+			var decoded HyperLogLog
+			if err := decoded.UnmarshalDebug(hll.MarshalDebug()); err != nil {
+				t.Fatal(err)
+			}
+
+			assertSameSketch(t, &decoded, &hll)
+		})
+	}
+}
+
+func TestUnmarshalDebugAuthoredState(t *testing.T) {
+	var full, half strings.Builder
+	full.WriteString("indexBits=4\n")
+	half.WriteString("indexBits=4 seed=3\n")
+	for i := 0; i < 16; i++ {
+		fmt.Fprintf(&full, "%d 1\n", i)
+		if i%2 == 0 {
+			fmt.Fprintf(&half, "%d 1\n", i)
+		}
+	}
+
+	for _, test := range []struct {
+		name string
+		data string
+		want int64
+	}{
+		// 0.673 * 16^2 / (16 * 2^-1) is 21.5, and with no empty buckets linear counting can't apply
+		{name: "every bucket at 1", data: full.String(), want: 21},
+		// Half the buckets empty falls to linear counting, 16 ln(16/8) or 11.09
+		{name: "half empty", data: half.String(), want: 11},
+		{name: "empty", data: "indexBits=10\n", want: 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var hll HyperLogLog
+			if err := hll.UnmarshalDebug(test.data); err != nil {
+				t.Fatal(err)
+			}
+
+			if hll.EstimateCardinality() != test.want {
+				t.Fatalf("got estimate %d, wanted %d", hll.EstimateCardinality(), test.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalDebugRejectsBadLines(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"indexBits=3\n",
+		"indexBits=4 colour=red\n",
+		"indexBits=4\n16 1\n",
+		"indexBits=4\n0 30\n",
+		"indexBits=4\nzero one\n",
+	} {
+		var hll HyperLogLog
+		if err := hll.UnmarshalDebug(data); err == nil {
+			t.Errorf("wanted an error decoding %q", data)
+		}
+	}
+}
+
+func TestHeatmap(t *testing.T) {
+	hll, err := NewHyperLogLog(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Values climb from empty up to the longest run the sketch can hold
+	maxValue := 32 - int(hll.indexBits) + 1
+	values := make([]int, 16)
+	for i := range values {
+		values[i] = i * maxValue / 15
+		hll.bucketGroup[i] = uint8(values[i])
+	}
+
+	lines := strings.Split(strings.TrimSuffix(hll.Heatmap(5), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines for 16 buckets 5 wide, wanted 4", len(lines))
+	}
+
+	for i, line := range lines {
+		want := 5
+		if i == len(lines)-1 {
+			want = 1
+		}
+
+		if len(line) != want {
+			t.Fatalf("line %d is %d wide, wanted %d", i, len(line), want)
+		}
+	}
+
+	// Buckets were set in increasing order so every shade is at least as dense as the last
+	shades := strings.Join(lines, "")
+	if shades[0] != heatmapShades[0] || shades[15] != heatmapShades[len(heatmapShades)-1] {
+		t.Fatalf("got %q, wanted it to run from blank to the densest shade", shades)
+	}
+
+	for i := 1; i < len(shades); i++ {
+		if strings.IndexByte(heatmapShades, shades[i]) < strings.IndexByte(heatmapShades, shades[i-1]) {
+			t.Fatalf("bucket %d at %d is shaded lighter than bucket %d at %d", i, values[i], i-1, values[i-1])
+		}
+	}
+}
+
+func TestRegisterHistogram(t *testing.T) {
+	hll, err := NewHyperLogLog(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Eight empty buckets, five at 1, two at 3 and one at the longest run
+	maxValue := uint8(hll.runBits() + 1)
+	copy(hll.bucketGroup, []uint8{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 3, 3, maxValue})
+
+	want := map[int]int{0: 8, 1: 5, 3: 2, int(maxValue): 1}
+	if got := hll.RegisterHistogram(); !maps.Equal(got, want) {
+		t.Fatalf("got histogram %v, wanted %v", got, want)
+	}
+
+	if got := hll.NonZeroRegisters(); got != 8 {
+		t.Fatalf("got %d non zero buckets, wanted 8", got)
+	}
+
+	// Every bucket is counted once whatever the sketch has seen
+	filled := filledSketch(t, 10, 5000)
+	total := 0
+	for _, count := range filled.RegisterHistogram() {
+		total += count
+	}
+
+	if total != int(filled.mBuckets) {
+		t.Fatalf("histogram counts %d buckets, wanted %d", total, filled.mBuckets)
+	}
+}
@@ -0,0 +1,51 @@
+package pds
+
+import (
+	"sort"
+)
+
+// bucketLoads counts how many distinct hashes have landed in each bucket
+type bucketLoads struct {
+	seen   map[uint64]struct{}
+	counts []int64
+}
+
+// record counts the hash against its bucket the first time it is seen
+func (bl *bucketLoads) record(h uint64, index uint32) {
+	if _, ok := bl.seen[h]; ok {
+		return
+	}
+
+	bl.seen[h] = struct{}{}
+	bl.counts[index]++
+}
+
+// BucketLoad is how many distinct hashes a bucket has received
+type BucketLoad struct {
+	Index uint32
+	Load  int64
+}
+
+// HotBuckets returns the n buckets that have received the most distinct hashes, most loaded
+// first. A bucket getting far more than its share points at keys that cluster under the hash.
+// It needs load tracking turned on with WithBucketLoadTracking and returns nil otherwise
+func (hll *HyperLogLog) HotBuckets(n int) []BucketLoad {
+	if hll.loads == nil || n < 1 {
+		return nil
+	}
+
+	loads := make([]BucketLoad, len(hll.loads.counts))
+	for i, count := range hll.loads.counts {
+		loads[i] = BucketLoad{Index: uint32(i), Load: count}
+	}
+
+	sort.SliceStable(loads, func(i, j int) bool {
+		return loads[i].Load > loads[j].Load
+	})
+
+	if n > len(loads) {
+		n = len(loads)
+	}
+
+	return loads[:n]
+}
@@ -0,0 +1,75 @@
+package pds
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// clusteringHasher puts keys starting "hot-" into buckets 5, 6 and 7 of a sketch with 10
+// index bits, like a hash that clusters some key pattern, and hashes the rest with FNV-1a
+type clusteringHasher struct {
+	FNVHasher
+}
+
+func (h clusteringHasher) Hash32(data []byte, seed uint32) uint32 {
+	if id, ok := strings.CutPrefix(string(data), "hot-"); ok {
+		n, _ := strconv.ParseUint(id, 10, 32)
+		return h.FNVHasher.Hash32(data, seed)<<10 | uint32(5+n%3)
+	}
+
+	return h.FNVHasher.Hash32(data, seed)
+}
+
+func TestHotBucketsFindsClusteredKeys(t *testing.T) {
+	hll, err := NewHyperLogLog(10, WithHasher(clusteringHasher{}), WithBucketLoadTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		hll.Add(fmt.Sprintf("cold-%d", i))
+	}
+
+	// Repeats count once as loads are of distinct hashes
+	for repeat := 0; repeat < 2; repeat++ {
+		for i := 0; i < 300; i++ {
+			hll.Add(fmt.Sprintf("hot-%d", i))
+		}
+	}
+
+	hot := hll.HotBuckets(3)
+	if len(hot) != 3 {
+		t.Fatalf("got %d buckets, wanted 3", len(hot))
+	}
+
+	indices := []uint32{hot[0].Index, hot[1].Index, hot[2].Index}
+	slices.Sort(indices)
+	if !slices.Equal(indices, []uint32{5, 6, 7}) {
+		t.Fatalf("got hot buckets %v, wanted 5, 6 and 7", hot)
+	}
+
+	for i, bucket := range hot {
+		if bucket.Load < 100 || bucket.Load > 130 {
+			t.Errorf("bucket %d got load %d, wanted 100 hot keys plus about 10 others", bucket.Index, bucket.Load)
+		}
+
+		if i > 0 && bucket.Load > hot[i-1].Load {
+			t.Errorf("buckets aren't ordered by load: %v", hot)
+		}
+	}
+
+	if all := hll.HotBuckets(5000); len(all) != 1024 {
+		t.Errorf("asking for more buckets than there are got %d, wanted 1024", len(all))
+	}
+}
+
+func TestHotBucketsNeedsTracking(t *testing.T) {
+	hll := filledSketch(t, 10, 1000)
+
+	if hot := hll.HotBuckets(3); hot != nil {
+		t.Fatalf("got %v without load tracking, wanted nil", hot)
+	}
+}
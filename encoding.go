@@ -0,0 +1,268 @@
+package pds
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Versions of the MarshalBinary format, binaryVersion being the one written unless
+// WithBinaryVersion pins another
+const (
+	// binaryVersionDense is the index bits followed by one byte per bucket, always with 32 bit
+	// unseeded hashes
+	binaryVersionDense = 1
+
+	// binaryVersionFlags adds a flags byte after the index bits and the seed of seeded sketches
+	binaryVersionFlags = 2
+
+	binaryVersion = binaryVersionFlags
+)
+
+// Bits of the flags byte that MarshalBinary, MarshalJSON and MarshalChunks write after the
+// index bits
+const (
+	flagWideHash = 1 << iota
+	flagSeeded
+
+	knownFlags = flagWideHash | flagSeeded
+)
+
+// ErrUnsupportedVersion is returned when decoding or pinning a format version this package
+// doesn't know
+var ErrUnsupportedVersion = errors.New("unsupported serialization version")
+
+// flags returns the flags byte describing how the sketch hashes
+func (hll *HyperLogLog) flags() byte {
+	var flags byte
+	if hll.wideHash {
+		flags |= flagWideHash
+	}
+
+	if hll.seed != 0 {
+		flags |= flagSeeded
+	}
+
+	return flags
+}
+
+// flagOptions returns the options giving a sketch the flags and seed, rejecting flags it
+// doesn't know
+func flagOptions(flags byte, seed uint64) ([]Option, error) {
+	if flags&^knownFlags != 0 {
+		return nil, fmt.Errorf("unknown flags %#x", flags)
+	}
+
+	var options []Option
+	if flags&flagWideHash != 0 {
+		options = append(options, With64BitHash())
+	}
+
+	if flags&flagSeeded != 0 {
+		options = append(options, WithSeed(seed))
+	}
+
+	return options, nil
+}
+
+// appendHeader appends the index bits and flags that MarshalBinary and MarshalChunks start
+// with, followed by the seed as a uint64 for seeded sketches
+func (hll *HyperLogLog) appendHeader(data []byte) []byte {
+	data = append(data, byte(hll.indexBits), hll.flags())
+	if hll.seed != 0 {
+		data = binary.BigEndian.AppendUint64(data, hll.seed)
+	}
+
+	return data
+}
+
+// readHeader reads a header written by appendHeader, returning the index bits, the options to
+// decode the buckets with and the data after the header
+func readHeader(data []byte) (uint32, []Option, []byte, error) {
+	if len(data) < 2 {
+		return 0, nil, nil, fmt.Errorf("header is too short")
+	}
+
+	indexBits, flags, rest := uint32(data[0]), data[1], data[2:]
+
+	var seed uint64
+	if flags&flagSeeded != 0 {
+		if len(rest) < 8 {
+			return 0, nil, nil, fmt.Errorf("header is too short for the seed")
+		}
+
+		seed, rest = binary.BigEndian.Uint64(rest), rest[8:]
+	}
+
+	options, err := flagOptions(flags, seed)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	return indexBits, options, rest, nil
+}
+
+// decodeBuckets builds a HyperLogLog from one byte per bucket, each no larger than the run of
+// zeros plus one that the hash width allows
+func decodeBuckets(indexBits uint32, values []byte, options ...Option) (HyperLogLog, error) {
+	decoded, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		return HyperLogLog{}, err
+	}
+
+	if int64(len(values)) != decoded.mBuckets {
+		return HyperLogLog{}, fmt.Errorf("got %d buckets but %d index bits needs %d", len(values), indexBits, decoded.mBuckets)
+	}
+
+	for i, value := range values {
+		if uint32(value) > decoded.runBits()+1 {
+			return HyperLogLog{}, fmt.Errorf("bucket value %d out of range", value)
+		}
+
+		decoded.bucketGroup[i] = value
+	}
+
+	return decoded, nil
+}
+
+// load replaces the buckets with those of a freshly decoded sketch. A zero HyperLogLog, as
+// declared to decode into, becomes the decoded sketch. A configured one keeps its options,
+// hasher and background estimate and only has its buckets replaced, so it needs the same index
+// bits, hash width and seed as the decoded sketch
+func (hll *HyperLogLog) load(decoded *HyperLogLog) error {
+	if hll.mBuckets == 0 {
+		*hll = *decoded
+		return nil
+	}
+
+	if err := hll.compatible(decoded); err != nil {
+		return fmt.Errorf("cannot decode into a configured sketch: %w", err)
+	}
+
+	hll.lockAsync()
+	defer hll.unlockAsync()
+
+	// The decoded buckets don't say which hashes set them, so exact counting stops
+	hll.reset()
+	hll.exact = nil
+
+	for i, value := range decoded.registers() {
+		if value != 0 {
+			hll.raiseRegister(uint32(i), int(value))
+		}
+	}
+
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, writing a version byte, the index bits,
+// a flags byte for the hash width and seeding and the seed if there is one, followed by one
+// byte per bucket. WithBinaryVersion can pin an older version
+func (hll *HyperLogLog) MarshalBinary() ([]byte, error) {
+	version := hll.pinnedVersion
+	if version == 0 {
+		version = binaryVersion
+	}
+
+	buckets := hll.registers()
+	data := make([]byte, 1, 11+len(buckets))
+	data[0] = version
+
+	switch version {
+	case binaryVersionDense:
+		if hll.flags() != 0 {
+			return nil, fmt.Errorf("binary version %d can't hold 64 bit or seeded hashes", version)
+		}
+
+		data = append(data, byte(hll.indexBits))
+	case binaryVersionFlags:
+		data = hll.appendHeader(data)
+	default:
+		return nil, fmt.Errorf("%w %d", ErrUnsupportedVersion, version)
+	}
+
+	return append(data, buckets...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, loading MarshalBinary output of any
+// version. A zero HyperLogLog takes on the encoded index bits, hash width and seed, while a
+// configured one keeps its options and needs them to match
+func (hll *HyperLogLog) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return fmt.Errorf("binary data is too short")
+	}
+
+	var indexBits uint32
+	var options []Option
+	var values []byte
+	switch data[0] {
+	case binaryVersionDense:
+		indexBits, values = uint32(data[1]), data[2:]
+	case binaryVersionFlags:
+		var err error
+		if indexBits, options, values, err = readHeader(data[1:]); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w %d", ErrUnsupportedVersion, data[0])
+	}
+
+	decoded, err := decodeBuckets(indexBits, values, options...)
+	if err != nil {
+		return err
+	}
+
+	return hll.load(&decoded)
+}
+
+// jsonHyperLogLog is the JSON form of a HyperLogLog, flags are those of MarshalBinary and
+// registers holds one byte per bucket and ends up base64 encoded
+type jsonHyperLogLog struct {
+	IndexBits uint32 `json:"indexBits"`
+	Flags     byte   `json:"flags"`
+	Seed      uint64 `json:"seed,omitempty"`
+	Registers []byte `json:"registers"`
+}
+
+// MarshalJSON implements json.Marshaler. It has a value receiver so HyperLogLogs stored by
+// value in other structs still encode
+func (hll HyperLogLog) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonHyperLogLog{
+		IndexBits: hll.indexBits,
+		Flags:     hll.flags(),
+		Seed:      hll.seed,
+		Registers: hll.registers(),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, loading MarshalJSON output like UnmarshalBinary
+func (hll *HyperLogLog) UnmarshalJSON(data []byte) error {
+	var encoded jsonHyperLogLog
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+
+	options, err := flagOptions(encoded.Flags, encoded.Seed)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := decodeBuckets(encoded.IndexBits, encoded.Registers, options...)
+	if err != nil {
+		return err
+	}
+
+	return hll.load(&decoded)
+}
+
+// GobEncode implements gob.GobEncoder with the MarshalBinary format, as gob can't see the
+// unexported fields. Like MarshalJSON it has a value receiver
+func (hll HyperLogLog) GobEncode() ([]byte, error) {
+	return hll.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder, loading GobEncode output like UnmarshalBinary
+func (hll *HyperLogLog) GobDecode(data []byte) error {
+	return hll.UnmarshalBinary(data)
+}
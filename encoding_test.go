@@ -0,0 +1,307 @@
+package pds
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// assertSameSketch fails unless both sketches have the same layout, hashing and buckets
+func assertSameSketch(t *testing.T, got, want *HyperLogLog) {
+	t.Helper()
+
+	if got.indexBits != want.indexBits || got.wideHash != want.wideHash {
+		t.Fatalf("got %d index bits and %d bit hashes, wanted %d and %d", got.indexBits, got.hashBits(), want.indexBits, want.hashBits())
+	}
+
+	if got.seed != want.seed || got.hashSeed != want.hashSeed {
+		t.Fatalf("got seed %d, wanted %d", got.seed, want.seed)
+	}
+
+	// Both need to hash new items into the same buckets too
+	got.Add("one more item")
+	want.Add("one more item")
+
+	if !slices.Equal(got.registers(), want.registers()) {
+		t.Fatalf("buckets differ after decoding")
+	}
+
+	if got.EstimateCardinality() != want.EstimateCardinality() {
+		t.Fatalf("got estimate %d, wanted %d", got.EstimateCardinality(), want.EstimateCardinality())
+	}
+}
+
+// encodingTests are the configurations every encoding needs to round trip
+var encodingTests = []struct {
+	name    string
+	options []Option
+}{
+	{name: "32 bit"},
+	{name: "64 bit", options: []Option{With64BitHash()}},
+	{name: "sparse", options: []Option{WithSparseRepresentation()}},
+	{name: "seeded", options: []Option{WithSeed(42)}},
+	{name: "seeded 64 bit", options: []Option{WithSeed(1 << 63), With64BitHash()}},
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	for _, test := range encodingTests {
+		t.Run(test.name, func(t *testing.T) {
+			hll := filledSketch(t, 10, 5000, test.options...)
+
+			data, err := hll.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded HyperLogLog
+			if err := decoded.UnmarshalBinary(data); err != nil {
+				t.Fatal(err)
+			}
+
+			assertSameSketch(t, &decoded, &hll)
+		})
+	}
+}
+
+func TestBinaryKeepsLongRuns(t *testing.T) {
+	hll, err := NewHyperLogLog(8, With64BitHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A run longer than 32 bit hashes allow only fits a 64 bit sketch
+	if err := hll.MergeRegisterMaxima([]uint32{0}, []uint8{40}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := hll.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded HyperLogLog
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	assertSameSketch(t, &decoded, &hll)
+}
+
+func TestUnmarshalBinaryRejectsBadData(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty"},
+		{name: "unknown version", data: []byte{99, 4, 0}},
+		{name: "no flags", data: []byte{binaryVersion, 4}},
+		{name: "unknown flags", data: append([]byte{binaryVersion, 4, 0x80}, make([]byte, 16)...)},
+		{name: "too few buckets", data: append([]byte{binaryVersion, 4, 0}, make([]byte, 15)...)},
+		{name: "value above 32 bit run", data: append([]byte{binaryVersion, 4, 0, 30}, make([]byte, 15)...)},
+		{name: "value above 64 bit run", data: append([]byte{binaryVersion, 4, flagWideHash, 62}, make([]byte, 15)...)},
+		{name: "seed cut short", data: []byte{binaryVersion, 4, flagSeeded, 0, 0, 0}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var hll HyperLogLog
+			if err := hll.UnmarshalBinary(test.data); err == nil {
+				t.Fatalf("wanted an error decoding %v", test.data)
+			}
+		})
+	}
+
+	var hll HyperLogLog
+	if err := hll.UnmarshalBinary([]byte{99, 4}); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("got %v, wanted ErrUnsupportedVersion", err)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, test := range encodingTests {
+		t.Run(test.name, func(t *testing.T) {
+			hll := filledSketch(t, 10, 5000, test.options...)
+
+			data, err := json.Marshal(hll)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded HyperLogLog
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+
+			assertSameSketch(t, &decoded, &hll)
+		})
+	}
+}
+
+func TestJSONWithoutFlags(t *testing.T) {
+	var decoded HyperLogLog
+	if err := json.Unmarshal([]byte(`{"indexBits":4,"registers":"AQIDBAUGBwgJCgsMDQ4PEA=="}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.wideHash || decoded.register(15) != 16 {
+		t.Fatalf("got %d bit hashes and bucket 15 at %d", decoded.hashBits(), decoded.register(15))
+	}
+}
+
+func TestGobRoundTrip(t *testing.T) {
+	for _, test := range encodingTests {
+		t.Run(test.name, func(t *testing.T) {
+			hll := filledSketch(t, 10, 5000, test.options...)
+
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(hll); err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded HyperLogLog
+			if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+				t.Fatal(err)
+			}
+
+			assertSameSketch(t, &decoded, &hll)
+		})
+	}
+}
+
+func TestUnmarshalBinaryVersions(t *testing.T) {
+	registers := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+	for _, test := range []struct {
+		name     string
+		data     []byte
+		wideHash bool
+		seed     uint64
+	}{
+		{name: "version 1", data: append([]byte{1, 4}, registers...)},
+		{name: "version 2", data: append([]byte{2, 4, 0}, registers...)},
+		{name: "version 2 with 64 bit hashes", data: append([]byte{2, 4, flagWideHash}, registers...), wideHash: true},
+		{name: "version 2 with a seed", data: append([]byte{2, 4, flagSeeded, 0, 0, 0, 0, 0, 0, 1, 0}, registers...), seed: 256},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var hll HyperLogLog
+			if err := hll.UnmarshalBinary(test.data); err != nil {
+				t.Fatal(err)
+			}
+
+			if hll.indexBits != 4 || hll.wideHash != test.wideHash || hll.seed != test.seed {
+				t.Fatalf("got %d index bits, %d bit hashes and seed %d", hll.indexBits, hll.hashBits(), hll.seed)
+			}
+
+			if !slices.Equal(hll.registers(), registers) {
+				t.Fatalf("got buckets %v, wanted %v", hll.registers(), registers)
+			}
+		})
+	}
+}
+
+func TestWithBinaryVersion(t *testing.T) {
+	hll := filledSketch(t, 8, 1000, WithBinaryVersion(binaryVersionDense))
+
+	data, err := hll.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data[0] != binaryVersionDense || len(data) != 2+256 {
+		t.Fatalf("got version %d and %d bytes, wanted version 1 and 258 bytes", data[0], len(data))
+	}
+
+	var decoded HyperLogLog
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	assertSameSketch(t, &decoded, &hll)
+
+	for _, options := range [][]Option{
+		{WithBinaryVersion(binaryVersionDense), With64BitHash()},
+		{WithBinaryVersion(binaryVersionDense), WithSeed(1)},
+	} {
+		hll := filledSketch(t, 8, 1000, options...)
+		if _, err := hll.MarshalBinary(); err == nil {
+			t.Errorf("version 1 marshalled a sketch it can't describe")
+		}
+	}
+
+	unknown := filledSketch(t, 8, 1000, WithBinaryVersion(99))
+	if _, err := unknown.MarshalBinary(); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("got %v, wanted ErrUnsupportedVersion", err)
+	}
+}
+
+func TestDecodingKeepsConfiguration(t *testing.T) {
+	source := filledSketch(t, 10, 5000)
+	data, err := source.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hll, err := NewHyperLogLog(10, WithKeyNormalizer(strings.ToLower), WithExactThreshold(100), WithAsyncEstimate(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hll.Close()
+
+	hll.Add("left over")
+	if err := hll.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(hll.registers(), source.registers()) {
+		t.Fatalf("buckets differ after decoding")
+	}
+
+	if _, exact := hll.ToExactSet(); exact {
+		t.Fatalf("still counting exactly after decoding buckets")
+	}
+
+	// The normalizer is still in place and the background estimate catches up with the
+	// decoded buckets
+	before := hll.EstimateCardinality()
+	hll.Add("ITEM-0")
+	if hll.EstimateCardinality() != before {
+		t.Fatalf("normalizer was dropped when decoding")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for hll.Count() != hll.EstimateCardinality() {
+		if time.Now().After(deadline) {
+			t.Fatalf("background estimate %d never caught up with %d", hll.Count(), hll.EstimateCardinality())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDecodingIntoMismatchedSketch(t *testing.T) {
+	source := filledSketch(t, 10, 100)
+	data, err := source.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, options := range [][]Option{{With64BitHash()}, {WithSeed(7)}} {
+		hll := filledSketch(t, 10, 10, options...)
+		before := slices.Clone(hll.registers())
+
+		if err := hll.UnmarshalBinary(data); err == nil {
+			t.Errorf("decoded a 32 bit unseeded sketch into a differently hashed one")
+		}
+
+		if !slices.Equal(hll.registers(), before) {
+			t.Errorf("buckets changed after a failed decode")
+		}
+	}
+
+	other := filledSketch(t, 12, 10)
+	if err := other.UnmarshalBinary(data); err == nil {
+		t.Errorf("decoded 10 index bits into a 12 index bit sketch")
+	}
+}
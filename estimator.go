@@ -0,0 +1,218 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+)
+
+// Estimator picks the algorithm used to turn the buckets into an estimate
+type Estimator int
+
+const (
+	// HarmonicMean is the original HyperLogLog estimator, with any corrections the sketch
+	// was configured with
+	HarmonicMean Estimator = iota
+
+	// MaximumLikelihood is Ertl's maximum likelihood estimator, more accurate across the
+	// whole range for a few extra passes over a histogram of the buckets
+	MaximumLikelihood
+
+	// LogLogBeta corrects the harmonic mean with a polynomial in the empty buckets, avoiding
+	// the jumps of switching between corrections
+	LogLogBeta
+)
+
+// histogram counts how many buckets hold each value, values above maxValue are counted as maxValue
+func (bg bucketGroup) histogram(maxValue int) []int {
+	counts := make([]int, maxValue+1)
+	for _, bucket := range bg {
+		value := int(bucket)
+		if value > maxValue {
+			value = maxValue
+		}
+
+		counts[value]++
+	}
+
+	return counts
+}
+
+// histogram counts how many of the sketch's buckets hold each value up to the most a run of
+// its hash bits can give
+func (hll *HyperLogLog) histogram() []int {
+	return hll.registers().histogram(int(hll.runBits()) + 1)
+}
+
+// mlRelativeError is how close to the root, relative to 1/sqrt(m), the maximum likelihood
+// estimate is refined before stopping
+const mlRelativeError = 0.01
+
+// maximumLikelihoodEstimate estimates the cardinality by finding the items per bucket that
+// makes the bucket histogram most likely under a poisson model, where q is the number of bits
+// used to count zeros. It follows Ertl's "New cardinality estimation algorithms for HyperLogLog
+// sketches", solving x a + sum(c_k h(x / 2^k)) = m - c_0 with h(x) = 1 - x / (e^x - 1) by a
+// secant method that converges from below in a handful of passes over the histogram
+func maximumLikelihoodEstimate(counts []int, totalBuckets float64, q uint32) float64 {
+	m := int(totalBuckets)
+
+	switch {
+	case counts[0] == m:
+		return 0
+	case counts[q+1] == m:
+		return totalBuckets * math.Pow(2, float64(q+1))
+	}
+
+	kMin, kMax := 0, int(q)+1
+	for counts[kMin] == 0 {
+		kMin++
+	}
+	for counts[kMax] == 0 {
+		kMax--
+	}
+	kMin, kMax = max(kMin, 1), min(kMax, int(q))
+
+	var z float64
+	for k := kMax; k >= kMin; k-- {
+		z = z/2 + float64(counts[k])
+	}
+	z = math.Ldexp(z, -kMin)
+
+	// The top value shares its term with the largest value below it as both see x / 2^q
+	cTop := float64(counts[q+1] + counts[kMax])
+	a := z + float64(counts[0])
+	b := z + math.Ldexp(float64(counts[q+1]), -int(q))
+	mPrime := float64(m - counts[0])
+
+	var x float64
+	if b <= 1.5*a {
+		x = mPrime / (b/2 + a)
+	} else {
+		x = mPrime / b * math.Log1p(b/a)
+	}
+
+	deltaX := x
+	var gPrevious float64
+	for deltaX > x*mlRelativeError/math.Sqrt(totalBuckets) {
+		// Start from a power of two small enough for the series of h to be accurate, then
+		// double up to each value in the histogram
+		_, exponent := math.Frexp(x)
+		top := max(kMax, exponent+1)
+
+		xPrime := math.Ldexp(x, -top-1)
+		xPrime2 := xPrime * xPrime
+		h := xPrime - xPrime2/3 + xPrime2*xPrime2*(1.0/45-xPrime2/472.5)
+		for k := top; k > kMax; k-- {
+			h = (xPrime + h*(1-h)) / (xPrime + (1 - h))
+			xPrime *= 2
+		}
+
+		g := cTop * h
+		for k := kMax - 1; k >= kMin; k-- {
+			h = (xPrime + h*(1-h)) / (xPrime + (1 - h))
+			xPrime *= 2
+			g += float64(counts[k]) * h
+		}
+		g += x * a
+
+		if g > gPrevious && mPrime >= g {
+			deltaX *= (mPrime - g) / (g - gPrevious)
+		} else {
+			deltaX = 0
+		}
+
+		x += deltaX
+		gPrevious = g
+	}
+
+	return totalBuckets * x
+}
+
+// RelativeError returns the relative standard error of estimates for the sketch's precision,
+// 1.04/sqrt(m), eg. 0.0081 for 14 index bits
+func (hll *HyperLogLog) RelativeError() float64 {
+	return 1.04 / math.Sqrt(float64(hll.mBuckets))
+}
+
+// EstimateWithBounds returns the estimate along with the interval around it that should hold
+// the true cardinality with the given confidence, eg. 0.95, treating the error as normal with
+// the sketch's CurrentError as its standard deviation. While counting exactly the bounds are
+// the estimate itself. It panics unless the confidence is between 0 and 1
+func (hll *HyperLogLog) EstimateWithBounds(confidence float64) (low int64, estimate int64, high int64) {
+	if confidence <= 0 || confidence >= 1 {
+		panic(fmt.Sprintf("pds: confidence %v needs to be between 0 and 1", confidence))
+	}
+
+	estimate = hll.EstimateCardinality()
+	if hll.exact != nil {
+		return estimate, estimate, estimate
+	}
+
+	margin := math.Sqrt2 * math.Erfinv(confidence) * hll.CurrentError() * float64(estimate)
+	low = max(int64(math.Floor(float64(estimate)-margin)), 0)
+	high = int64(math.Ceil(float64(estimate) + margin))
+
+	return low, estimate, high
+}
+
+// logLogBetaEstimate turns the harmonic sum over some buckets into an estimate with
+// LogLog-Beta, which adds a polynomial in the number of empty buckets to the sum instead of
+// switching to linear counting, so the estimate moves smoothly across the whole range
+func logLogBetaEstimate(indexBits uint32, constant float64, totalBuckets float64, total float64, zeros float64) float64 {
+	if zeros == totalBuckets {
+		return 0
+	}
+
+	coefficients := betaCoefficients[indexBits]
+	beta := coefficients[0] * zeros
+
+	zl := math.Log(zeros + 1)
+	power := 1.0
+	for _, coefficient := range coefficients[1:] {
+		power *= zl
+		beta += coefficient * power
+	}
+
+	return constant * totalBuckets * (totalBuckets - zeros) / (beta + total)
+}
+
+// CombineEstimates blends the estimates of sketches that all counted the same set, weighting
+// each by the inverse of its variance. As RelativeError is 1.04/sqrt(m), each weight comes out
+// proportional to the sketch's number of buckets m
+func CombineEstimates(sketches []*HyperLogLog) (int64, error) {
+	if len(sketches) == 0 {
+		return 0, fmt.Errorf("need at least one sketch")
+	}
+
+	var weighted, weights float64
+	for _, sketch := range sketches {
+		relativeError := sketch.RelativeError()
+		weight := 1 / (relativeError * relativeError)
+		weighted += weight * float64(sketch.estimate())
+		weights += weight
+	}
+
+	return int64(math.Round(weighted / weights)), nil
+}
+
+// RegisterEntropy returns the Shannon entropy in bits of how the bucket values are spread.
+// A busy sketch with low entropy is saturating, an almost empty one with low entropy points
+// at a hash that isn't spreading its input
+func (hll *HyperLogLog) RegisterEntropy() float64 {
+	var entropy float64
+	for _, count := range hll.registers().histogram(int(hll.runBits()) + 1) {
+		if count == 0 {
+			continue
+		}
+
+		p := float64(count) / float64(hll.mBuckets)
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
+
+// CardinalityAtAbsoluteError returns the cardinality at which the expected error reaches
+// absErr items, solving RelativeError() * n = absErr for n
+func (hll *HyperLogLog) CardinalityAtAbsoluteError(absErr int64) int64 {
+	return int64(float64(absErr) / hll.RelativeError())
+}
@@ -0,0 +1,225 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestMaximumLikelihoodAtLeastAsAccurate(t *testing.T) {
+	// Summed over seeds and a sweep through the small, transition and large ranges, so one
+	// lucky sketch can't decide it
+	var harmonicError, mlError float64
+	for seed := uint64(0); seed < 32; seed++ {
+		// Random keys so each seed gives a different sketch
+		rng := rand.New(rand.NewPCG(seed, 0))
+		hll, err := NewHyperLogLog(10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		added := 0
+		for _, n := range []int{100, 500, 1000, 2000, 3000, 5000, 10000, 50000, 200000} {
+			for ; added < n; added++ {
+				hll.Add(fmt.Sprintf("%x", rng.Uint64()))
+			}
+
+			harmonicError += math.Abs(float64(hll.EstimateWith(HarmonicMean))/float64(n) - 1)
+			mlError += math.Abs(float64(hll.EstimateWith(MaximumLikelihood))/float64(n) - 1)
+		}
+	}
+
+	if mlError > harmonicError {
+		t.Fatalf("maximum likelihood was off by %.3f in total against %.3f for the harmonic mean", mlError, harmonicError)
+	}
+}
+
+func TestLogLogBetaAtLeastAsAccurate(t *testing.T) {
+	// Summed like TestMaximumLikelihoodAtLeastAsAccurate, through the switch away from linear
+	// counting where the harmonic mean jumps
+	var harmonicError, betaError float64
+	for seed := uint64(0); seed < 16; seed++ {
+		harmonic := filledSketch(t, 10, 0)
+		beta := filledSketch(t, 10, 0, WithLogLogBeta())
+
+		// Random keys so each seed gives a different sketch
+		rng := rand.New(rand.NewPCG(seed, 0))
+		added := 0
+		for _, n := range []int{100, 1000, 2000, 2600, 3000, 4000, 10000, 50000} {
+			for ; added < n; added++ {
+				key := fmt.Sprintf("%x", rng.Uint64())
+				harmonic.Add(key)
+				beta.Add(key)
+			}
+
+			harmonicError += math.Abs(float64(harmonic.EstimateCardinality())/float64(n) - 1)
+			betaError += math.Abs(float64(beta.EstimateCardinality())/float64(n) - 1)
+		}
+	}
+
+	if betaError > harmonicError {
+		t.Fatalf("loglog beta was off by %.3f in total against %.3f for the harmonic mean", betaError, harmonicError)
+	}
+}
+
+func TestWithMLEstimator(t *testing.T) {
+	hll := filledSketch(t, 12, 20000, WithMLEstimator())
+
+	if hll.EstimateCardinality() != hll.EstimateWith(MaximumLikelihood) {
+		t.Fatalf("got %d, wanted the maximum likelihood estimate %d", hll.EstimateCardinality(), hll.EstimateWith(MaximumLikelihood))
+	}
+}
+
+func TestCombineEstimates(t *testing.T) {
+	low := filledSketch(t, 8, 100000)
+	high := filledSketch(t, 14, 100000)
+
+	combined, err := CombineEstimates([]*HyperLogLog{&low, &high})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Weights go with the number of buckets, so the high precision sketch counts 64 times as much
+	lowEstimate, highEstimate := float64(low.EstimateCardinality()), float64(high.EstimateCardinality())
+	want := int64(math.Round((lowEstimate + 64*highEstimate) / 65))
+	if combined != want {
+		t.Fatalf("got %d combining %.0f and %.0f, wanted %d", combined, lowEstimate, highEstimate, want)
+	}
+
+	if math.Abs(float64(combined)-highEstimate) > math.Abs(lowEstimate-highEstimate)/64+1 {
+		t.Fatalf("combined estimate %d strayed from the high precision %.0f towards %.0f", combined, highEstimate, lowEstimate)
+	}
+
+	alone, err := CombineEstimates([]*HyperLogLog{&low})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if alone != low.EstimateCardinality() {
+		t.Fatalf("got %d combining one sketch, wanted its own estimate %d", alone, low.EstimateCardinality())
+	}
+
+	if _, err := CombineEstimates(nil); err == nil {
+		t.Fatalf("wanted an error combining no sketches")
+	}
+}
+
+// lengthHasher is a broken hash that ignores everything about its input but the length
+type lengthHasher struct {
+	FNVHasher
+}
+
+func (lengthHasher) Hash32(data []byte, seed uint32) uint32 {
+	return uint32(len(data))
+}
+
+func TestRegisterEntropy(t *testing.T) {
+	healthy := filledSketch(t, 12, 20000)
+
+	// A hash that ignores most of its input puts nearly everything in a handful of buckets
+	broken := filledSketch(t, 12, 20000, WithHasher(lengthHasher{}))
+
+	empty, err := NewHyperLogLog(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// About 5 items a bucket spreads the values over several runs, close to 3 bits
+	if entropy := healthy.RegisterEntropy(); entropy < 2 || entropy > 3.5 {
+		t.Errorf("healthy sketch got entropy %f, wanted close to 3", entropy)
+	}
+
+	if entropy := broken.RegisterEntropy(); entropy > 0.1 {
+		t.Errorf("sketch with a broken hash got entropy %f, wanted close to 0", entropy)
+	}
+
+	if entropy := empty.RegisterEntropy(); entropy != 0 {
+		t.Errorf("empty sketch got entropy %f, wanted 0", entropy)
+	}
+}
+
+func TestCardinalityAtAbsoluteError(t *testing.T) {
+	for _, test := range []struct {
+		indexBits uint32
+		absErr    int64
+		want      int64
+	}{
+		// 1.04/sqrt(2^14) is 0.008125, so 1000 items off comes at about 123077 items
+		{indexBits: 14, absErr: 1000, want: 123076},
+		// 1.04/sqrt(2^10) is 0.0325
+		{indexBits: 10, absErr: 100, want: 3076},
+		{indexBits: 10, absErr: 0, want: 0},
+	} {
+		hll, err := NewHyperLogLog(test.indexBits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		n := hll.CardinalityAtAbsoluteError(test.absErr)
+		if n != test.want {
+			t.Errorf("%d index bits and %d absolute error got %d, wanted %d", test.indexBits, test.absErr, n, test.want)
+		}
+
+		// Going back through the relative error gives the absolute error again
+		if math.Abs(hll.RelativeError()*float64(n)-float64(test.absErr)) > 1 {
+			t.Errorf("%d items at relative error %f is off by %f, wanted %d", n, hll.RelativeError(), hll.RelativeError()*float64(n), test.absErr)
+		}
+	}
+}
+
+func TestEstimateWithBoundsCoverage(t *testing.T) {
+	// Over seeded sketches of the same cardinality the intervals should hold it about as often
+	// as the confidence asked for
+	const trials = 200
+	const n = 20000
+	confidences := []float64{0.5, 0.9}
+	covered := make([]int, len(confidences))
+	for seed := uint64(0); seed < trials; seed++ {
+		rng := rand.New(rand.NewPCG(seed, 0))
+		hll := filledSketch(t, 10, 0)
+		for i := 0; i < n; i++ {
+			hll.Add(fmt.Sprintf("%x", rng.Uint64()))
+		}
+
+		for i, confidence := range confidences {
+			low, estimate, high := hll.EstimateWithBounds(confidence)
+			if estimate != hll.EstimateCardinality() || low > estimate || high < estimate {
+				t.Fatalf("got bounds %d and %d around %d, wanted them either side of the estimate %d", low, high, estimate, hll.EstimateCardinality())
+			}
+
+			if low <= n && n <= high {
+				covered[i]++
+			}
+		}
+	}
+
+	for i, confidence := range confidences {
+		// A few standard deviations of the binomial count of covering trials
+		coverage := float64(covered[i]) / trials
+		if tolerance := 4 * math.Sqrt(confidence*(1-confidence)/trials); math.Abs(coverage-confidence) > tolerance {
+			t.Errorf("got %.3f of intervals at confidence %v holding the cardinality", coverage, confidence)
+		}
+	}
+}
+
+func TestEstimateWithBoundsExact(t *testing.T) {
+	hll := filledSketch(t, 10, 10, WithExactThreshold(100))
+	if low, estimate, high := hll.EstimateWithBounds(0.95); low != 10 || estimate != 10 || high != 10 {
+		t.Fatalf("got bounds %d, %d and %d while counting exactly, wanted all 10", low, estimate, high)
+	}
+}
+
+func TestEstimateWithBoundsPanicsOutOfRange(t *testing.T) {
+	hll := filledSketch(t, 10, 10)
+	for _, confidence := range []float64{0, 1, -0.5, 2} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("wanted a panic for a confidence of %v", confidence)
+				}
+			}()
+			hll.EstimateWithBounds(confidence)
+		}()
+	}
+}
@@ -0,0 +1,18 @@
+package pds
+
+// ToExactSet returns a copy of every hash added so far while the sketch is still under the
+// threshold set by WithExactThreshold, and false once it has grown past it or was never
+// tracking hashes. Merging other buckets in also stops the exact tracking, and 64 bit
+// hashes are kept as their low 32 bits
+func (hll *HyperLogLog) ToExactSet() (map[uint32]struct{}, bool) {
+	if hll.exact == nil {
+		return nil, false
+	}
+
+	set := make(map[uint32]struct{}, len(hll.exact))
+	for h := range hll.exact {
+		set[h] = struct{}{}
+	}
+
+	return set, true
+}
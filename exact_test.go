@@ -0,0 +1,45 @@
+package pds
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestToExactSet(t *testing.T) {
+	hll, err := NewHyperLogLog(12, WithExactThreshold(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		hll.Add(fmt.Sprintf("item-%d", i))
+		hll.Add(fmt.Sprintf("item-%d", i))
+	}
+
+	set, exact := hll.ToExactSet()
+	if !exact || len(set) != 100 {
+		t.Fatalf("got %d hashes and exact %t at the threshold, wanted 100 and true", len(set), exact)
+	}
+
+	for i := 0; i < 100; i++ {
+		if _, ok := set[uint32(hll.hash(fmt.Sprintf("item-%d", i)))]; !ok {
+			t.Fatalf("item-%d is missing from the exact set", i)
+		}
+	}
+
+	// The set is a copy, changing it leaves the sketch alone
+	clear(set)
+	if again, _ := hll.ToExactSet(); len(again) != 100 {
+		t.Fatalf("clearing the returned set left %d hashes in the sketch", len(again))
+	}
+
+	hll.Add("one too many")
+	if set, exact := hll.ToExactSet(); exact || set != nil {
+		t.Fatalf("got %d hashes and exact %t past the threshold, wanted nil and false", len(set), exact)
+	}
+
+	untracked := filledSketch(t, 12, 10)
+	if _, exact := untracked.ToExactSet(); exact {
+		t.Fatalf("got an exact set without WithExactThreshold")
+	}
+}
@@ -0,0 +1,47 @@
+package pds
+
+import (
+	"expvar"
+)
+
+// expvarStats is the JSON published by the Expvar vars
+type expvarStats struct {
+	Estimate  int64   `json:"estimate"`
+	FillRatio float64 `json:"fillRatio"`
+}
+
+// stats works out what the Expvar vars publish
+func (hll *HyperLogLog) stats() expvarStats {
+	return expvarStats{
+		Estimate:  hll.EstimateCardinality(),
+		FillRatio: float64(hll.NonZeroRegisters()) / float64(hll.mBuckets),
+	}
+}
+
+// Expvar returns an expvar.Var publishing the estimate and the fraction of buckets that have
+// been filled, eg. for expvar.Publish("visitors", hll.Expvar()). Both are worked out afresh
+// whenever the var is read, so the reads need keeping apart from adds like any other read of
+// a HyperLogLog, ConcurrentHyperLogLog.Expvar is safe to read alongside adds
+func (hll *HyperLogLog) Expvar() expvar.Var {
+	return expvar.Func(func() any {
+		return hll.stats()
+	})
+}
+
+// Expvar returns an expvar.Var publishing the estimate and the fraction of buckets that have
+// been filled, safe to read while adds are running
+func (c *ConcurrentHyperLogLog) Expvar() expvar.Var {
+	return expvar.Func(func() any {
+		snapshot := c.Snapshot()
+		return snapshot.stats()
+	})
+}
+
+// Expvar returns an expvar.Var publishing the estimate and the fraction of buckets that have
+// been filled, safe to read while adds are running
+func (s *ShardedHyperLogLog) Expvar() expvar.Var {
+	return expvar.Func(func() any {
+		snapshot := s.Snapshot()
+		return snapshot.stats()
+	})
+}
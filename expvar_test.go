@@ -0,0 +1,64 @@
+package pds
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+)
+
+// readExpvar decodes what an Expvar var publishes
+func readExpvar(t *testing.T, v expvar.Var) expvarStats {
+	t.Helper()
+
+	var stats expvarStats
+	if err := json.Unmarshal([]byte(v.String()), &stats); err != nil {
+		t.Fatal(err)
+	}
+
+	return stats
+}
+
+func TestExpvarMatchesEstimate(t *testing.T) {
+	hll := filledSketch(t, 10, 0)
+	v := hll.Expvar()
+
+	if stats := readExpvar(t, v); stats.Estimate != 0 || stats.FillRatio != 0 {
+		t.Fatalf("got %+v for an empty sketch", stats)
+	}
+
+	// The var is read afresh, following adds made after it was built
+	concurrent, err := NewConcurrentHyperLogLog(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sharded, err := NewShardedHyperLogLog(10, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5000; i++ {
+		item := fmt.Sprintf("item-%d", i)
+		hll.Add(item)
+		concurrent.Add(item)
+		sharded.Add(item)
+	}
+
+	stats := readExpvar(t, v)
+	if stats.Estimate != hll.EstimateCardinality() {
+		t.Fatalf("published estimate %d, wanted %d", stats.Estimate, hll.EstimateCardinality())
+	}
+
+	if want := float64(hll.NonZeroRegisters()) / 1024; stats.FillRatio != want {
+		t.Fatalf("published fill ratio %f, wanted %f", stats.FillRatio, want)
+	}
+
+	if got := readExpvar(t, concurrent.Expvar()); got != stats || got.Estimate != concurrent.EstimateCardinality() {
+		t.Fatalf("concurrent sketch published %+v, wanted %+v", got, stats)
+	}
+
+	if got := readExpvar(t, sharded.Expvar()); got != stats || got.Estimate != sharded.EstimateCardinality() {
+		t.Fatalf("sharded sketch published %+v, wanted %+v", got, stats)
+	}
+}
@@ -0,0 +1,54 @@
+package pds
+
+import (
+	"math"
+)
+
+// saturatedFraction is the share of buckets pinned at the top of their range that counts as
+// saturated. Past it the harmonic mean starts to drift while the tail fit holds steady
+const saturatedFraction = 0.02
+
+// tailFit estimates the cardinality of a saturated sketch from the histogram of its buckets,
+// ignoring the ones that have hit the top of their range. Under a poisson model the fraction of
+// buckets at or below k is exp(-lambda / 2^k), so it picks the k where that fraction gives the
+// least error and solves for lambda. ok is false while too few buckets are pinned at q+1, the
+// most a run of q bits can give, for the sketch to be saturated
+func tailFit(counts []int, totalBuckets float64, q uint32) (estimate float64, relativeError float64, ok bool) {
+	if float64(counts[q+1]) < saturatedFraction*totalBuckets {
+		return 0, 0, false
+	}
+
+	relativeError = math.Inf(1)
+	estimate = totalBuckets * math.Pow(2, float64(q))
+
+	var below int
+	for k := 0; k < int(q); k++ {
+		below += counts[k]
+
+		fraction := float64(below) / totalBuckets
+		if fraction == 0 || fraction == 1 {
+			continue
+		}
+
+		kError := math.Sqrt((1-fraction)/(totalBuckets*fraction)) / -math.Log(fraction)
+		if kError < relativeError {
+			relativeError = kError
+			estimate = totalBuckets * -math.Pow(2, float64(k)) * math.Log(fraction)
+		}
+	}
+
+	return estimate, relativeError, true
+}
+
+// CurrentError returns the relative standard error of the current estimate, RelativeError
+// normally. Once WithSaturationExtrapolation has kicked in it is the error of the tail fit
+// instead, which grows quickly the further past saturation the sketch gets
+func (hll *HyperLogLog) CurrentError() float64 {
+	if hll.extrapolate && hll.estimator == HarmonicMean {
+		if _, relativeError, ok := tailFit(hll.histogram(), float64(hll.mBuckets), hll.runBits()); ok {
+			return relativeError
+		}
+	}
+
+	return hll.RelativeError()
+}
@@ -0,0 +1,106 @@
+package pds
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// fillPoisson sets every bucket as if n items had been spread over the sketch, each bucket
+// seeing a poisson number of them, so sketches can be pushed far past what adding items
+// one by one allows
+func fillPoisson(hll *HyperLogLog, n float64, rng *rand.Rand) {
+	q := int(hll.runBits())
+	lambda := n / float64(hll.mBuckets)
+
+	for i := range hll.bucketGroup {
+		// The longest run is at most k with probability exp(-lambda / 2^k) below q+1
+		u := rng.Float64()
+		value := q + 1
+		for k := 0; k <= q; k++ {
+			if u <= math.Exp(-lambda*math.Pow(2, -float64(k))) {
+				value = k
+				break
+			}
+		}
+
+		hll.bucketGroup[i] = uint8(value)
+	}
+
+	hll.cacheValid = false
+}
+
+func TestSaturationExtrapolation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	plain, err := NewHyperLogLog(14)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	extrapolated, err := NewHyperLogLog(14, WithSaturationExtrapolation())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range []float64{2e9, 4e9, 8e9} {
+		fillPoisson(&plain, n, rng)
+		copy(extrapolated.bucketGroup, plain.bucketGroup)
+		extrapolated.cacheValid = false
+
+		plainError := math.Abs(float64(plain.EstimateCardinality())/n - 1)
+		extrapolatedError := math.Abs(float64(extrapolated.EstimateCardinality())/n - 1)
+		if extrapolatedError >= plainError {
+			t.Errorf("at %.0e got extrapolated error %.3f, no better than %.3f without", n, extrapolatedError, plainError)
+		}
+
+		if extrapolated.CurrentError() <= extrapolated.RelativeError() {
+			t.Errorf("at %.0e got reported error %.4f, wanted more than the usual %.4f", n, extrapolated.CurrentError(), extrapolated.RelativeError())
+		}
+	}
+}
+
+func TestSaturationExtrapolationWaitsForPinnedBuckets(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	hll, err := NewHyperLogLog(14, WithSaturationExtrapolation())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Over 1% of buckets are at or one below the top here, but too few are pinned at it
+	fillPoisson(&hll, 3e7, rng)
+	if _, _, ok := tailFit(hll.histogram(), float64(hll.mBuckets), hll.runBits()); ok {
+		t.Fatalf("extrapolating a sketch that isn't saturated")
+	}
+
+	if hll.CurrentError() != hll.RelativeError() {
+		t.Fatalf("got error %f, wanted the usual %f", hll.CurrentError(), hll.RelativeError())
+	}
+}
+
+func TestSaturationExtrapolationOnlyReplacesHarmonicMean(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	plain, err := NewHyperLogLog(14, WithMLEstimator())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	extrapolated, err := NewHyperLogLog(14, WithMLEstimator(), WithSaturationExtrapolation())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fillPoisson(&plain, 4e9, rng)
+	copy(extrapolated.bucketGroup, plain.bucketGroup)
+	extrapolated.cacheValid = false
+
+	if plain.EstimateCardinality() != extrapolated.EstimateCardinality() {
+		t.Fatalf("got %d with extrapolation, wanted the maximum likelihood estimate %d", extrapolated.EstimateCardinality(), plain.EstimateCardinality())
+	}
+
+	if extrapolated.CurrentError() != extrapolated.RelativeError() {
+		t.Fatalf("got error %f, wanted the usual %f", extrapolated.CurrentError(), extrapolated.RelativeError())
+	}
+}
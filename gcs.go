@@ -0,0 +1,285 @@
+package pds
+
+import (
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"slices"
+)
+
+// gcsBinaryVersion is the version byte written at the front of a Golomb-coded set
+const gcsBinaryVersion = 1
+
+// gcsHeaderSize is the version byte, the remainder bits, the number of items and the seed
+const gcsHeaderSize = 18
+
+// GCSBuilder collects items for a Golomb-coded set, a static membership set compact enough to
+// ship over the network, eg. as a safe browsing style list of blocked URLs. Each item hashes
+// to a number below n*2^p, and the sorted numbers are written as Golomb-Rice coded gaps: the
+// high bits in unary then the low p bits as is. That takes about p+1.5 bits per item for a
+// false positive rate of 2^-p, close to the p+1.44 bits the rate needs at the very least
+type GCSBuilder struct {
+	p      uint
+	hashes []uint64
+	keys   keyHasher
+}
+
+// NewGCSBuilder builds a new GCSBuilder for a set with a false positive rate of 2^-p, p being
+// from 1 to 32. Options set how keys are hashed, and readers need the same Hasher
+func NewGCSBuilder(p uint, options ...KeyOption) (*GCSBuilder, error) {
+	if p < 1 || p > 32 {
+		return nil, fmt.Errorf("remainder bits need to be in interval 1>=x>=32")
+	}
+
+	return &GCSBuilder{p: p, keys: newKeyHasher(options)}, nil
+}
+
+// Add hashes and puts some string into the set
+func (gb *GCSBuilder) Add(s string) {
+	gb.hashes = append(gb.hashes, hashKey(&gb.keys, s))
+}
+
+// AddBytes hashes and puts a byte slice into the set
+func (gb *GCSBuilder) AddBytes(b []byte) {
+	gb.hashes = append(gb.hashes, hashKey(&gb.keys, b))
+}
+
+// Build encodes every item added so far as a Golomb-coded set for NewGCSReader, starting with
+// a version byte, the remainder bits, the number of items and the seed as uint64s. Duplicates
+// are only written once
+func (gb *GCSBuilder) Build() ([]byte, error) {
+	hashes := slices.Clone(gb.hashes)
+	slices.Sort(hashes)
+	hashes = slices.Compact(hashes)
+
+	n := uint64(len(hashes))
+	if n > 0 && bits.Len64(n)+int(gb.p) > 64 {
+		return nil, fmt.Errorf("cannot code %d items with %d remainder bits", n, gb.p)
+	}
+
+	values := make([]uint64, len(hashes))
+	for i, h := range hashes {
+		values[i] = gcsValue(h, n, gb.p)
+	}
+	slices.Sort(values)
+
+	data := make([]byte, gcsHeaderSize, gcsHeaderSize+n*uint64(gb.p+2)/8+1)
+	data[0] = gcsBinaryVersion
+	data[1] = uint8(gb.p)
+	binary.BigEndian.PutUint64(data[2:10], n)
+	binary.BigEndian.PutUint64(data[10:18], gb.keys.seed)
+
+	w := gcsWriter{data: data}
+	previous := uint64(0)
+	for _, value := range values {
+		gap := value - previous
+		previous = value
+
+		for quotient := gap >> gb.p; quotient > 0; quotient-- {
+			w.write(1, 1)
+		}
+		w.write(0, 1)
+		w.write(gap, gb.p)
+	}
+
+	return w.data, nil
+}
+
+// gcsValue maps a hash onto [0, n*2^p) with a multiply rather than a division
+func gcsValue(h uint64, n uint64, p uint) uint64 {
+	value, _ := bits.Mul64(mix64(h), n<<p)
+	return value
+}
+
+// gcsWriter appends bits to a byte slice, most significant first
+type gcsWriter struct {
+	data []byte
+	used uint
+}
+
+// write appends the low count bits of value
+func (w *gcsWriter) write(value uint64, count uint) {
+	for count > 0 {
+		if w.used == 0 {
+			w.data = append(w.data, 0)
+		}
+
+		take := min(count, 8-w.used)
+		chunk := byte(value>>(count-take)) & (1<<take - 1)
+		w.data[len(w.data)-1] |= chunk << (8 - w.used - take)
+
+		w.used = (w.used + take) % 8
+		count -= take
+	}
+}
+
+// gcsReader reads bits back from a byte slice written by gcsWriter
+type gcsReader struct {
+	data []byte
+	bit  uint64
+}
+
+// read returns the next count bits, reporting false if the data ran out
+func (r *gcsReader) read(count uint) (uint64, bool) {
+	if r.bit+uint64(count) > 8*uint64(len(r.data)) {
+		return 0, false
+	}
+
+	value := uint64(0)
+	for count > 0 {
+		offset := uint(r.bit % 8)
+		take := min(count, 8-offset)
+		chunk := uint64(r.data[r.bit/8]>>(8-offset-take)) & (1<<take - 1)
+
+		value = value<<take | chunk
+		r.bit += uint64(take)
+		count -= take
+	}
+
+	return value, true
+}
+
+// next decodes the next gap, reporting false if the data ran out
+func (r *gcsReader) next(p uint) (uint64, bool) {
+	quotient := uint64(0)
+	for {
+		bit, ok := r.read(1)
+		if !ok {
+			return 0, false
+		}
+
+		if bit == 0 {
+			break
+		}
+		quotient++
+	}
+
+	remainder, ok := r.read(p)
+
+	return quotient<<p | remainder, ok
+}
+
+// GCSReader answers membership queries against a Golomb-coded set from GCSBuilder.Build
+// without decompressing it. Each query decodes the gaps from the start until it passes the
+// item's value, so checking many items at once with ContainsAll is much cheaper
+type GCSReader struct {
+	p    uint
+	n    uint64
+	data []byte
+	keys keyHasher
+}
+
+// NewGCSReader reads a Golomb-coded set from GCSBuilder.Build, checking every gap decodes. The
+// reader keeps data rather than copying it. The seed is read from data, so options only need
+// to give the Hasher the set was built with, and any seed given has to match
+func NewGCSReader(data []byte, options ...KeyOption) (*GCSReader, error) {
+	if len(data) < 1 {
+		return nil, fmt.Errorf("binary data is too short")
+	}
+
+	if data[0] != gcsBinaryVersion {
+		return nil, fmt.Errorf("%w %d", ErrUnsupportedVersion, data[0])
+	}
+
+	if len(data) < gcsHeaderSize {
+		return nil, fmt.Errorf("binary data is too short")
+	}
+
+	seed := binary.BigEndian.Uint64(data[10:18])
+
+	keys := newKeyHasher(options)
+	if err := keys.decodeSeed(seed, false); err != nil {
+		return nil, err
+	}
+
+	gr := &GCSReader{p: uint(data[1]), n: binary.BigEndian.Uint64(data[2:10]), data: data[gcsHeaderSize:], keys: keys}
+	if gr.p < 1 || gr.p > 32 {
+		return nil, fmt.Errorf("remainder bits need to be in interval 1>=x>=32")
+	}
+
+	if gr.n > 0 && bits.Len64(gr.n)+int(gr.p) > 64 {
+		return nil, fmt.Errorf("cannot code %d items with %d remainder bits", gr.n, gr.p)
+	}
+
+	r := gcsReader{data: gr.data}
+	for i := uint64(0); i < gr.n; i++ {
+		if _, ok := r.next(gr.p); !ok {
+			return nil, fmt.Errorf("set ends after %d of %d items", i, gr.n)
+		}
+	}
+
+	return gr, nil
+}
+
+// Len returns the number of distinct items in the set
+func (gr *GCSReader) Len() uint64 {
+	return gr.n
+}
+
+// contains decodes values in order until reaching or passing the target
+func (gr *GCSReader) contains(h uint64) bool {
+	if gr.n == 0 {
+		return false
+	}
+
+	target := gcsValue(h, gr.n, gr.p)
+	r := gcsReader{data: gr.data}
+	value := uint64(0)
+	for i := uint64(0); i < gr.n; i++ {
+		gap, _ := r.next(gr.p)
+		value += gap
+
+		if value >= target {
+			return value == target
+		}
+	}
+
+	return false
+}
+
+// Contains reports whether some string might be in the set, false means it definitely isn't
+func (gr *GCSReader) Contains(s string) bool {
+	return gr.contains(hashKey(&gr.keys, s))
+}
+
+// ContainsBytes reports whether a byte slice might be in the set
+func (gr *GCSReader) ContainsBytes(b []byte) bool {
+	return gr.contains(hashKey(&gr.keys, b))
+}
+
+// ContainsAll reports for each string whether it might be in the set, decoding the set once
+// for all of them
+func (gr *GCSReader) ContainsAll(items []string) []bool {
+	found := make([]bool, len(items))
+	if gr.n == 0 {
+		return found
+	}
+
+	order := make([]int, len(items))
+	targets := make([]uint64, len(items))
+	for i, item := range items {
+		order[i] = i
+		targets[i] = gcsValue(hashKey(&gr.keys, item), gr.n, gr.p)
+	}
+
+	slices.SortFunc(order, func(a, b int) int {
+		return cmp.Compare(targets[a], targets[b])
+	})
+
+	// Walk the set and the sorted targets together
+	r := gcsReader{data: gr.data}
+	value, _ := r.next(gr.p)
+	decoded := uint64(1)
+	for _, i := range order {
+		for value < targets[i] && decoded < gr.n {
+			gap, _ := r.next(gr.p)
+			value += gap
+			decoded++
+		}
+
+		found[i] = value == targets[i]
+	}
+
+	return found
+}
@@ -0,0 +1,82 @@
+package pds
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGCSRoundTrip(t *testing.T) {
+	for _, options := range [][]KeyOption{nil, {WithKeySeed(11)}} {
+		gb, err := NewGCSBuilder(10, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 5000; i++ {
+			gb.Add(fmt.Sprintf("item-%d", i))
+		}
+		gb.AddBytes([]byte("item-0"))
+
+		data, err := gb.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The seed comes with the data
+		gr, err := NewGCSReader(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if gr.Len() != 5000 {
+			t.Fatalf("got %d items, wanted duplicates written once", gr.Len())
+		}
+
+		items := make([]string, 0, 6000)
+		for i := 0; i < 5000; i++ {
+			items = append(items, fmt.Sprintf("item-%d", i))
+		}
+		for i := 0; i < 1000; i++ {
+			items = append(items, fmt.Sprintf("other-%d", i))
+		}
+
+		found := gr.ContainsAll(items)
+		falsePositives := 0
+		for i, item := range items {
+			if found[i] != gr.Contains(item) {
+				t.Fatalf("ContainsAll and Contains disagree on %s", item)
+			}
+
+			if i < 5000 && !found[i] {
+				t.Fatalf("%s went missing", item)
+			}
+
+			if i >= 5000 && found[i] {
+				falsePositives++
+			}
+		}
+
+		// About 1 in 1000 at a rate of 2^-10
+		if falsePositives > 10 {
+			t.Fatalf("got %d false positives of 1000", falsePositives)
+		}
+	}
+}
+
+func TestGCSReaderSeed(t *testing.T) {
+	gb, _ := NewGCSBuilder(8, WithKeySeed(3))
+	gb.Add("a")
+	data, err := gb.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGCSReader(data, WithKeySeed(4)); err == nil {
+		t.Fatalf("read a set with a different seed than given")
+	}
+
+	gr, err := NewGCSReader(data, WithKeySeed(3))
+	if err != nil || !gr.Contains("a") {
+		t.Fatalf("got error %v reading a set with a matching seed", err)
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/LaceySam/probabilistic-data-structures
+
+go 1.23
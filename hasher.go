@@ -0,0 +1,150 @@
+package pds
+
+import "fmt"
+
+// FNV-1a offset bases and primes, see http://www.isthe.com/chongo/tech/comp/fnv/
+const (
+	fnvOffset32 = 2166136261
+	fnvPrime32  = 16777619
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// Hasher hashes bytes for the sketches in this package, a seed of 0 should give the
+// hash function's standard output
+type Hasher interface {
+	Hash32(data []byte, seed uint32) uint32
+	Hash64(data []byte, seed uint64) uint64
+}
+
+// FNVHasher hashes with FNV-1a, mixing any seed into the offset basis
+// eg. Hash32([]byte("hello"), 0) returns 0x4f9f2cab and Hash64([]byte("hello"), 0) returns 0xa430d84680aabd0b
+type FNVHasher struct{}
+
+// Hash32 returns the 32 bit FNV-1a hash of data
+func (FNVHasher) Hash32(data []byte, seed uint32) uint32 {
+	return fnv32(data, seed)
+}
+
+// Hash64 returns the 64 bit FNV-1a hash of data
+func (FNVHasher) Hash64(data []byte, seed uint64) uint64 {
+	return fnv64(data, seed)
+}
+
+// fnv32 works out the 32 bit FNV-1a hash of strings as well as byte slices, so strings can be
+// hashed without copying them into a byte slice first
+func fnv32[T string | []byte](data T, seed uint32) uint32 {
+	h := uint32(fnvOffset32) ^ seed
+	for i := 0; i < len(data); i++ {
+		h ^= uint32(data[i])
+		h *= fnvPrime32
+	}
+
+	return h
+}
+
+// fnv64 works out the 64 bit FNV-1a hash of strings as well as byte slices
+func fnv64[T string | []byte](data T, seed uint64) uint64 {
+	h := uint64(fnvOffset64) ^ seed
+	for i := 0; i < len(data); i++ {
+		h ^= uint64(data[i])
+		h *= fnvPrime64
+	}
+
+	return h
+}
+
+// HashFunc adapts a plain 64 bit hash function such as xxhash.Sum64 into a Hasher, with Hash32
+// folding the two halves of the hash together. The function takes no seed, so any seed is
+// mixed into its output afterwards, meaning keys that collide under the function collide
+// whatever the seed
+type HashFunc func(data []byte) uint64
+
+// Hash64 returns the function's hash of data, mixed with the seed if there is one
+func (f HashFunc) Hash64(data []byte, seed uint64) uint64 {
+	h := f(data)
+	if seed != 0 {
+		h = mix64(h ^ seed)
+	}
+
+	return h
+}
+
+// Hash32 returns the 64 bit hash of data folded down to 32 bits
+func (f HashFunc) Hash32(data []byte, seed uint32) uint32 {
+	h := f.Hash64(data, uint64(seed))
+
+	return uint32(h ^ h>>32)
+}
+
+// KeyOption configures how a filter or sketch other than a HyperLogLog hashes its keys
+type KeyOption func(*keyHasher)
+
+// WithKeyHasher replaces the default FNV-1a hashing of keys
+func WithKeyHasher(hasher Hasher) KeyOption {
+	return func(kh *keyHasher) {
+		kh.hasher = hasher
+	}
+}
+
+// WithKeySeed seeds the hashing of keys like WithSeed does for a HyperLogLog, so differently
+// seeded filters and sketches of the same keys go wrong on different keys. Only those with the
+// same seed merge, and the seed is kept by their binary encodings
+func WithKeySeed(seed uint64) KeyOption {
+	return func(kh *keyHasher) {
+		kh.seed = seed
+		kh.hashSeed = mix64(seed)
+		kh.seeded = true
+	}
+}
+
+// keyHasher hashes keys into 64 bits with the Hasher and seed a filter or sketch was built
+// with. The zero value hashes with unseeded FNV-1a
+type keyHasher struct {
+	hasher   Hasher
+	seed     uint64
+	hashSeed uint64
+	seeded   bool
+}
+
+// newKeyHasher builds a keyHasher from the options passed to a constructor
+func newKeyHasher(options []KeyOption) keyHasher {
+	kh := keyHasher{hasher: FNVHasher{}}
+	for _, option := range options {
+		option(&kh)
+	}
+
+	return kh
+}
+
+// hashKey hashes a string or byte slice key. Like HyperLogLog.hash the default FNVHasher is run
+// over strings directly so they aren't copied into a byte slice escaping through the Hasher
+func hashKey[T string | []byte](kh *keyHasher, key T) uint64 {
+	switch kh.hasher.(type) {
+	case nil, FNVHasher:
+		return fnv64(key, kh.hashSeed)
+	}
+
+	return kh.hasher.Hash64([]byte(key), kh.hashSeed)
+}
+
+// compatible returns an error unless both hash keys with the same seed
+func (kh *keyHasher) compatible(other *keyHasher) error {
+	if kh.seed != other.seed {
+		return fmt.Errorf("keys were hashed with different seeds")
+	}
+
+	return nil
+}
+
+// decodeSeed takes on a seed read from encoded data, keeping the hasher. Once built or given a
+// seed the receiver's seed has to match
+func (kh *keyHasher) decodeSeed(seed uint64, built bool) error {
+	if (built || kh.seeded) && kh.seed != seed {
+		return fmt.Errorf("data was hashed with seed %d but keys here are hashed with seed %d", seed, kh.seed)
+	}
+
+	WithKeySeed(seed)(kh)
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package pds
+
+import (
+	"hash/fnv"
+	"testing"
+)
+
+func TestFNVHasherValues(t *testing.T) {
+	for _, test := range []struct {
+		data   string
+		seed   uint64
+		want32 uint32
+		want64 uint64
+	}{
+		{data: "", want32: 0x811c9dc5, want64: 0xcbf29ce484222325},
+		{data: "a", want32: 0xe40c292c, want64: 0xaf63dc4c8601ec8c},
+		{data: "hello", want32: 0x4f9f2cab, want64: 0xa430d84680aabd0b},
+	} {
+		var hasher FNVHasher
+		if got := hasher.Hash32([]byte(test.data), uint32(test.seed)); got != test.want32 {
+			t.Errorf("Hash32(%q) got %#x, wanted %#x", test.data, got, test.want32)
+		}
+
+		if got := hasher.Hash64([]byte(test.data), test.seed); got != test.want64 {
+			t.Errorf("Hash64(%q) got %#x, wanted %#x", test.data, got, test.want64)
+		}
+
+		// Unseeded it is plain FNV-1a, the same as the standard library's
+		h32, h64 := fnv.New32a(), fnv.New64a()
+		h32.Write([]byte(test.data))
+		h64.Write([]byte(test.data))
+		if h32.Sum32() != test.want32 || h64.Sum64() != test.want64 {
+			t.Errorf("hash/fnv disagrees for %q", test.data)
+		}
+	}
+}
+
+func TestFNVHasherSeeds(t *testing.T) {
+	var hasher FNVHasher
+	data := []byte("hello")
+
+	// The seed is xored into the offset basis
+	if got, want := hasher.Hash64(data, 1), fnv64("hello", 1); got != want || got == hasher.Hash64(data, 0) {
+		t.Fatalf("seeded Hash64 got %#x, wanted %#x and different from unseeded", got, want)
+	}
+
+	if got := hasher.Hash32(data, 1); got == hasher.Hash32(data, 0) {
+		t.Fatalf("seeded Hash32 matched the unseeded hash %#x", got)
+	}
+}
+
+func TestHashFunc(t *testing.T) {
+	hasher := HashFunc(func(data []byte) uint64 { return uint64(len(data)) << 40 })
+	data := []byte("hello")
+
+	if got := hasher.Hash64(data, 0); got != 5<<40 {
+		t.Fatalf("unseeded Hash64 got %#x, wanted the function's own %#x", got, uint64(5<<40))
+	}
+
+	if got := hasher.Hash64(data, 7); got != mix64(5<<40^7) {
+		t.Fatalf("seeded Hash64 got %#x, wanted %#x", got, mix64(5<<40^7))
+	}
+
+	if got := hasher.Hash32(data, 0); got != 5<<8 {
+		t.Fatalf("Hash32 got %#x, wanted the halves folded together to %#x", got, 5<<8)
+	}
+}
@@ -0,0 +1,131 @@
+package pds
+
+import (
+	"container/list"
+	"fmt"
+)
+
+// hllMapEntry is the sketch of one key in an HLLMap
+type hllMapEntry struct {
+	key  string
+	hll  HyperLogLog
+	size int
+}
+
+// HLLMap keeps a sketch per key, eg. distinct viewers per video, within a bound on the number
+// of keys and the memory their buckets take. Sketches start sparse so the many keys that only
+// ever see a few items stay small. Once over a bound the least recently added to keys are
+// evicted, handing their sketches to a callback so they can be flushed elsewhere first
+type HLLMap struct {
+	indexBits uint32
+	options   []Option
+	maxKeys   int
+	maxBytes  int
+	onEvict   func(key string, hll HyperLogLog)
+
+	entries map[string]*list.Element
+	recency *list.List
+	bytes   int
+}
+
+// NewHLLMap builds a new HLLMap holding up to maxKeys sketches whose buckets take up to
+// maxBytes between them, either bound can be 0 to leave it off but not both. onEvict is
+// called with every evicted key and its sketch and can be nil. The options apply to every
+// key's sketch, alongside WithSparseRepresentation
+func NewHLLMap(indexBits uint32, maxKeys int, maxBytes int, onEvict func(key string, hll HyperLogLog), options ...Option) (*HLLMap, error) {
+	if maxKeys < 0 || maxBytes < 0 {
+		return nil, fmt.Errorf("bounds cannot be negative")
+	}
+
+	if maxKeys == 0 && maxBytes == 0 {
+		return nil, fmt.Errorf("need a bound on keys or bytes")
+	}
+
+	options = append([]Option{WithSparseRepresentation()}, options...)
+	hll, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		return nil, err
+	}
+	hll.Close()
+
+	return &HLLMap{
+		indexBits: indexBits,
+		options:   options,
+		maxKeys:   maxKeys,
+		maxBytes:  maxBytes,
+		onEvict:   onEvict,
+		entries:   make(map[string]*list.Element),
+		recency:   list.New(),
+	}, nil
+}
+
+// Add hashes and puts item into key's sketch, starting one if key doesn't have it, then
+// evicts keys until the map is back within its bounds
+func (m *HLLMap) Add(key string, item string) {
+	element, ok := m.entries[key]
+	if ok {
+		m.recency.MoveToFront(element)
+	} else {
+		hll, _ := NewHyperLogLog(m.indexBits, m.options...)
+		element = m.recency.PushFront(&hllMapEntry{key: key, hll: hll})
+		m.entries[key] = element
+	}
+
+	entry := element.Value.(*hllMapEntry)
+	entry.hll.Add(item)
+
+	size := entry.hll.SizeInBytes()
+	m.bytes += size - entry.size
+	entry.size = size
+
+	// The key just added to stays even if it alone is over the bytes bound
+	for m.recency.Len() > 1 && m.overBounds() {
+		m.evict(m.recency.Back())
+	}
+}
+
+// overBounds reports whether there are too many keys or they take too much memory
+func (m *HLLMap) overBounds() bool {
+	return (m.maxKeys > 0 && m.recency.Len() > m.maxKeys) || (m.maxBytes > 0 && m.bytes > m.maxBytes)
+}
+
+// evict removes a key and hands its sketch to the callback
+func (m *HLLMap) evict(element *list.Element) {
+	entry := m.recency.Remove(element).(*hllMapEntry)
+	delete(m.entries, entry.key)
+	m.bytes -= entry.size
+
+	if m.onEvict != nil {
+		m.onEvict(entry.key, entry.hll)
+	}
+	entry.hll.Close()
+}
+
+// EstimateCardinality returns the estimated number of distinct items added to key, and false
+// if the key has no sketch. It doesn't count as a use of the key for eviction
+func (m *HLLMap) EstimateCardinality(key string) (int64, bool) {
+	element, ok := m.entries[key]
+	if !ok {
+		return 0, false
+	}
+
+	return element.Value.(*hllMapEntry).hll.EstimateCardinality(), true
+}
+
+// Len returns how many keys have a sketch
+func (m *HLLMap) Len() int {
+	return m.recency.Len()
+}
+
+// SizeInBytes returns roughly how much memory the buckets of every sketch take up
+func (m *HLLMap) SizeInBytes() int {
+	return m.bytes
+}
+
+// EvictAll evicts every key through the callback, least recently added to first, eg. to
+// flush everything downstream on shutdown
+func (m *HLLMap) EvictAll() {
+	for m.recency.Len() > 0 {
+		m.evict(m.recency.Back())
+	}
+}
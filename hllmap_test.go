@@ -0,0 +1,21 @@
+package pds
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestNewHLLMapClosesProbeSketch(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 20; i++ {
+		if _, err := NewHLLMap(10, 10, 0, nil, WithAsyncEstimate(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("got %d goroutines after building maps, started with %d", after, before)
+	}
+}
@@ -0,0 +1,70 @@
+package pds
+
+import (
+	"fmt"
+)
+
+// HybridHyperLogLog counts the most recent distinct keys exactly and folds older keys into a
+// HyperLogLog once they drop out of the recent set
+type HybridHyperLogLog struct {
+	hll     HyperLogLog
+	recent  []string
+	next    int
+	members map[string]struct{}
+}
+
+// NewHybridHyperLogLog builds a new HybridHyperLogLog keeping up to recentSize keys exactly
+func NewHybridHyperLogLog(indexBits uint32, recentSize int, options ...Option) (HybridHyperLogLog, error) {
+	if recentSize < 1 {
+		return HybridHyperLogLog{}, fmt.Errorf("recent size needs to be at least 1")
+	}
+
+	hll, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		return HybridHyperLogLog{}, err
+	}
+
+	return HybridHyperLogLog{
+		hll:     hll,
+		recent:  make([]string, 0, recentSize),
+		members: make(map[string]struct{}, recentSize),
+	}, nil
+}
+
+// Add puts a key into the recent set, pushing the oldest recent key into the HyperLogLog
+// when the recent set is full. The key normalizer runs once here and the recent set holds
+// the normalized keys, so keys it maps together are one recent key
+func (h *HybridHyperLogLog) Add(s string) {
+	s = h.hll.normalize(s)
+	if _, ok := h.members[s]; ok {
+		return
+	}
+
+	if len(h.recent) < cap(h.recent) {
+		h.recent = append(h.recent, s)
+	} else {
+		oldest := h.recent[h.next]
+		h.hll.addNormalized(oldest)
+		delete(h.members, oldest)
+
+		h.recent[h.next] = s
+		h.next = (h.next + 1) % len(h.recent)
+	}
+
+	h.members[s] = struct{}{}
+}
+
+// Count returns the HyperLogLog estimate plus every recent key the HyperLogLog has not seen.
+// A recent key is treated as already seen if adding it wouldn't change any bucket, so keys
+// that really were folded in earlier are never counted twice, but a brand new key can
+// occasionally be mistaken for an old one and missed
+func (h *HybridHyperLogLog) Count() int64 {
+	count := h.hll.estimate()
+	for key := range h.members {
+		if !h.hll.mightContain(h.hll.hash(key)) {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,80 @@
+package pds
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHybridCountsOverlappingKeysOnce(t *testing.T) {
+	h, err := NewHybridHyperLogLog(14, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Small enough to all be exactly recent
+	for i := 0; i < 100; i++ {
+		h.Add(fmt.Sprintf("key-%d", i))
+	}
+
+	if h.Count() != 100 {
+		t.Fatalf("got %d for 100 recent keys, wanted exactly 100", h.Count())
+	}
+
+	for i := 100; i < 2000; i++ {
+		h.Add(fmt.Sprintf("key-%d", i))
+	}
+	before := h.Count()
+
+	// Keys long since folded into the sketch come back round into the recent set, and
+	// shouldn't be counted a second time. Pushing the newest keys into the sketch to make room
+	// moves the estimate a little, but nothing like the 100 a plain sum would add
+	for i := 0; i < 100; i++ {
+		h.Add(fmt.Sprintf("key-%d", i))
+	}
+
+	if after := h.Count(); after < before-25 || after > before+25 {
+		t.Fatalf("count went from %d to %d after re-adding 100 old keys", before, after)
+	}
+
+	if c := h.Count(); c < 1900 || c > 2100 {
+		t.Fatalf("got %d for 2000 distinct keys", c)
+	}
+}
+
+func TestNewHybridHyperLogLogRejectsEmptyRecentSet(t *testing.T) {
+	if _, err := NewHybridHyperLogLog(14, 0); err == nil {
+		t.Fatalf("wanted an error for a recent size of 0")
+	}
+}
+
+func TestHybridNormalizesKeysOnce(t *testing.T) {
+	h, err := NewHybridHyperLogLog(14, 50, WithKeyNormalizer(strings.ToLower))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keys differing only in case are one recent key
+	for i := 0; i < 50; i++ {
+		h.Add(fmt.Sprintf("key-%d", i))
+		h.Add(fmt.Sprintf("KEY-%d", i))
+	}
+
+	if h.Count() != 50 {
+		t.Fatalf("got %d for 50 keys added in two cases, wanted exactly 50", h.Count())
+	}
+
+	// Folded into the sketch they are still recognised when they come back in the other case
+	for i := 50; i < 100; i++ {
+		h.Add(fmt.Sprintf("key-%d", i))
+	}
+	before := h.Count()
+
+	for i := 0; i < 50; i++ {
+		h.Add(fmt.Sprintf("Key-%d", i))
+	}
+
+	if after := h.Count(); after < before-5 || after > before+5 {
+		t.Fatalf("count went from %d to %d after re-adding 50 folded keys in another case", before, after)
+	}
+}
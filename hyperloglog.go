@@ -1,45 +1,24 @@
 package pds
 
 import (
+	"encoding/binary"
 	"fmt"
-	"hash/fnv"
+	"maps"
 	"math"
+	"math/bits"
+	"slices"
 )
 
 const (
-	byteSize      = 8
 	bytesIn32Bits = 4
-)
-
-// hash takes a string and hashes it into a uint32
-func hash(value string) uint32 {
-	h := fnv.New32a()
-	h.Write([]byte(value))
-
-	return h.Sum32()
-}
-
-// bucket contains the cardinality estimate
-type bucket struct {
-	cardinalityEstimation int
-}
-
-// findRun figures out how many zeros in a row from the first item there is
-// eg. 00011111000101 returns 3
-func findRun(a uint32) int {
-	return int(math.Log2(float64(a & -a)))
-}
 
-// updateLongestRun will update a bucket if a longer zero run has been found
-func (b *bucket) updateLongestRun(value uint32) {
-	cardinalityEstimation := findRun(value) + 1
-
-	if b.cardinalityEstimation < cardinalityEstimation {
-		b.cardinalityEstimation = cardinalityEstimation
-	}
-}
+	// maxWideBucketValue is the longest zero run plus one a 64 bit hash can give with the
+	// fewest index bits
+	maxWideBucketValue = 64 - 4 + 1
+)
 
-type bucketGroup []bucket
+// bucketGroup holds every bucket's longest zero run plus one, a byte apiece
+type bucketGroup []uint8
 
 // newBucketGroup creates a new bucket group
 func newBucketGroup(mBuckets int64) bucketGroup {
@@ -55,7 +34,7 @@ func (bg bucketGroup) Len() float64 {
 func (bg bucketGroup) countZeroBuckets() float64 {
 	var count float64
 	for _, bucket := range bg {
-		if bucket.cardinalityEstimation == 0 {
+		if bucket == 0 {
 			count++
 		}
 	}
@@ -64,29 +43,79 @@ func (bg bucketGroup) countZeroBuckets() float64 {
 }
 
 // smallRangeCorrection returns a better cardinality estimate for smaller sets
-func (bg bucketGroup) smallRangeCorrection(prediction float64, totalBuckets float64) float64 {
-	return totalBuckets * math.Log(totalBuckets/bg.countZeroBuckets())
+func smallRangeCorrection(totalBuckets float64, zeroBuckets float64) float64 {
+	return totalBuckets * math.Log(totalBuckets/zeroBuckets)
+}
+
+// hashSpace32 is how many different values a 32 bit hash can take
+const hashSpace32 = 1 << 32
+
+// largeRangeCorrection returns a better cardinality estimate for sets big enough that items
+// collide in the 32 bit hash space. Past the size of the hash space there is nothing left to
+// correct against, so the prediction is left alone
+func largeRangeCorrection(prediction float64) float64 {
+	if prediction <= hashSpace32/30 || prediction >= hashSpace32 {
+		return prediction
+	}
+
+	return -hashSpace32 * math.Log(1-prediction/hashSpace32)
 }
 
-// correct will return a better cardinality prediction if the set is too small
-func (bg bucketGroup) correct(prediction float64) float64 {
+// correct will return a better cardinality prediction if the set is too small, linear counting
+// needs at least one empty bucket to work with
+func correct(prediction float64, totalBuckets float64, zeroBuckets float64) float64 {
 
 	switch {
-	case prediction <= 2.5*bg.Len():
-		return bg.smallRangeCorrection(prediction, bg.Len())
+	case prediction <= 2.5*totalBuckets && zeroBuckets > 0:
+		return smallRangeCorrection(totalBuckets, zeroBuckets)
 	default:
 		return prediction
 	}
 }
 
-// harmonicMean calculates a mean of some group, reducing the impact of extreme values
-func (bg bucketGroup) harmonicMean(constant float64) int64 {
-	var total float64
-	for _, v := range bg {
-		total += math.Pow(2, float64(-1*v.cardinalityEstimation))
+// harmonicSum adds up 2^-value over every stride'th bucket along with how many of them are empty
+func (bg bucketGroup) harmonicSum(stride int) (float64, float64) {
+	var total, zeros float64
+	for i := 0; i < len(bg); i += stride {
+		value := bg[i]
+		if value == 0 {
+			zeros++
+		}
+
+		total += math.Pow(2, -float64(value))
 	}
 
-	return int64(bg.correct((constant * bg.Len() * bg.Len()) / total))
+	return total, zeros
+}
+
+// harmonicEstimate turns the harmonic sum over some buckets into a corrected cardinality estimate
+func harmonicEstimate(constant float64, totalBuckets float64, total float64, zeros float64) float64 {
+	return correct((constant*totalBuckets*totalBuckets)/total, totalBuckets, zeros)
+}
+
+// correctLargeRange applies the large range correction to sketches using 32 bit hashes, 64 bit
+// hashes leave too much room for collisions to matter
+func (hll *HyperLogLog) correctLargeRange(prediction float64) float64 {
+	if hll.wideHash {
+		return prediction
+	}
+
+	return largeRangeCorrection(prediction)
+}
+
+// biasConstant returns the bias correction constant for m = 2^indexBits buckets. 4, 5 and 6
+// index bits come straight from the paper, the rest follow 0.7213 / (1 + 1.079/m)
+func biasConstant(indexBits uint32) float64 {
+	switch indexBits {
+	case 4:
+		return 0.673
+	case 5:
+		return 0.697
+	case 6:
+		return 0.709
+	default:
+		return 0.7213 / (1 + 1.079/float64(uint64(1)<<indexBits))
+	}
 }
 
 // HyperLogLog for estimating the cardinality of massive sets
@@ -94,82 +123,641 @@ type HyperLogLog struct {
 	constant    float64
 	indexBits   uint32
 	mBuckets    int64
+	indexMask   uint64
 	bucketGroup bucketGroup
+	sparse      *sparseRegisters
+	estimator   Estimator
+	hasher      Hasher
+	normalizer  func(string) string
+	history     *estimateHistory
+	loads       *bucketLoads
+	async       *asyncEstimate
+	universeCap int64
+	inputLog    *inputLog
+	timestamps  *registerTimestamps
+	hysteresis  *correctionHysteresis
+	extrapolate bool
+	biasCorrect bool
+	wideHash    bool
+	seed        uint64
+	hashSeed    uint64
+
+	storage      []byte
+	flushStorage func([]byte) error
+
+	estimateWorkers int
+
+	exact          map[uint32]struct{}
+	exactThreshold int
+
+	pinnedVersion byte
+
+	subscribers    []chan int64
+	subscribeDelta float64
+	lastPublished  int64
+	liveHistogram  []int
+
+	cachedEstimate int64
+	cacheValid     bool
 }
 
 // NewHyperLogLog builds a new HyperLogLog struct
-func NewHyperLogLog(indexBits uint32) (HyperLogLog, error) {
+func NewHyperLogLog(indexBits uint32, options ...Option) (HyperLogLog, error) {
 
 	if indexBits < 4 || indexBits > 16 {
 		return HyperLogLog{}, fmt.Errorf("index bits need to be in interval 4>=x>=16")
 	}
 
-	mBuckets := math.Pow(2, float64(indexBits))
+	// Shift rather than math.Pow so the bucket count stays exact, the check above keeps
+	// the shift well clear of overflowing an int64
+	mBuckets := int64(1) << indexBits
+	constant := biasConstant(indexBits)
 
-	var constant float64
-	switch indexBits {
-	case 4:
-		constant = 0.673
-	case 5:
-		constant = 0.697
-	case 6:
-		constant = 0.709
-	default:
-		constant = 0.7213 / (1 + 1.079/mBuckets)
+	hll := HyperLogLog{
+		constant:    constant,
+		indexBits:   uint32(indexBits),
+		mBuckets:    mBuckets,
+		indexMask:   uint64(mBuckets - 1),
+		bucketGroup: newBucketGroup(mBuckets),
+		hasher:      FNVHasher{},
+
+		subscribeDelta: defaultSubscribeDelta,
+	}
+
+	for _, option := range options {
+		option(&hll)
 	}
 
+	if hll.storage != nil {
+		if err := hll.useStorage(); err != nil {
+			return HyperLogLog{}, err
+		}
+	}
+
+	if hll.async != nil {
+		// The background estimate reads the buckets while they're being added to, which a
+		// sparse sketch merging its pending pairs on reads can't allow
+		hll.denseRegisters()
+		hll.async.start(hll.computeEstimate())
+	}
+
+	return hll, nil
+}
+
+// emptyCopy builds an empty HyperLogLog configured the same way, leaving out any subscribers
+// and diagnostics
+func (hll *HyperLogLog) emptyCopy() HyperLogLog {
 	return HyperLogLog{
-		constant:    constant,
-		indexBits:   uint32(indexBits),
-		mBuckets:    int64(mBuckets),
-		bucketGroup: newBucketGroup(int64(mBuckets)),
-	}, nil
+		constant:    hll.constant,
+		indexBits:   hll.indexBits,
+		mBuckets:    hll.mBuckets,
+		indexMask:   hll.indexMask,
+		bucketGroup: newBucketGroup(hll.mBuckets),
+		estimator:   hll.estimator,
+		hasher:      hll.hasher,
+		normalizer:  hll.normalizer,
+		universeCap: hll.universeCap,
+		extrapolate: hll.extrapolate,
+		biasCorrect: hll.biasCorrect,
+		wideHash:    hll.wideHash,
+		seed:        hll.seed,
+		hashSeed:    hll.hashSeed,
+
+		pinnedVersion: hll.pinnedVersion,
+
+		estimateWorkers: hll.estimateWorkers,
+
+		subscribeDelta: hll.subscribeDelta,
+	}
 }
 
-// getHeadBitTotal gets the numeric value from a byte
-func getHeadBitTotal(bits uint32, byteNumber uint32) uint32 {
-	var x uint32
+// Clone returns a deep copy of the sketch that later adds to either side don't affect, along
+// with copies of anything tracked alongside the buckets. The copy doesn't take over any
+// background estimate, subscribers or input log
+func (hll *HyperLogLog) Clone() HyperLogLog {
+	hll.lockAsync()
+	defer hll.unlockAsync()
+
+	clone := hll.emptyCopy()
+	clone.bucketGroup = slices.Clone(hll.bucketGroup)
+	clone.exactThreshold = hll.exactThreshold
+
+	if hll.sparse != nil {
+		clone.sparse = &sparseRegisters{
+			data:    slices.Clone(hll.sparse.data),
+			pairs:   hll.sparse.pairs,
+			pending: slices.Clone(hll.sparse.pending),
+		}
+	}
+
+	if hll.exact != nil {
+		clone.exact = maps.Clone(hll.exact)
+	}
+
+	if hll.history != nil {
+		clone.history = &estimateHistory{
+			estimates: slices.Clone(hll.history.estimates),
+			next:      hll.history.next,
+			size:      hll.history.size,
+		}
+	}
+
+	if hll.loads != nil {
+		clone.loads = &bucketLoads{
+			seen:   maps.Clone(hll.loads.seen),
+			counts: slices.Clone(hll.loads.counts),
+		}
+	}
 
-	start := byteSize*byteNumber - byteSize
-	for i := start; i < bits+start; i++ {
-		x += uint32(math.Pow(float64(2), float64(i)))
+	if hll.timestamps != nil {
+		clone.timestamps = &registerTimestamps{
+			now:      hll.timestamps.now,
+			raisedAt: slices.Clone(hll.timestamps.raisedAt),
+		}
 	}
 
-	return x
+	if hll.hysteresis != nil {
+		clone.hysteresis = hll.hysteresis.clone()
+	}
+
+	return clone
+}
+
+// Reset empties every bucket and anything tracked alongside them in place, keeping the
+// configuration and the memory already allocated so the sketch can be reused
+func (hll *HyperLogLog) Reset() {
+	hll.lockAsync()
+	defer hll.unlockAsync()
+
+	hll.reset()
 }
 
-// getSignificantBits gets the numeric value of n significant bits from a fully on binary
-// eg. 11111111 for 4 bits would return 15
-func getSignificantBits(n uint32) uint32 {
-	if n <= byteSize {
-		return getHeadBitTotal(n, 1)
-	} else {
-		remainingBits := n - byteSize
-		return getHeadBitTotal(byteSize, 1) + getHeadBitTotal(remainingBits, 2)
+// reset does the work of Reset for callers already holding the background estimate's lock
+func (hll *HyperLogLog) reset() {
+	clear(hll.bucketGroup)
+
+	if hll.sparse != nil {
+		hll.sparse.reset()
+	}
+
+	if hll.exactThreshold > 0 {
+		hll.exact = make(map[uint32]struct{}, hll.exactThreshold)
 	}
+
+	if hll.history != nil {
+		hll.history.estimates = hll.history.estimates[:0]
+		hll.history.next = 0
+	}
+
+	if hll.loads != nil {
+		clear(hll.loads.seen)
+		clear(hll.loads.counts)
+	}
+
+	if hll.timestamps != nil {
+		clear(hll.timestamps.raisedAt)
+	}
+
+	if hll.hysteresis != nil {
+		hll.hysteresis.reset()
+	}
+
+	hll.lastPublished = 0
+	hll.liveHistogram = nil
+	hll.cacheValid = false
 }
 
 // splitBinary splits the given number into a part used for indexing and part used to count zeros
-func (hll *HyperLogLog) splitBinary(h uint32) (uint32, uint32) {
-	binaryTotal := getSignificantBits(hll.indexBits)
-	// Compute AND on a all on binary to our binary to find the index
-	// eg. 11111111 & 00000011 = 3
-	binaryIndex := h & binaryTotal
+// eg. with 4 index bits 0b1011_0110 gives index 0b0110 and 0b1011 left over
+func (hll *HyperLogLog) splitBinary(h uint64) (uint32, uint64) {
+	return uint32(h & hll.indexMask), h >> hll.indexBits
+}
+
+// rank returns the bucket value for the bits left over after the index, one more than how
+// many zeros they start with from the lowest bit. All zero bits count as a full run
+// eg. 0b0110_1000 gives 4
+func (hll *HyperLogLog) rank(unusedBinary uint64) int {
+	return min(bits.TrailingZeros64(unusedBinary), int(hll.runBits())) + 1
+}
+
+// normalize runs the key normalizer over a string key if there is one
+func (hll *HyperLogLog) normalize(value string) string {
+	if hll.normalizer == nil {
+		return value
+	}
+
+	return hll.normalizer(value)
+}
+
+// hashBits returns how many bits each hash has
+func (hll *HyperLogLog) hashBits() uint32 {
+	if hll.wideHash {
+		return 64
+	}
+
+	return 32
+}
+
+// runBits returns how many bits of each hash are left over for counting zeros
+func (hll *HyperLogLog) runBits() uint32 {
+	return hll.hashBits() - hll.indexBits
+}
+
+// hash takes a string and hashes it, into 32 bits unless the sketch uses 64 bit hashes. The
+// byte slice a string is converted to escapes through the Hasher interface, so the default
+// FNVHasher is run over the string directly to keep adds from allocating
+func (hll *HyperLogLog) hash(value string) uint64 {
+	if _, ok := hll.hasher.(FNVHasher); ok {
+		if hll.wideHash {
+			return fnv64(value, hll.hashSeed)
+		}
+
+		return uint64(fnv32(value, hll.seed32()))
+	}
+
+	return hll.hashBytes([]byte(value))
+}
+
+// hashBytes takes a byte slice and hashes it, into 32 bits unless the sketch uses 64 bit hashes
+func (hll *HyperLogLog) hashBytes(value []byte) uint64 {
+	if hll.wideHash {
+		return hll.hasher.Hash64(value, hll.hashSeed)
+	}
+
+	return uint64(hll.hasher.Hash32(value, hll.seed32()))
+}
+
+// seed32 folds the hash seed down for 32 bit hashes
+func (hll *HyperLogLog) seed32() uint32 {
+	return uint32(hll.hashSeed ^ hll.hashSeed>>32)
+}
+
+// addHash puts an already hashed value into the data structure, returning whether it raised
+// a bucket
+func (hll *HyperLogLog) addHash(h uint64) bool {
+	binaryIndex, unusedBinary := hll.splitBinary(h)
+	if hll.loads != nil {
+		hll.loads.record(h, binaryIndex)
+	}
+
+	if !hll.updateBucket(h, binaryIndex, unusedBinary) {
+		return false
+	}
+
+	if hll.timestamps != nil {
+		hll.timestamps.raisedAt[binaryIndex] = hll.timestamps.now()
+	}
+
+	if len(hll.subscribers) > 0 {
+		hll.publish()
+	}
+
+	return true
+}
+
+// updateBucket records the hash while counting exactly and updates the bucket's longest run,
+// holding off any background estimate while it does
+func (hll *HyperLogLog) updateBucket(h uint64, binaryIndex uint32, unusedBinary uint64) bool {
+	hll.lockAsync()
+	defer hll.unlockAsync()
+
+	if hll.exact != nil {
+		hll.exact[uint32(h)] = struct{}{}
+		if len(hll.exact) > hll.exactThreshold {
+			hll.exact = nil
+		}
+	}
+
+	return hll.raiseRegister(binaryIndex, hll.rank(unusedBinary))
+}
 
-	// Shift remaining binary for later zero counting
-	unusedBinary := ((h - binaryIndex) >> hll.indexBits)
+// mightContain reports whether adding the hash would leave the buckets unchanged, which is
+// always true for hashes that have already been added
+func (hll *HyperLogLog) mightContain(h uint64) bool {
+	binaryIndex, unusedBinary := hll.splitBinary(h)
 
-	return binaryIndex, unusedBinary
+	return hll.register(binaryIndex) >= hll.rank(unusedBinary)
 }
 
 // Add hashes and puts some string into the data structure
 func (hll *HyperLogLog) Add(s string) {
-	h := hash(s)
-	binaryIndex, unusedBinary := hll.splitBinary(h)
-	hll.bucketGroup[binaryIndex].updateLongestRun(unusedBinary)
+	hll.addNormalized(hll.normalize(s))
+}
+
+// addNormalized puts a string the key normalizer has already been run over into the data
+// structure
+func (hll *HyperLogLog) addNormalized(s string) {
+	if hll.inputLog != nil {
+		hll.inputLog.writeString(s)
+	}
+
+	hll.addHash(hll.hash(s))
+}
+
+// AddBytes hashes and puts a byte slice into the data structure without converting it to a
+// string first. The key normalizer only applies to strings so isn't run
+func (hll *HyperLogLog) AddBytes(b []byte) {
+	if hll.inputLog != nil {
+		hll.inputLog.write(b)
+	}
+
+	hll.addHash(hll.hashBytes(b))
+}
+
+// AddAll hashes and puts every string into the data structure, copying each into one reused
+// buffer for hashing instead of converting them separately. It returns how many of them
+// raised a bucket, which falls away as fewer of the values are new
+func (hll *HyperLogLog) AddAll(values []string) int {
+	var buffer []byte
+	var changed int
+	for _, value := range values {
+		value = hll.normalize(value)
+		if hll.inputLog != nil {
+			hll.inputLog.writeString(value)
+		}
+
+		buffer = append(buffer[:0], value...)
+		if hll.addHash(hll.hashBytes(buffer)) {
+			changed++
+		}
+	}
+
+	return changed
+}
+
+// AddAllBytes hashes and puts every byte slice into the data structure, returning how many of
+// them raised a bucket. Like AddBytes the key normalizer isn't run
+func (hll *HyperLogLog) AddAllBytes(values [][]byte) int {
+	var changed int
+	for _, value := range values {
+		if hll.inputLog != nil {
+			hll.inputLog.write(value)
+		}
+
+		if hll.addHash(hll.hashBytes(value)) {
+			changed++
+		}
+	}
+
+	return changed
+}
+
+// AddUint64 hashes and puts an integer into the data structure by the 8 big endian bytes of
+// it mixed, so it counts separately from the same number added as a string. Mixing first
+// spreads runs of sequential ids, which FNV-1a alone leaves clumped together, and as the mix
+// can be undone distinct integers still hash from distinct bytes
+func (hll *HyperLogLog) AddUint64(x uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], mix64(x))
+
+	// Hashed as a string so the default hasher reads the bytes where they are, a slice
+	// handed to a Hasher would have to move to the heap
+	key := string(b[:])
+	if hll.inputLog != nil {
+		hll.inputLog.writeString(key)
+	}
+
+	hll.addHash(hll.hash(key))
+}
+
+// AddKey hashes and puts a composite key into the data structure, resetting the
+// builder so its buffer can be reused for the next key
+func (hll *HyperLogLog) AddKey(kb *KeyBuilder) {
+	if hll.inputLog != nil {
+		hll.inputLog.write(kb.Bytes())
+	}
+
+	hll.addHash(hll.hashBytes(kb.Bytes()))
+	kb.Reset()
+}
+
+// AddAllUnique collapses duplicates out of the values before hashing them in, which saves
+// hashing on batches with lots of repeats but only costs extra memory on mostly unique ones
+func (hll *HyperLogLog) AddAllUnique(values []string) {
+	seen := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		if _, ok := seen[value]; ok {
+			continue
+		}
+
+		seen[value] = struct{}{}
+		hll.Add(value)
+	}
+}
+
+// AddAllDelta adds every value and returns how much the estimate moved, roughly how many of
+// them were new. The change is subject to the error of both estimates so is noisy for
+// batches that are small next to the cardinality, and can even come out negative
+func (hll *HyperLogLog) AddAllDelta(values []string) int64 {
+	before := hll.estimate()
+	for _, value := range values {
+		hll.Add(value)
+	}
+
+	return hll.estimate() - before
 }
 
 // EstimateCardinality returns the current hyper log log cardinality estimate
 func (hll *HyperLogLog) EstimateCardinality() int64 {
-	return hll.bucketGroup.harmonicMean(hll.constant)
+	estimate := hll.estimate()
+	if hll.history != nil {
+		hll.history.record(estimate)
+	}
+
+	return estimate
+}
+
+// estimate works out the cardinality estimate, exactly while the sketch is still small enough
+// to keep every hash and otherwise with the configured estimator
+func (hll *HyperLogLog) estimate() int64 {
+	if hll.exact != nil {
+		return int64(len(hll.exact))
+	}
+
+	return hll.bucketEstimate()
+}
+
+// bucketEstimate returns the cardinality estimate from the buckets, only working it out again
+// once a bucket has changed since last time
+func (hll *HyperLogLog) bucketEstimate() int64 {
+	if !hll.cacheValid {
+		hll.cachedEstimate = hll.computeEstimate()
+		hll.cacheValid = true
+	}
+
+	return hll.cachedEstimate
+}
+
+// computeEstimate works out the cardinality estimate from the buckets with the configured estimator
+func (hll *HyperLogLog) computeEstimate() int64 {
+	return hll.estimateWith(hll.estimator)
+}
+
+// EstimateWith returns the cardinality estimate worked out with the given estimator for this
+// call only, eg. to compare estimators on the same sketch. It isn't cached or recorded in the
+// estimate history
+func (hll *HyperLogLog) EstimateWith(estimator Estimator) int64 {
+	if hll.exact != nil {
+		return int64(len(hll.exact))
+	}
+
+	return hll.estimateWith(estimator)
+}
+
+// estimateWith works out the cardinality estimate from the buckets with the given estimator
+func (hll *HyperLogLog) estimateWith(estimator Estimator) int64 {
+	var total, zeros float64
+	if estimator != MaximumLikelihood {
+		total, zeros = hll.harmonicSum()
+	}
+
+	var counts []int
+	if estimator == MaximumLikelihood || hll.extrapolate {
+		counts = hll.histogram()
+	}
+
+	return hll.estimateFrom(estimator, total, zeros, counts, hll.hysteresis)
+}
+
+// estimateFrom turns the harmonic sum, empty buckets and histogram of some buckets laid out
+// like this sketch's into an estimate with the given estimator and the sketch's corrections.
+// The histogram is only needed by MaximumLikelihood and saturation extrapolation, and
+// hysteresis is nil for buckets that aren't the sketch's own
+func (hll *HyperLogLog) estimateFrom(estimator Estimator, total float64, zeros float64, counts []int, hysteresis *correctionHysteresis) int64 {
+	totalBuckets := float64(hll.mBuckets)
+
+	var estimate int64
+	switch estimator {
+	case MaximumLikelihood:
+		estimate = int64(maximumLikelihoodEstimate(counts, totalBuckets, hll.runBits()))
+	case LogLogBeta:
+		estimate = int64(logLogBetaEstimate(hll.indexBits, hll.constant, totalBuckets, total, zeros))
+	default:
+		if hll.biasCorrect {
+			estimate = int64(biasCorrectedEstimate(hll.indexBits, hll.constant, totalBuckets, total, zeros))
+		} else if hysteresis != nil {
+			estimate = int64(hysteresis.estimate(hll.constant, totalBuckets, total, zeros))
+		} else {
+			estimate = int64(harmonicEstimate(hll.constant, totalBuckets, total, zeros))
+		}
+
+		if hysteresis != nil && !hll.wideHash {
+			estimate = int64(hysteresis.correctLargeRange(float64(estimate)))
+		} else {
+			estimate = int64(hll.correctLargeRange(float64(estimate)))
+		}
+
+		if hll.extrapolate {
+			if extrapolated, _, ok := tailFit(counts, totalBuckets, hll.runBits()); ok {
+				estimate = int64(extrapolated)
+			}
+		}
+	}
+
+	if hll.universeCap > 0 && estimate > hll.universeCap {
+		return hll.universeCap
+	}
+
+	return estimate
+}
+
+// estimateBuckets works out the estimate of buckets laid out like this sketch's with its
+// configured estimator and corrections, the same way whichever sketch they came from, so
+// estimates of several sketches and their union can be combined. Exact counts and the
+// correction hysteresis aren't used as they belong to this sketch's own buckets
+func (hll *HyperLogLog) estimateBuckets(buckets bucketGroup) int64 {
+	total, zeros := buckets.harmonicSum(1)
+
+	return hll.estimateFrom(hll.estimator, total, zeros, buckets.histogram(int(hll.runBits())+1), nil)
+}
+
+// fastEstimateStride is how far apart the buckets sampled by EstimateFast are
+const fastEstimateStride = 4
+
+// EstimateFast returns a rougher estimate from only every fourth bucket, treating them as a
+// HyperLogLog with two fewer index bits that saw a quarter of the items. This costs a quarter
+// of the work but the standard error doubles, from 1.04/sqrt(m) to 1.04/sqrt(m/4). Sketches
+// with fewer than 6 index bits are too small to sample and get the full estimate
+func (hll *HyperLogLog) EstimateFast() int64 {
+	if hll.indexBits < 6 {
+		return hll.estimate()
+	}
+	sampledConstant := biasConstant(hll.indexBits - 2)
+
+	total, zeros := hll.registers().harmonicSum(fastEstimateStride)
+	sampledBuckets := float64(hll.mBuckets / fastEstimateStride)
+
+	return int64(hll.correctLargeRange(fastEstimateStride * harmonicEstimate(sampledConstant, sampledBuckets, total, zeros)))
+}
+
+// AtLeast reports whether the estimate is at least n, straight from the cached estimate when no
+// bucket has changed since it was worked out. Otherwise, as every non empty bucket needs at
+// least one distinct item, it returns early once that many buckets are filled, and stops
+// scanning as soon as too few buckets are left to get there, working out and caching the full
+// estimate instead. Close to n the early return can answer true where the estimate itself
+// would have fallen just short
+func (hll *HyperLogLog) AtLeast(n int64) bool {
+	if n <= 0 {
+		return true
+	}
+
+	if hll.exact != nil {
+		return int64(len(hll.exact)) >= n
+	}
+
+	if hll.cacheValid {
+		return hll.cachedEstimate >= n
+	}
+
+	buckets := hll.registers()
+	var filled int64
+	for i, bucket := range buckets {
+		if bucket != 0 {
+			filled++
+			if filled >= n {
+				return true
+			}
+		}
+
+		if filled+int64(len(buckets)-i-1) < n {
+			break
+		}
+	}
+
+	return hll.bucketEstimate() >= n
+}
+
+// ExpectedHashCollisions estimates how many of the counted items have collided in the
+// hash space using the birthday approximation n^2 / (2 * 2^32), or 2^64 with 64 bit hashes
+func (hll *HyperLogLog) ExpectedHashCollisions() float64 {
+	n := float64(hll.estimate())
+
+	return n * n / (2 * math.Pow(2, float64(hll.hashBits())))
+}
+
+// SizeInBytes returns roughly how much memory the buckets take up, sparse or dense, leaving
+// out the fixed size of the sketch itself and anything tracked alongside the buckets
+func (hll *HyperLogLog) SizeInBytes() int {
+	if hll.sparse != nil {
+		return hll.sparse.size()
+	}
+
+	return len(hll.bucketGroup)
+}
+
+// Equal reports whether both HyperLogLogs have the same index bits, hash width, seed and
+// buckets
+func (hll *HyperLogLog) Equal(other *HyperLogLog) bool {
+	if hll.compatible(other) != nil {
+		return false
+	}
+
+	otherBuckets := other.registers()
+	for i, bucket := range hll.registers() {
+		if bucket != otherBuckets[i] {
+			return false
+		}
+	}
+
+	return true
 }
@@ -0,0 +1,459 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// filledSketch builds a sketch with n distinct items added
+func filledSketch(t testing.TB, indexBits uint32, n int, options ...Option) HyperLogLog {
+	t.Helper()
+
+	hll, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < n; i++ {
+		hll.Add(fmt.Sprintf("item-%d", i))
+	}
+
+	return hll
+}
+
+// goldenConstants are the reference bias correction constants for every valid number of index
+// bits, 4, 5 and 6 from the paper and the rest worked out from 0.7213 / (1 + 1.079/m)
+var goldenConstants = map[uint32]float64{
+	4:  0.673,
+	5:  0.697,
+	6:  0.709,
+	7:  0.7152704932638152,
+	8:  0.7182725932495458,
+	9:  0.7197831133217303,
+	10: 0.7205407583220416,
+	11: 0.7209201792610241,
+	12: 0.7211100396160289,
+	13: 0.7212050072994537,
+	14: 0.7212525005219688,
+	15: 0.7212762494789677,
+	16: 0.7212881245439701,
+}
+
+func TestBiasConstantMatchesGoldenTable(t *testing.T) {
+	for indexBits := uint32(4); indexBits <= 16; indexBits++ {
+		hll, err := NewHyperLogLog(indexBits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := goldenConstants[indexBits]
+		if math.Abs(hll.constant-want) > 1e-12 {
+			t.Errorf("got constant %v for %d index bits, wanted %v", hll.constant, indexBits, want)
+		}
+	}
+}
+
+func TestExpectedHashCollisionsGrowQuadratically(t *testing.T) {
+	small := filledSketch(t, 14, 10000)
+	large := filledSketch(t, 14, 100000)
+
+	// Ten times the items gives about a hundred times the collisions
+	ratio := large.ExpectedHashCollisions() / small.ExpectedHashCollisions()
+	estimates := float64(large.EstimateCardinality()) / float64(small.EstimateCardinality())
+	if math.Abs(ratio-estimates*estimates) > 1e-9*ratio {
+		t.Fatalf("collisions grew %.2fx for %.2fx the estimate, wanted %.2fx", ratio, estimates, estimates*estimates)
+	}
+
+	if ratio < 80 || ratio > 120 {
+		t.Fatalf("collisions grew %.2fx for ten times the items", ratio)
+	}
+
+	wide := filledSketch(t, 14, 100000, With64BitHash())
+	if wide.ExpectedHashCollisions() >= large.ExpectedHashCollisions()/1e9 {
+		t.Fatalf("64 bit hashes expect %g collisions against %g for 32 bit", wide.ExpectedHashCollisions(), large.ExpectedHashCollisions())
+	}
+}
+
+// duplicateBatch returns n long keys drawn from only distinct of them, like a batch of
+// request URLs dominated by a few hot pages
+func duplicateBatch(n, distinct int) []string {
+	prefix := strings.Repeat("/a/fairly/long/request/path", 8)
+
+	values := make([]string, n)
+	for i := range values {
+		values[i] = fmt.Sprintf("%s/%d", prefix, i%distinct)
+	}
+
+	return values
+}
+
+func TestAddAllUniqueMatchesAddAll(t *testing.T) {
+	for _, distinct := range []int{10, 5000} {
+		values := duplicateBatch(10000, distinct)
+
+		all, err := NewHyperLogLog(12)
+		if err != nil {
+			t.Fatal(err)
+		}
+		all.AddAll(values)
+
+		unique, err := NewHyperLogLog(12)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unique.AddAllUnique(values)
+
+		if !unique.Equal(&all) {
+			t.Fatalf("AddAllUnique and AddAll filled different buckets for %d distinct values", distinct)
+		}
+	}
+}
+
+func TestAddAllCountsRaisedBuckets(t *testing.T) {
+	values := duplicateBatch(1000, 1000)
+	want := filledSketch(t, 12, 0)
+	for _, value := range values {
+		want.Add(value)
+	}
+
+	hll := filledSketch(t, 12, 0)
+	if changed := hll.AddAll(values); changed == 0 || changed > len(values) {
+		t.Fatalf("got %d raised buckets from %d new values", changed, len(values))
+	}
+
+	if !hll.Equal(&want) {
+		t.Fatalf("AddAll and Add filled different buckets")
+	}
+
+	if changed := hll.AddAll(values); changed != 0 {
+		t.Fatalf("got %d raised buckets adding the same values again, wanted 0", changed)
+	}
+
+	raw := make([][]byte, len(values))
+	for i, value := range values {
+		raw[i] = []byte(value)
+	}
+
+	if changed := hll.AddAllBytes(raw); changed != 0 {
+		t.Fatalf("got %d raised buckets adding the same values as bytes, wanted 0", changed)
+	}
+}
+
+func benchmarkAddAll(b *testing.B, add func(*HyperLogLog, []string)) {
+	values := duplicateBatch(10000, 10)
+
+	hll, err := NewHyperLogLog(12)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		add(&hll, values)
+	}
+}
+
+func BenchmarkAddAllDuplicates(b *testing.B) {
+	benchmarkAddAll(b, func(hll *HyperLogLog, values []string) { hll.AddAll(values) })
+}
+
+func BenchmarkAddAllUniqueDuplicates(b *testing.B) {
+	benchmarkAddAll(b, (*HyperLogLog).AddAllUnique)
+}
+
+func TestBucketCountForEveryIndexBits(t *testing.T) {
+	for indexBits := uint32(4); indexBits <= 16; indexBits++ {
+		hll, err := NewHyperLogLog(indexBits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hll.mBuckets != 1<<indexBits || len(hll.bucketGroup) != 1<<indexBits {
+			t.Errorf("%d index bits got %d buckets, wanted %d", indexBits, hll.mBuckets, 1<<indexBits)
+		}
+	}
+
+	for _, indexBits := range []uint32{0, 3, 17, 64} {
+		if _, err := NewHyperLogLog(indexBits); err == nil {
+			t.Errorf("wanted an error for %d index bits", indexBits)
+		}
+	}
+}
+
+func TestAtLeast(t *testing.T) {
+	for _, n := range []int{0, 10, 1000, 5000, 100000} {
+		hll := filledSketch(t, 12, n)
+		estimate := hll.EstimateCardinality()
+
+		// Well away from the estimate both ways agree
+		for _, threshold := range []int64{estimate / 2, estimate*2 + 10} {
+			if got, want := hll.AtLeast(threshold), estimate >= threshold; got != want {
+				t.Errorf("%d items AtLeast(%d) got %t, wanted %t for estimate %d", n, threshold, got, want, estimate)
+			}
+		}
+
+		// Close to it AtLeast may say yes early but never says no to an estimate that reaches
+		// the threshold
+		for threshold := estimate - 20; threshold <= estimate+20; threshold++ {
+			if !hll.AtLeast(threshold) && estimate >= threshold {
+				t.Errorf("%d items AtLeast(%d) said no to estimate %d", n, threshold, estimate)
+			}
+		}
+	}
+}
+
+func TestAtLeastWithoutCachedEstimate(t *testing.T) {
+	for _, n := range []int{10, 1000, 100000} {
+		estimated := filledSketch(t, 12, n)
+		estimate := estimated.EstimateCardinality()
+
+		// Each threshold needs a sketch that hasn't worked out its estimate yet
+		for _, threshold := range []int64{estimate / 2, estimate*2 + 10, 1 << 20} {
+			hll := filledSketch(t, 12, n)
+			if got, want := hll.AtLeast(threshold), estimate >= threshold; got != want {
+				t.Errorf("%d items AtLeast(%d) got %t, wanted %t for estimate %d", n, threshold, got, want, estimate)
+			}
+		}
+
+		// Answering from the full estimate leaves it cached for the next call
+		hll := filledSketch(t, 12, n)
+		hll.AtLeast(estimate*2 + 10)
+		if !hll.cacheValid || hll.cachedEstimate != estimate {
+			t.Errorf("%d items left the estimate uncached after AtLeast", n)
+		}
+	}
+}
+
+func TestEstimateFastError(t *testing.T) {
+	// A quarter of the buckets doubles the standard error, 1.04/sqrt(4096) or about 1.6% at
+	// 14 index bits. Only a quarter of the items are seen too, which for small counts is an
+	// error of its own so the sweep starts well above them
+	for seed := uint64(0); seed < 4; seed++ {
+		hll, err := NewHyperLogLog(14)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Random keys so each seed gives a different sketch
+		rng := rand.New(rand.NewPCG(seed, 0))
+		kb := NewKeyBuilder()
+
+		added := 0
+		for _, n := range []int{20000, 200000, 1000000} {
+			for ; added < n; added++ {
+				hll.AddKey(kb.AddInt(int64(rng.Uint64())))
+			}
+
+			fast, full := float64(hll.EstimateFast()), float64(hll.EstimateCardinality())
+			if math.Abs(fast/float64(n)-1) > 0.06 {
+				t.Errorf("seed %d with %d items got fast estimate %.0f", seed, n, fast)
+			}
+
+			if math.Abs(fast/full-1) > 0.06 {
+				t.Errorf("seed %d with %d items got fast estimate %.0f against %.0f", seed, n, fast, full)
+			}
+		}
+	}
+
+	small := filledSketch(t, 5, 100)
+	if small.EstimateFast() != small.EstimateCardinality() {
+		t.Fatalf("fast estimate with 5 index bits didn't fall back to the full estimate")
+	}
+}
+
+// BenchmarkEstimateHarmonicMean works out the full estimate every time, where
+// EstimateCardinality would return a cached one
+func BenchmarkEstimateHarmonicMean(b *testing.B) {
+	hll := filledSketch(b, 14, 200000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hll.EstimateWith(HarmonicMean)
+	}
+}
+
+func BenchmarkEstimateFast(b *testing.B) {
+	hll := filledSketch(b, 14, 200000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hll.EstimateFast()
+	}
+}
+
+func TestKeyNormalizerCollapsesVariants(t *testing.T) {
+	normalize := WithKeyNormalizer(func(s string) string { return strings.ToLower(strings.TrimSpace(s)) })
+
+	hll, err := NewHyperLogLog(12, normalize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hll.Add("Foo")
+	hll.Add(" foo ")
+	hll.AddAll([]string{"FOO", "fOo", "foo\t"})
+
+	want, err := NewHyperLogLog(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Add("foo")
+
+	if !hll.Equal(&want) || hll.EstimateCardinality() != 1 {
+		t.Fatalf("got estimate %d for casing variants of one key, wanted 1", hll.EstimateCardinality())
+	}
+
+	plain, err := NewHyperLogLog(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain.AddAll([]string{"Foo", " foo ", "FOO", "fOo", "foo\t"})
+
+	if plain.EstimateCardinality() != 5 {
+		t.Fatalf("got estimate %d without a normalizer, wanted 5", plain.EstimateCardinality())
+	}
+}
+
+func TestAddAllDeltaSumsToCardinality(t *testing.T) {
+	hll, err := NewHyperLogLog(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each batch of 2000 repeats the last 500 items of the batch before
+	var total int64
+	for batch := 0; batch < 20; batch++ {
+		values := make([]string, 2000)
+		for i := range values {
+			values[i] = fmt.Sprintf("item-%d", batch*1500+i)
+		}
+
+		total += hll.AddAllDelta(values)
+	}
+
+	// The deltas add up to the final estimate, which is within the sketch's error of the
+	// 30500 distinct items
+	if total != hll.EstimateCardinality() {
+		t.Fatalf("deltas summed to %d, wanted the final estimate %d", total, hll.EstimateCardinality())
+	}
+
+	if math.Abs(float64(total)/30500-1) > 3*hll.RelativeError() {
+		t.Fatalf("deltas summed to %d for 30500 distinct items", total)
+	}
+
+	if delta := hll.AddAllDelta([]string{"item-0", "item-1"}); delta != 0 {
+		t.Fatalf("re-adding seen items moved the estimate by %d", delta)
+	}
+}
+
+func TestUniverseCapClampsEstimate(t *testing.T) {
+	for _, estimator := range []Estimator{HarmonicMean, MaximumLikelihood, LogLogBeta} {
+		capped := filledSketch(t, 12, 5000, WithUniverseCap(1000), WithEstimator(estimator))
+		if capped.EstimateCardinality() != 1000 {
+			t.Errorf("estimator %d got %d for 5000 items under a cap of 1000", estimator, capped.EstimateCardinality())
+		}
+
+		// Only the top is clamped, estimates under the cap are left as they are
+		under := filledSketch(t, 12, 500, WithUniverseCap(1000), WithEstimator(estimator))
+		plain := filledSketch(t, 12, 500, WithEstimator(estimator))
+		if under.EstimateCardinality() != plain.EstimateCardinality() {
+			t.Errorf("estimator %d got %d under the cap, wanted the uncapped %d", estimator, under.EstimateCardinality(), plain.EstimateCardinality())
+		}
+	}
+}
+
+func TestAddUint64SequentialIDs(t *testing.T) {
+	const indexBits = 14
+	tolerance := 3 * 1.04 / math.Sqrt(1<<indexBits)
+
+	for _, n := range []uint64{1000, 100000, 1000000} {
+		hll, err := NewHyperLogLog(indexBits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := uint64(0); i < n; i++ {
+			hll.AddUint64(i)
+		}
+
+		if got := float64(hll.EstimateCardinality()); math.Abs(got-float64(n))/float64(n) > tolerance {
+			t.Errorf("got estimate %.0f for %d sequential ids, wanted within %.1f%%", got, n, tolerance*100)
+		}
+	}
+}
+
+func TestAddUint64DistinctFromString(t *testing.T) {
+	hll, err := NewHyperLogLog(10, WithExactThreshold(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hll.AddUint64(1)
+	hll.AddUint64(1)
+	hll.Add("1")
+
+	if got := hll.EstimateCardinality(); got != 2 {
+		t.Fatalf("got %d, wanted the integer and the string counted once each", got)
+	}
+}
+
+func TestSizeInBytes(t *testing.T) {
+	dense := filledSketch(t, 14, 100)
+	if got := dense.SizeInBytes(); got != 1<<14 {
+		t.Fatalf("got %d bytes for a dense sketch, wanted one per bucket", got)
+	}
+
+	sparse := filledSketch(t, 14, 100, WithSparseRepresentation())
+	if got := sparse.SizeInBytes(); got == 0 || got >= dense.SizeInBytes() {
+		t.Fatalf("got %d bytes for a sparse sketch of 100 items, wanted under %d", got, dense.SizeInBytes())
+	}
+
+	// Once it turns dense it takes the same as any other dense sketch
+	for i := 100; i < 50000; i++ {
+		sparse.Add(fmt.Sprintf("item-%d", i))
+	}
+
+	if got := sparse.SizeInBytes(); got != dense.SizeInBytes() {
+		t.Fatalf("got %d bytes once dense, wanted %d", got, dense.SizeInBytes())
+	}
+}
+
+func TestAddDoesNotAllocate(t *testing.T) {
+	for _, test := range encodingTests {
+		t.Run(test.name, func(t *testing.T) {
+			hll := filledSketch(t, 12, 10000, test.options...)
+			data := []byte("a byte key")
+
+			allocs := testing.AllocsPerRun(1000, func() {
+				hll.Add("a string key")
+				hll.AddBytes(data)
+				hll.AddUint64(42)
+			})
+
+			if allocs != 0 {
+				t.Fatalf("got %.1f allocations per Add, wanted 0", allocs)
+			}
+		})
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	hll, err := NewHyperLogLog(14)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("item-%d", i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hll.Add(keys[i%len(keys)])
+	}
+}
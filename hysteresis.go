@@ -0,0 +1,85 @@
+package pds
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// hysteresisBand is how far either side of the small and large range thresholds the estimate
+// has to move, relative to the threshold, before a different correction is picked
+const hysteresisBand = 0.02
+
+// The corrections correctionHysteresis can have settled on
+const (
+	unchosenRange int32 = iota
+	smallRange
+	normalRange
+	largeRange
+)
+
+// correctionHysteresis remembers which corrections were used last so estimates hovering around
+// a threshold don't flip between them
+type correctionHysteresis struct {
+	chosen      atomic.Int32
+	chosenLarge atomic.Int32
+}
+
+// estimate works out the corrected estimate like harmonicEstimate, only leaving the last chosen
+// correction once the raw estimate is clear of the threshold by the hysteresis band
+func (ch *correctionHysteresis) estimate(constant float64, totalBuckets float64, total float64, zeros float64) float64 {
+	prediction := (constant * totalBuckets * totalBuckets) / total
+	threshold := bandedThreshold(2.5*totalBuckets, ch.chosen.Load(), smallRange)
+
+	if prediction <= threshold && zeros > 0 {
+		ch.chosen.Store(smallRange)
+		return smallRangeCorrection(totalBuckets, zeros)
+	}
+
+	ch.chosen.Store(normalRange)
+
+	return prediction
+}
+
+// correctLargeRange applies the large range correction like largeRangeCorrection, only leaving
+// the last chosen correction once the prediction is clear of the threshold by the hysteresis
+// band. Past the size of the hash space the prediction is still left alone
+func (ch *correctionHysteresis) correctLargeRange(prediction float64) float64 {
+	threshold := bandedThreshold(hashSpace32/30, ch.chosenLarge.Load(), normalRange)
+
+	if prediction <= threshold || prediction >= hashSpace32 {
+		ch.chosenLarge.Store(normalRange)
+		return prediction
+	}
+
+	ch.chosenLarge.Store(largeRange)
+
+	return -hashSpace32 * math.Log(1-prediction/hashSpace32)
+}
+
+// bandedThreshold moves the threshold by the hysteresis band away from the last chosen side,
+// where below is the correction picked at or under the threshold
+func bandedThreshold(threshold float64, chosen int32, below int32) float64 {
+	switch {
+	case chosen == unchosenRange:
+		return threshold
+	case chosen == below:
+		return threshold * (1 + hysteresisBand)
+	default:
+		return threshold * (1 - hysteresisBand)
+	}
+}
+
+// clone returns a copy remembering the same corrections
+func (ch *correctionHysteresis) clone() *correctionHysteresis {
+	clone := &correctionHysteresis{}
+	clone.chosen.Store(ch.chosen.Load())
+	clone.chosenLarge.Store(ch.chosenLarge.Load())
+
+	return clone
+}
+
+// reset forgets the corrections chosen, for when the buckets have been replaced
+func (ch *correctionHysteresis) reset() {
+	ch.chosen.Store(unchosenRange)
+	ch.chosenLarge.Store(unchosenRange)
+}
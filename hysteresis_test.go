@@ -0,0 +1,108 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// uncorrected reports whether got is the raw estimate, allowing for rounding in and out of
+// the harmonic sum
+func uncorrected(got, raw float64) bool {
+	return math.Abs(got-raw) <= 1e-9*raw
+}
+
+// totalFor returns the harmonic sum giving a raw estimate of prediction for m buckets
+func totalFor(constant, m, prediction float64) float64 {
+	return constant * m * m / prediction
+}
+
+func TestCorrectionHysteresisHoldsInsideBand(t *testing.T) {
+	const m, zeros = 1024.0, 100.0
+	constant := biasConstant(10)
+	threshold := 2.5 * m
+
+	var ch correctionHysteresis
+
+	// Just above the threshold settles on no correction, and dipping just under it stays there
+	above := ch.estimate(constant, m, totalFor(constant, m, threshold*1.01), zeros)
+	if !uncorrected(above, threshold*1.01) {
+		t.Fatalf("got %f just above the threshold, wanted the raw %f", above, threshold*1.01)
+	}
+
+	for i := 0; i < 10; i++ {
+		for _, raw := range []float64{threshold * 0.99, threshold * 1.01} {
+			if got := ch.estimate(constant, m, totalFor(constant, m, raw), zeros); !uncorrected(got, raw) {
+				t.Fatalf("got %f for %f inside the band, wanted it left uncorrected", got, raw)
+			}
+		}
+	}
+
+	// Clearing the band switches to the small range correction, which then holds in turn
+	below := ch.estimate(constant, m, totalFor(constant, m, threshold*0.97), zeros)
+	if below != smallRangeCorrection(m, zeros) {
+		t.Fatalf("got %f past the band, wanted the small range correction", below)
+	}
+
+	if got := ch.estimate(constant, m, totalFor(constant, m, threshold*1.01), zeros); got != smallRangeCorrection(m, zeros) {
+		t.Fatalf("got %f back inside the band, wanted the small range correction kept", got)
+	}
+}
+
+func TestCorrectionHysteresisGivesRepeatableEstimates(t *testing.T) {
+	hll, err := NewHyperLogLog(10, WithCorrectionHysteresis())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fill up to the small range threshold of 2.5m raw
+	for i := 0; ; i++ {
+		hll.Add(fmt.Sprintf("item-%d", i))
+
+		total, _ := hll.harmonicSum()
+		if hll.constant*1024*1024/total >= 2.5*1024 {
+			break
+		}
+	}
+
+	first := hll.EstimateCardinality()
+	for i := 0; i < 100; i++ {
+		if got := hll.EstimateWith(HarmonicMean); got != first {
+			t.Fatalf("estimate %d flickered to %d on a borderline sketch", first, got)
+		}
+	}
+}
+
+func TestCorrectionHysteresisHoldsLargeRange(t *testing.T) {
+	const space = float64(hashSpace32)
+	threshold := space / 30
+
+	var ch correctionHysteresis
+
+	// Just below the threshold settles on no correction, and going just over it stays there
+	if got := ch.correctLargeRange(threshold * 0.99); got != threshold*0.99 {
+		t.Fatalf("got %f just below the threshold, wanted the raw %f", got, threshold*0.99)
+	}
+
+	for i := 0; i < 10; i++ {
+		for _, raw := range []float64{threshold * 1.01, threshold * 0.99} {
+			if got := ch.correctLargeRange(raw); got != raw {
+				t.Fatalf("got %f for %f inside the band, wanted it left uncorrected", got, raw)
+			}
+		}
+	}
+
+	// Clearing the band switches to the large range correction, which then holds in turn
+	if got := ch.correctLargeRange(threshold * 1.03); got != largeRangeCorrection(threshold*1.03) {
+		t.Fatalf("got %f past the band, wanted the large range correction", got)
+	}
+
+	if got := ch.correctLargeRange(threshold * 0.99); got != -space*math.Log(1-threshold*0.99/space) {
+		t.Fatalf("got %f back inside the band, wanted the large range correction kept", got)
+	}
+
+	// Past the hash space there is nothing to correct against whatever was chosen
+	if got := ch.correctLargeRange(space * 1.5); got != space*1.5 {
+		t.Fatalf("got %f past the hash space, wanted it left alone", got)
+	}
+}
@@ -0,0 +1,73 @@
+package pds
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// inputLog writes every key exactly as it was hashed, each prefixed with its length as a
+// uvarint. Keys are copied into buffer and written out from there, so the writer never holds
+// on to the caller's key and it can stay on the stack
+type inputLog struct {
+	w      io.Writer
+	err    error
+	buffer []byte
+}
+
+// write logs a key, giving up after the first error
+func (il *inputLog) write(key []byte) {
+	if il.err != nil {
+		return
+	}
+
+	il.buffer = append(binary.AppendUvarint(il.buffer[:0], uint64(len(key))), key...)
+	_, il.err = il.w.Write(il.buffer)
+}
+
+// writeString logs a string key, giving up after the first error
+func (il *inputLog) writeString(key string) {
+	if il.err != nil {
+		return
+	}
+
+	il.buffer = append(binary.AppendUvarint(il.buffer[:0], uint64(len(key))), key...)
+	_, il.err = il.w.Write(il.buffer)
+}
+
+// InputLogErr returns the first error hit writing to the input log, after which logging stops
+func (hll *HyperLogLog) InputLogErr() error {
+	if hll.inputLog == nil {
+		return nil
+	}
+
+	return hll.inputLog.err
+}
+
+// Replay hashes every key from an input log written by WithInputLog into the HyperLogLog.
+// Keys are logged after normalizing so they are hashed as is, replaying into a sketch with
+// the same hasher reproduces the logged sketch's buckets
+func (hll *HyperLogLog) Replay(r io.Reader) error {
+	br := bufio.NewReader(r)
+	var key []byte
+
+	for {
+		length, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if uint64(cap(key)) < length {
+			key = make([]byte, length)
+		}
+		key = key[:length]
+
+		if _, err := io.ReadFull(br, key); err != nil {
+			return err
+		}
+
+		hll.addHash(hll.hashBytes(key))
+	}
+}
@@ -0,0 +1,89 @@
+package pds
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReplayReproducesBuckets(t *testing.T) {
+	var log bytes.Buffer
+	hll, err := NewHyperLogLog(10, WithInputLog(&log), WithKeyNormalizer(strings.ToLower))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every way of adding keys ends up in the log
+	for i := 0; i < 1000; i++ {
+		hll.Add(fmt.Sprintf("Item-%d", i))
+	}
+	hll.AddAll([]string{"BATCHED", "keys"})
+	hll.AddBytes([]byte("raw bytes"))
+	hll.AddAllBytes([][]byte{{1, 2, 3}, {}})
+	hll.AddUint64(42)
+	hll.AddKey(NewKeyBuilder().AddString("user").AddInt(7))
+
+	if err := hll.InputLogErr(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Keys were logged normalized, so the replay doesn't need the normalizer
+	replayed, err := NewHyperLogLog(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := replayed.Replay(&log); err != nil {
+		t.Fatal(err)
+	}
+
+	if !replayed.Equal(&hll) {
+		t.Fatalf("replayed buckets differ from the logged sketch")
+	}
+}
+
+func TestReplayRejectsTruncatedLog(t *testing.T) {
+	var log bytes.Buffer
+	hll, err := NewHyperLogLog(10, WithInputLog(&log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hll.Add("a key long enough to cut short")
+
+	replayed, err := NewHyperLogLog(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := replayed.Replay(bytes.NewReader(log.Bytes()[:log.Len()-3])); err == nil {
+		t.Fatalf("wanted an error replaying a truncated log")
+	}
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestInputLogErr(t *testing.T) {
+	hll, err := NewHyperLogLog(10, WithInputLog(failingWriter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hll.Add("first")
+	hll.Add("second")
+
+	if err := hll.InputLogErr(); err == nil || err.Error() != "disk full" {
+		t.Fatalf("got %v, wanted the writer's error", err)
+	}
+
+	// The sketch keeps counting even once logging has stopped
+	if hll.EstimateCardinality() != 2 {
+		t.Fatalf("got estimate %d, wanted 2", hll.EstimateCardinality())
+	}
+}
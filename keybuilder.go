@@ -0,0 +1,64 @@
+package pds
+
+import (
+	"encoding/binary"
+)
+
+// Field tags written ahead of each field so different field types never encode the same
+const (
+	stringField byte = iota + 1
+	intField
+	bytesField
+)
+
+// KeyBuilder accumulates a composite key out of several fields
+// eg. kb.AddString("user").AddInt(42) can then be passed to HyperLogLog.AddKey
+type KeyBuilder struct {
+	buf []byte
+}
+
+// NewKeyBuilder builds a new, empty KeyBuilder
+func NewKeyBuilder() *KeyBuilder {
+	return &KeyBuilder{}
+}
+
+// appendField writes a tagged, length prefixed field to the buffer
+func (kb *KeyBuilder) appendField(tag byte, value []byte) *KeyBuilder {
+	kb.buf = append(kb.buf, tag)
+	kb.buf = binary.AppendUvarint(kb.buf, uint64(len(value)))
+	kb.buf = append(kb.buf, value...)
+
+	return kb
+}
+
+// AddString adds a string field to the key
+func (kb *KeyBuilder) AddString(s string) *KeyBuilder {
+	kb.buf = append(kb.buf, stringField)
+	kb.buf = binary.AppendUvarint(kb.buf, uint64(len(s)))
+	kb.buf = append(kb.buf, s...)
+
+	return kb
+}
+
+// AddInt adds an integer field to the key
+func (kb *KeyBuilder) AddInt(i int64) *KeyBuilder {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(i))
+
+	return kb.appendField(intField, b[:])
+}
+
+// AddBytes adds a byte slice field to the key
+func (kb *KeyBuilder) AddBytes(b []byte) *KeyBuilder {
+	return kb.appendField(bytesField, b)
+}
+
+// Bytes returns the encoded key, only valid until the builder is next modified
+func (kb *KeyBuilder) Bytes() []byte {
+	return kb.buf
+}
+
+// Reset empties the builder while keeping its buffer for the next key
+func (kb *KeyBuilder) Reset() {
+	kb.buf = kb.buf[:0]
+}
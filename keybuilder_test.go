@@ -0,0 +1,42 @@
+package pds
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestKeyBuilderKeepsFieldsApart(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		a, b *KeyBuilder
+	}{
+		{name: "split strings", a: NewKeyBuilder().AddString("ab").AddString("c"), b: NewKeyBuilder().AddString("a").AddString("bc")},
+		{name: "one string or two", a: NewKeyBuilder().AddString("abc"), b: NewKeyBuilder().AddString("a").AddString("bc")},
+		{name: "string or bytes", a: NewKeyBuilder().AddString("abc"), b: NewKeyBuilder().AddBytes([]byte("abc"))},
+		{name: "int or bytes", a: NewKeyBuilder().AddInt(1), b: NewKeyBuilder().AddBytes([]byte{0, 0, 0, 0, 0, 0, 0, 1})},
+		{name: "empty fields", a: NewKeyBuilder().AddString("").AddString("a"), b: NewKeyBuilder().AddString("a").AddString("")},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if bytes.Equal(test.a.Bytes(), test.b.Bytes()) {
+				t.Fatalf("both groupings encode to %v", test.a.Bytes())
+			}
+		})
+	}
+}
+
+func TestKeyBuilderCountsCompositeKeys(t *testing.T) {
+	hll, err := NewHyperLogLog(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// AddKey resets the builder for the next key
+	kb := NewKeyBuilder()
+	for _, fields := range [][2]string{{"ab", "c"}, {"a", "bc"}, {"ab", "c"}} {
+		hll.AddKey(kb.AddString(fields[0]).AddString(fields[1]))
+	}
+
+	if got := hll.EstimateCardinality(); got != 2 {
+		t.Fatalf("got %d distinct keys, wanted 2", got)
+	}
+}
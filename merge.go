@@ -0,0 +1,335 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"runtime"
+	"sync"
+)
+
+// compatible returns an error unless other's buckets line up with this sketch's, which needs
+// the same index bits, hash width and seed
+func (hll *HyperLogLog) compatible(other *HyperLogLog) error {
+	if other.indexBits != hll.indexBits {
+		return fmt.Errorf("%d index bits don't match %d index bits", other.indexBits, hll.indexBits)
+	}
+
+	if other.wideHash != hll.wideHash {
+		return fmt.Errorf("%d bit hashes don't match %d bit hashes", other.hashBits(), hll.hashBits())
+	}
+
+	if other.seed != hll.seed {
+		return fmt.Errorf("sketches were hashed with different seeds")
+	}
+
+	return nil
+}
+
+// MergeUpsampled folds a lower precision HyperLogLog into this one. With d more index bits
+// here, the lowest d bits of each of its runs became index bits. A bucket at r > d had those
+// bits all zero, so its item goes in the first of the 2^d buckets sharing its low index bits
+// at a run of r-d. One at r <= d places its item at 1, the lowest run it could have, in the
+// bucket whose extra index bits end in a one after r-1 zeros and are zero above that. Once
+// about two fifths of the lower buckets are set each is likely hiding other items, which
+// would have landed across the rest of its group, so buckets at r > d are then replicated
+// across all 2^d at r-d. Below that most lower buckets hold a single item and replicating
+// would count it 2^d times, so only the one bucket is raised. This is only an approximation,
+// close to the lower sketch's own estimate, and can only ever increase the estimate. Both
+// need the same hash width and seed
+func (hll *HyperLogLog) MergeUpsampled(lower *HyperLogLog) error {
+	if hll.indexBits < lower.indexBits {
+		return fmt.Errorf("cannot upsample %d index bits into %d index bits", lower.indexBits, hll.indexBits)
+	}
+
+	if hll.wideHash != lower.wideHash {
+		return fmt.Errorf("cannot upsample %d bit hashes into %d bit hashes", lower.hashBits(), hll.hashBits())
+	}
+
+	if hll.seed != lower.seed {
+		return fmt.Errorf("cannot upsample a sketch hashed with a different seed")
+	}
+
+	hll.lockAsync()
+	defer hll.unlockAsync()
+
+	hll.exact = nil
+
+	lowerBuckets := lower.registers()
+	extraBits := int(hll.indexBits - lower.indexBits)
+
+	set := 0
+	for _, b := range lowerBuckets {
+		if b > 0 {
+			set++
+		}
+	}
+	spread := 5*set >= 2*len(lowerBuckets)
+
+	for j, b := range lowerBuckets {
+		r := int(b)
+		switch {
+		case r == 0:
+		case r > extraBits:
+			groupSize := 1
+			if spread {
+				groupSize <<= extraBits
+			}
+
+			for e := 0; e < groupSize; e++ {
+				hll.raiseRegister(uint32(j|e<<lower.indexBits), r-extraBits)
+			}
+		default:
+			hll.raiseRegister(uint32(j|1<<(r-1)<<lower.indexBits), 1)
+		}
+	}
+
+	return nil
+}
+
+// MergeRegisterMaxima raises each bucket named in indices to at least the matching value,
+// useful for loading per bucket maxima that were aggregated elsewhere. Values can't be above
+// the run of zeros plus one that the sketch's hash width allows, and nothing is changed if
+// any index or value is out of range
+func (hll *HyperLogLog) MergeRegisterMaxima(indices []uint32, values []uint8) error {
+	if len(indices) != len(values) {
+		return fmt.Errorf("got %d indices but %d values", len(indices), len(values))
+	}
+
+	maxValue := hll.runBits() + 1
+	for i, index := range indices {
+		if int64(index) >= hll.mBuckets {
+			return fmt.Errorf("bucket index %d out of range", index)
+		}
+
+		if uint32(values[i]) > maxValue {
+			return fmt.Errorf("bucket value %d is above the maximum of %d", values[i], maxValue)
+		}
+	}
+
+	hll.lockAsync()
+	defer hll.unlockAsync()
+
+	hll.exact = nil
+
+	for i, index := range indices {
+		hll.raiseRegister(index, int(values[i]))
+	}
+
+	return nil
+}
+
+// EstimateUnionCardinality estimates the cardinality of the union of the sketches by taking
+// the largest value of each bucket across them, without building a merged sketch. Each maximum
+// goes straight into the harmonic sum and histogram the estimate is worked out from, which
+// uses the first sketch's estimator and corrections like estimateBuckets. They all need the
+// same index bits, hash width and seed
+func EstimateUnionCardinality(sketches []*HyperLogLog) (int64, error) {
+	if len(sketches) == 0 {
+		return 0, fmt.Errorf("need at least one sketch")
+	}
+
+	first := sketches[0]
+	buckets := make([]bucketGroup, len(sketches))
+	for i, sketch := range sketches {
+		if err := first.compatible(sketch); err != nil {
+			return 0, fmt.Errorf("cannot union sketches: %w", err)
+		}
+
+		buckets[i] = sketch.registers()
+	}
+
+	maxValue := uint8(first.runBits() + 1)
+	counts := make([]int, maxValue+1)
+
+	var total, zeros float64
+	for i, value := range buckets[0] {
+		for _, other := range buckets[1:] {
+			value = max(value, other[i])
+		}
+
+		if value == 0 {
+			zeros++
+		}
+		total += math.Pow(2, -float64(value))
+		counts[min(value, maxValue)]++
+	}
+
+	return first.estimateFrom(first.estimator, total, zeros, counts, nil), nil
+}
+
+// Fold combines the sketches into a new one by running reducer over each bucket in turn, eg.
+// taking the max gives the usual union and taking the min a rough stand in for intersection.
+// The result is configured like the first sketch, and they all need the same index bits,
+// hash width and seed
+func Fold(reducer func(a, b uint8) uint8, sketches ...*HyperLogLog) (HyperLogLog, error) {
+	if len(sketches) == 0 {
+		return HyperLogLog{}, fmt.Errorf("need at least one sketch")
+	}
+
+	first := sketches[0]
+	for _, sketch := range sketches[1:] {
+		if err := first.compatible(sketch); err != nil {
+			return HyperLogLog{}, fmt.Errorf("cannot fold sketches: %w", err)
+		}
+	}
+
+	buckets := make([]bucketGroup, len(sketches))
+	for i, sketch := range sketches {
+		buckets[i] = sketch.registers()
+	}
+
+	folded := first.emptyCopy()
+	for i := range folded.bucketGroup {
+		value := buckets[0][i]
+		for _, sketchBuckets := range buckets[1:] {
+			value = reducer(value, sketchBuckets[i])
+		}
+
+		folded.bucketGroup[i] = value
+	}
+
+	return folded, nil
+}
+
+// Merge folds another HyperLogLog into this one by keeping the larger value of each bucket,
+// giving the sketch of the union of both streams. Both need the same index bits, hash
+// width and seed
+func (hll *HyperLogLog) Merge(other HyperLogLog) error {
+	if err := hll.compatible(&other); err != nil {
+		return fmt.Errorf("cannot merge sketches: %w", err)
+	}
+
+	hll.lockAsync()
+	defer hll.unlockAsync()
+
+	hll.mergeExact(other.exact)
+	for i, bucket := range other.registers() {
+		hll.raiseRegister(uint32(i), int(bucket))
+	}
+
+	return nil
+}
+
+// minMergeChunk is the fewest buckets MergeAll hands a worker, so workers don't share cache lines
+const minMergeChunk = 64
+
+// MergeAll merges every sketch into a new one configured like the first, splitting the
+// buckets between GOMAXPROCS goroutines that each take the largest value of their share
+// across all of the sketches. They all need the same index bits, hash width and seed
+func MergeAll(sketches []HyperLogLog) (HyperLogLog, error) {
+	if len(sketches) == 0 {
+		return HyperLogLog{}, fmt.Errorf("need at least one sketch")
+	}
+
+	first := &sketches[0]
+	buckets := make([]bucketGroup, len(sketches))
+	for i := range sketches {
+		sketch := &sketches[i]
+		if err := first.compatible(sketch); err != nil {
+			return HyperLogLog{}, fmt.Errorf("cannot merge sketches: %w", err)
+		}
+
+		buckets[i] = sketch.registers()
+	}
+
+	merged := first.emptyCopy()
+	workers := max(min(runtime.GOMAXPROCS(0), len(merged.bucketGroup)/minMergeChunk), 1)
+	chunkSize := (len(merged.bucketGroup) + workers - 1) / workers
+
+	var wg sync.WaitGroup
+	for start := 0; start < len(merged.bucketGroup); start += chunkSize {
+		end := min(start+chunkSize, len(merged.bucketGroup))
+
+		wg.Add(1)
+		go func(chunk bucketGroup) {
+			defer wg.Done()
+			for _, sketchBuckets := range buckets {
+				for i, value := range sketchBuckets[start:end] {
+					chunk[i] = max(chunk[i], value)
+				}
+			}
+		}(merged.bucketGroup[start:end])
+	}
+	wg.Wait()
+
+	return merged, nil
+}
+
+// mergeExact keeps counting exactly after a merge if both sides still have every hash
+func (hll *HyperLogLog) mergeExact(other map[uint32]struct{}) {
+	if hll.exact == nil {
+		return
+	}
+
+	if other == nil {
+		hll.exact = nil
+		return
+	}
+
+	for h := range other {
+		hll.exact[h] = struct{}{}
+	}
+
+	if len(hll.exact) > hll.exactThreshold {
+		hll.exact = nil
+	}
+}
+
+// Compress folds the sketch down to fewer index bits, giving exactly the sketch that would
+// have been built with newIndexBits from the start, so sketches of different precisions can
+// be merged once the more precise ones are compressed. Buckets sharing their low newIndexBits
+// index bits combine, with the index bits dropped becoming the first bits of their zero runs.
+// Diagnostics kept per bucket are dropped, and it can't be used with WithAsyncEstimate or
+// WithRegisterStorage
+func (hll *HyperLogLog) Compress(newIndexBits uint32) error {
+	if newIndexBits > hll.indexBits {
+		return fmt.Errorf("cannot compress %d index bits up to %d index bits", hll.indexBits, newIndexBits)
+	}
+
+	if hll.async != nil {
+		return fmt.Errorf("cannot compress a sketch with a background estimate")
+	}
+
+	if hll.storage != nil {
+		return fmt.Errorf("cannot compress a sketch with register storage")
+	}
+
+	compressed, err := NewHyperLogLog(newIndexBits)
+	if err != nil {
+		return err
+	}
+
+	droppedBits := int(hll.indexBits - newIndexBits)
+	mask := uint32(compressed.mBuckets - 1)
+	for i, bucket := range hll.registers() {
+		if bucket == 0 {
+			continue
+		}
+
+		// While the dropped index bits are all zero the old run carries straight on from them
+		value := int(bucket) + droppedBits
+		if dropped := uint32(i) >> newIndexBits; dropped != 0 {
+			value = bits.TrailingZeros32(dropped) + 1
+		}
+
+		compressed.raiseRegister(uint32(i)&mask, value)
+	}
+
+	hll.constant = compressed.constant
+	hll.indexBits = compressed.indexBits
+	hll.mBuckets = compressed.mBuckets
+	hll.indexMask = compressed.indexMask
+	hll.bucketGroup = compressed.bucketGroup
+	hll.sparse = nil
+	hll.loads = nil
+	hll.timestamps = nil
+	hll.liveHistogram = nil
+	hll.cacheValid = false
+
+	if hll.hysteresis != nil {
+		hll.hysteresis.reset()
+	}
+
+	return nil
+}
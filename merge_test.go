@@ -0,0 +1,512 @@
+package pds
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestMergeUpsampled(t *testing.T) {
+	for _, n := range []int{100, 1000, 10000, 100000, 1000000} {
+		lower := filledSketch(t, 10, n)
+		want := filledSketch(t, 14, n)
+
+		hll, err := NewHyperLogLog(14)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := hll.MergeUpsampled(&lower); err != nil {
+			t.Fatal(err)
+		}
+
+		// Small counts land within about a factor of two, larger ones track the directly
+		// built sketch to about the lower sketch's own error
+		got, wanted := float64(hll.EstimateCardinality()), float64(want.EstimateCardinality())
+		tolerance := 0.1
+		if n < 10000 {
+			tolerance = 0.5
+		}
+
+		if got < wanted*(1-tolerance) || got > wanted*(1+tolerance) {
+			t.Errorf("upsampling %d items got %.0f, wanted about %.0f", n, got, wanted)
+		}
+	}
+}
+
+func TestMergeUpsampledBranches(t *testing.T) {
+	const extraBits = 4
+	for _, test := range []struct {
+		name   string
+		n      int
+		spread bool
+	}{
+		{name: "not spread", n: 200, spread: false},
+		{name: "spread", n: 100000, spread: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			lower := filledSketch(t, 10, test.n)
+			want := filledSketch(t, 10+extraBits, test.n)
+			hll := filledSketch(t, 10+extraBits, 0)
+
+			if err := hll.MergeUpsampled(&lower); err != nil {
+				t.Fatal(err)
+			}
+
+			// Without spreading each lower bucket raises one bucket here, with it those above
+			// the extra bits raise their whole group
+			var set, wantFilled int
+			for _, bucket := range lower.registers() {
+				switch {
+				case bucket == 0:
+				case test.spread && int(bucket) > extraBits:
+					set++
+					wantFilled += 1 << extraBits
+				default:
+					set++
+					wantFilled++
+				}
+			}
+
+			if spread := 5*set >= 2*int(lower.mBuckets); spread != test.spread {
+				t.Fatalf("got %d of %d lower buckets set, wanted the sketch spread %t", set, lower.mBuckets, test.spread)
+			}
+
+			var filled int
+			for _, bucket := range hll.registers() {
+				if bucket != 0 {
+					filled++
+				}
+			}
+
+			if filled != wantFilled {
+				t.Errorf("got %d buckets filled, wanted %d", filled, wantFilled)
+			}
+
+			got, wanted := float64(hll.EstimateCardinality()), float64(want.EstimateCardinality())
+			if got < wanted*0.9 || got > wanted*1.1 {
+				t.Errorf("upsampling %d items got %.0f, wanted about the %.0f of a sketch built at the higher precision", test.n, got, wanted)
+			}
+		})
+	}
+}
+
+func TestMergeUpsampledOnlyRaises(t *testing.T) {
+	lower := filledSketch(t, 10, 50000)
+	hll := filledSketch(t, 14, 200000)
+	before := hll.EstimateCardinality()
+
+	if err := hll.MergeUpsampled(&lower); err != nil {
+		t.Fatal(err)
+	}
+
+	if hll.EstimateCardinality() < before {
+		t.Fatalf("estimate fell from %d to %d", before, hll.EstimateCardinality())
+	}
+
+	same := filledSketch(t, 10, 5000)
+	copied, err := NewHyperLogLog(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := copied.MergeUpsampled(&same); err != nil {
+		t.Fatal(err)
+	}
+
+	if copied.EstimateCardinality() != same.EstimateCardinality() {
+		t.Fatalf("upsampling at the same precision got %d, wanted %d", copied.EstimateCardinality(), same.EstimateCardinality())
+	}
+
+	higher := filledSketch(t, 12, 100)
+	if err := lower.MergeUpsampled(&higher); err == nil {
+		t.Fatalf("upsampled 12 index bits into 10 index bits")
+	}
+}
+
+func TestMergeRegisterMaxima(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		options []Option
+		values  []uint8
+		wantErr bool
+	}{
+		{name: "in range", values: []uint8{1, 21}},
+		{name: "above 32 bit maximum", values: []uint8{1, 22}, wantErr: true},
+		{name: "above sparse value bits", values: []uint8{1, 200}, wantErr: true},
+		{name: "in range for 64 bit hash", options: []Option{With64BitHash()}, values: []uint8{1, 53}},
+		{name: "above 64 bit maximum", options: []Option{With64BitHash()}, values: []uint8{1, 54}, wantErr: true},
+		{name: "sparse in range", options: []Option{WithSparseRepresentation()}, values: []uint8{1, 21}},
+		{name: "sparse above maximum", options: []Option{WithSparseRepresentation()}, values: []uint8{1, 64}, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			hll, err := NewHyperLogLog(12, test.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = hll.MergeRegisterMaxima([]uint32{3, 7}, test.values)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, wanted error %t", err, test.wantErr)
+			}
+
+			if test.wantErr {
+				if hll.register(3) != 0 || hll.register(7) != 0 {
+					t.Fatalf("registers changed after an error")
+				}
+				return
+			}
+
+			if hll.register(3) != int(test.values[0]) || hll.register(7) != int(test.values[1]) {
+				t.Fatalf("got registers %d and %d, wanted %v", hll.register(3), hll.register(7), test.values)
+			}
+		})
+	}
+}
+
+func TestMergeRegisterMaximaOutOfRangeIndex(t *testing.T) {
+	hll, err := NewHyperLogLog(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := hll.MergeRegisterMaxima([]uint32{1, 16}, []uint8{1, 1}); err == nil {
+		t.Fatalf("wanted an error for bucket 16 of 16")
+	}
+
+	if err := hll.MergeRegisterMaxima([]uint32{1}, []uint8{1, 2}); err == nil {
+		t.Fatalf("wanted an error for mismatched lengths")
+	}
+}
+
+func TestMergeRegisterMaximaMatchesAdds(t *testing.T) {
+	added := filledSketch(t, 10, 20000)
+
+	// Aggregate each bucket's maximum the way a GROUP BY bucket query would, from the hashes
+	// of the same items
+	maxima := make(map[uint32]uint8)
+	for i := 0; i < 20000; i++ {
+		index, rest := added.splitBinary(added.hash(fmt.Sprintf("item-%d", i)))
+		maxima[index] = max(maxima[index], uint8(added.rank(rest)))
+	}
+
+	indices := make([]uint32, 0, len(maxima))
+	values := make([]uint8, 0, len(maxima))
+	for index, value := range maxima {
+		indices = append(indices, index)
+		values = append(values, value)
+	}
+
+	loaded, err := NewHyperLogLog(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loaded.MergeRegisterMaxima(indices, values); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(loaded.bucketGroup, added.bucketGroup) || loaded.EstimateCardinality() != added.EstimateCardinality() {
+		t.Fatalf("loaded maxima estimate %d, wanted the added sketch's %d", loaded.EstimateCardinality(), added.EstimateCardinality())
+	}
+}
+
+func TestEstimateUnionCardinality(t *testing.T) {
+	for _, options := range [][]Option{nil, {WithMLEstimator()}, {With64BitHash()}} {
+		// Overlapping sketches, so the union has to take the largest of each bucket
+		sketches := make([]HyperLogLog, 4)
+		pointers := make([]*HyperLogLog, len(sketches))
+		for i := range sketches {
+			sketches[i] = filledSketch(t, 12, 0, options...)
+			for j := 0; j < 3000; j++ {
+				sketches[i].Add(fmt.Sprintf("item-%d", 1000*i+j))
+			}
+			pointers[i] = &sketches[i]
+		}
+
+		before := slices.Clone(sketches[0].bucketGroup)
+
+		union, err := EstimateUnionCardinality(pointers)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		merged, err := MergeAll(sketches)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := merged.EstimateCardinality(); union != want {
+			t.Errorf("got union estimate %d, wanted the merged sketch's %d", union, want)
+		}
+
+		if !slices.Equal(sketches[0].bucketGroup, before) {
+			t.Errorf("estimating the union changed the first sketch")
+		}
+	}
+
+	if _, err := EstimateUnionCardinality(nil); err == nil {
+		t.Errorf("wanted an error for no sketches")
+	}
+
+	narrow, wider := filledSketch(t, 10, 100), filledSketch(t, 12, 100)
+	if _, err := EstimateUnionCardinality([]*HyperLogLog{&narrow, &wider}); err == nil {
+		t.Errorf("wanted an error for different index bits")
+	}
+}
+
+func benchmarkUnion(b *testing.B, count int) {
+	sketches := make([]*HyperLogLog, count)
+	for i := range sketches {
+		sketch := filledSketch(b, 14, 10000)
+		sketches[i] = &sketch
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := EstimateUnionCardinality(sketches); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEstimateUnionCardinality2(b *testing.B) {
+	benchmarkUnion(b, 2)
+}
+
+func BenchmarkEstimateUnionCardinality16(b *testing.B) {
+	benchmarkUnion(b, 16)
+}
+
+func TestFoldMaxIsUnion(t *testing.T) {
+	// Each sketch's items are a prefix of the largest's, so their union is the largest
+	a := filledSketch(t, 12, 5000)
+	b := filledSketch(t, 12, 20000)
+	c := filledSketch(t, 12, 100)
+
+	folded, err := Fold(func(x, y uint8) uint8 { return max(x, y) }, &a, &b, &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := MergeAll([]HyperLogLog{a, b, c})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !folded.Equal(&merged) || !folded.Equal(&b) {
+		t.Fatalf("folding with max differs from MergeAll")
+	}
+
+	union, err := EstimateUnionCardinality([]*HyperLogLog{&a, &b, &c})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if folded.EstimateCardinality() != union {
+		t.Fatalf("got %d folding with max, wanted the union estimate %d", folded.EstimateCardinality(), union)
+	}
+}
+
+func TestFoldMinTracksIntersection(t *testing.T) {
+	a := filledSketch(t, 12, 100000)
+	b, err := NewHyperLogLog(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 50000; i < 150000; i++ {
+		b.Add(fmt.Sprintf("item-%d", i))
+	}
+
+	folded, err := Fold(func(x, y uint8) uint8 { return min(x, y) }, &a, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, bucket := range folded.bucketGroup {
+		want := min(a.bucketGroup[i], b.bucketGroup[i])
+		if bucket != want {
+			t.Fatalf("bucket %d got %d, wanted the smaller side's %d", i, bucket, want)
+		}
+	}
+
+	// Min only stands in for the intersection, buckets the two sides fill from different
+	// items still count, so it lands above the 50000 shared items and below either side
+	estimate := folded.EstimateCardinality()
+	if estimate < 50000 || estimate > min(a.EstimateCardinality(), b.EstimateCardinality()) {
+		t.Fatalf("got %d folding with min, wanted between 50000 and either side", estimate)
+	}
+
+	// Folding a sketch with itself under min changes nothing
+	same, err := Fold(func(x, y uint8) uint8 { return min(x, y) }, &a, &a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !same.Equal(&a) {
+		t.Fatalf("folding a sketch with itself changed it")
+	}
+}
+
+func TestFoldRejectsMismatchedSketches(t *testing.T) {
+	narrow, wider := filledSketch(t, 10, 100), filledSketch(t, 12, 100)
+
+	if _, err := Fold(func(x, y uint8) uint8 { return max(x, y) }, &narrow, &wider); err == nil {
+		t.Fatalf("wanted an error folding different index bits")
+	}
+
+	if _, err := Fold(func(x, y uint8) uint8 { return max(x, y) }); err == nil {
+		t.Fatalf("wanted an error folding no sketches")
+	}
+}
+
+func TestMergeAllMatchesMerge(t *testing.T) {
+	for _, options := range [][]Option{nil, {With64BitHash()}} {
+		// Enough buckets to be split between several goroutines
+		sketches := make([]HyperLogLog, 8)
+		want := filledSketch(t, 16, 0, options...)
+		for i := range sketches {
+			sketches[i] = filledSketch(t, 16, 0, options...)
+			for j := 0; j < 5000; j++ {
+				sketches[i].Add(fmt.Sprintf("sketch-%d-%d", i, j))
+			}
+
+			if err := want.Merge(sketches[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		merged, err := MergeAll(sketches)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !merged.Equal(&want) {
+			t.Fatalf("MergeAll differs from merging the sketches one by one")
+		}
+	}
+
+	if _, err := MergeAll(nil); err == nil {
+		t.Fatalf("wanted an error merging no sketches")
+	}
+
+	narrow, wider := filledSketch(t, 10, 100), filledSketch(t, 12, 100)
+	if _, err := MergeAll([]HyperLogLog{narrow, wider}); err == nil {
+		t.Fatalf("wanted an error merging different index bits")
+	}
+}
+
+func TestCompressMatchesNativeSketch(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		options []Option
+	}{
+		{name: "32 bit"},
+		{name: "64 bit", options: []Option{With64BitHash()}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			for _, n := range []int{0, 100, 50000} {
+				compressed := filledSketch(t, 14, n, test.options...)
+				native := filledSketch(t, 10, n, test.options...)
+
+				if err := compressed.Compress(10); err != nil {
+					t.Fatal(err)
+				}
+
+				if !compressed.Equal(&native) {
+					t.Fatalf("compressing %d items from 14 to 10 index bits differs from a native 10 index bit sketch", n)
+				}
+
+				if compressed.EstimateCardinality() != native.EstimateCardinality() {
+					t.Fatalf("got estimate %d after compressing %d items, wanted %d", compressed.EstimateCardinality(), n, native.EstimateCardinality())
+				}
+			}
+		})
+	}
+
+	hll := filledSketch(t, 10, 10)
+	if err := hll.Compress(12); err == nil {
+		t.Fatalf("wanted an error compressing up to more index bits")
+	}
+}
+
+func TestMergePathsRejectDifferentHashWidths(t *testing.T) {
+	narrow := filledSketch(t, 10, 1000)
+	wide := filledSketch(t, 10, 1000, With64BitHash())
+
+	if err := narrow.Merge(wide); err == nil {
+		t.Errorf("Merge accepted a 64 bit sketch into a 32 bit one")
+	}
+
+	if err := narrow.MergeUpsampled(&wide); err == nil {
+		t.Errorf("MergeUpsampled accepted a 64 bit sketch into a 32 bit one")
+	}
+
+	if _, err := MergeAll([]HyperLogLog{narrow, wide}); err == nil {
+		t.Errorf("MergeAll accepted sketches of different hash widths")
+	}
+
+	if _, err := EstimateUnionCardinality([]*HyperLogLog{&narrow, &wide}); err == nil {
+		t.Errorf("EstimateUnionCardinality accepted sketches of different hash widths")
+	}
+
+	if _, err := Fold(func(a, b uint8) uint8 { return max(a, b) }, &narrow, &wide); err == nil {
+		t.Errorf("Fold accepted sketches of different hash widths")
+	}
+
+	if _, err := JaccardEstimate(narrow, wide); err == nil {
+		t.Errorf("JaccardEstimate accepted sketches of different hash widths")
+	}
+
+	if _, err := EstimateIntersection(narrow, wide); err == nil {
+		t.Errorf("EstimateIntersection accepted sketches of different hash widths")
+	}
+
+	if _, err := narrow.Containment(&wide); err == nil {
+		t.Errorf("Containment accepted sketches of different hash widths")
+	}
+
+	if _, err := narrow.ProbablySame(&wide, 0.1); err == nil {
+		t.Errorf("ProbablySame accepted sketches of different hash widths")
+	}
+
+	if narrow.Equal(&wide) {
+		t.Errorf("Equal matched sketches of different hash widths")
+	}
+}
+
+func TestMergePathsRejectDifferentSeeds(t *testing.T) {
+	a := filledSketch(t, 10, 1000, WithSeed(1))
+	b := filledSketch(t, 10, 1000, WithSeed(2))
+
+	if err := a.Merge(b); err == nil {
+		t.Errorf("Merge accepted a differently seeded sketch")
+	}
+
+	if err := a.MergeUpsampled(&b); err == nil {
+		t.Errorf("MergeUpsampled accepted a differently seeded sketch")
+	}
+
+	if _, err := MergeAll([]HyperLogLog{a, b}); err == nil {
+		t.Errorf("MergeAll accepted differently seeded sketches")
+	}
+
+	if _, err := EstimateUnionCardinality([]*HyperLogLog{&a, &b}); err == nil {
+		t.Errorf("EstimateUnionCardinality accepted differently seeded sketches")
+	}
+
+	if _, err := Fold(func(a, b uint8) uint8 { return max(a, b) }, &a, &b); err == nil {
+		t.Errorf("Fold accepted differently seeded sketches")
+	}
+
+	if _, err := JaccardEstimate(a, b); err == nil {
+		t.Errorf("JaccardEstimate accepted differently seeded sketches")
+	}
+
+	same := filledSketch(t, 10, 1000, WithSeed(1))
+	if err := a.Merge(same); err != nil {
+		t.Errorf("Merge rejected a sketch with the same seed: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package pds
+
+import (
+	"sync/atomic"
+)
+
+// MultisetCounter keeps an estimated distinct count and an exact total count of one stream
+type MultisetCounter struct {
+	hll   HyperLogLog
+	total atomic.Uint64
+}
+
+// NewMultisetCounter builds a new MultisetCounter
+func NewMultisetCounter(indexBits uint32, options ...Option) (*MultisetCounter, error) {
+	hll, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MultisetCounter{hll: hll}, nil
+}
+
+// Add counts an occurrence of s towards both the distinct and total counts
+func (mc *MultisetCounter) Add(s string) {
+	mc.hll.Add(s)
+	mc.total.Add(1)
+}
+
+// Distinct returns the estimated number of distinct items added
+func (mc *MultisetCounter) Distinct() int64 {
+	return mc.hll.EstimateCardinality()
+}
+
+// Total returns exactly how many items have been added
+func (mc *MultisetCounter) Total() uint64 {
+	return mc.total.Load()
+}
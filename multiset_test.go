@@ -0,0 +1,37 @@
+package pds
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMultisetCounter(t *testing.T) {
+	mc, err := NewMultisetCounter(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	standalone, err := NewHyperLogLog(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Item i turns up i%7+1 times
+	var total uint64
+	for i := 0; i < 5000; i++ {
+		for repeat := 0; repeat <= i%7; repeat++ {
+			key := fmt.Sprintf("item-%d", i)
+			mc.Add(key)
+			standalone.Add(key)
+			total++
+		}
+	}
+
+	if mc.Total() != total {
+		t.Fatalf("got total %d, wanted exactly %d", mc.Total(), total)
+	}
+
+	if mc.Distinct() != standalone.EstimateCardinality() {
+		t.Fatalf("got distinct %d, wanted the standalone estimate %d", mc.Distinct(), standalone.EstimateCardinality())
+	}
+}
@@ -0,0 +1,235 @@
+package pds
+
+import (
+	"io"
+	"time"
+)
+
+// Option configures a HyperLogLog when passed to NewHyperLogLog
+type Option func(*HyperLogLog)
+
+// WithMLEstimator makes EstimateCardinality use the maximum likelihood estimator instead of
+// the harmonic mean, see estimator.go for the extra cost involved
+func WithMLEstimator() Option {
+	return WithEstimator(MaximumLikelihood)
+}
+
+// WithEstimator sets the estimator EstimateCardinality uses, EstimateWith can still pick a
+// different one for a single call
+func WithEstimator(estimator Estimator) Option {
+	return func(hll *HyperLogLog) {
+		hll.estimator = estimator
+	}
+}
+
+// WithHasher replaces the default FNV-1a hashing
+func WithHasher(hasher Hasher) Option {
+	return func(hll *HyperLogLog) {
+		hll.hasher = hasher
+	}
+}
+
+// WithHash replaces the default FNV-1a hashing with a plain 64 bit hash function, eg. xxHash,
+// murmur3 or a keyed hash to stand up to adversarial keys. See HashFunc for how it is turned
+// into 32 bit and seeded hashes
+func WithHash(hash func([]byte) uint64) Option {
+	return func(hll *HyperLogLog) {
+		hll.hasher = HashFunc(hash)
+	}
+}
+
+// WithSeed seeds the hasher, so separately seeded sketches of the same stream make
+// independent estimates, eg. for measuring their spread, while the same seed always gives the
+// same buckets. Sketches only merge with others using the same seed, and the seed is kept by
+// the binary, JSON, gob, chunked, debug and protobuf encodings
+func WithSeed(seed uint64) Option {
+	return func(hll *HyperLogLog) {
+		hll.seed = seed
+
+		// FNV-1a's low bits, which pick the bucket, only depend on the low bits of its seed,
+		// so every bit of the seed is spread across it. A seed of 0 stays 0
+		hll.hashSeed = mix64(seed)
+	}
+}
+
+// WithSubscribeDelta sets how far the estimate has to move, relative to the last estimate sent,
+// before subscribers are sent a new one
+func WithSubscribeDelta(delta float64) Option {
+	return func(hll *HyperLogLog) {
+		hll.subscribeDelta = delta
+	}
+}
+
+// WithBinaryVersion pins the format version MarshalBinary and GobEncode write, so sketches can
+// be read by older releases while UnmarshalBinary still reads every version. Version 1 has no
+// flags byte and can't describe 64 bit hashes or seeds, so marshalling a sketch using either
+// fails, as does marshalling with a version this package doesn't know
+func WithBinaryVersion(version byte) Option {
+	return func(hll *HyperLogLog) {
+		hll.pinnedVersion = version
+	}
+}
+
+// WithKeyNormalizer runs every string key through normalizer before it is hashed, eg.
+// strings.ToLower so "Foo" and "foo" count once. The normalizer must be deterministic or
+// the same key can be counted more than once
+func WithKeyNormalizer(normalizer func(string) string) Option {
+	return func(hll *HyperLogLog) {
+		hll.normalizer = normalizer
+	}
+}
+
+// WithEstimateHistory keeps the last size estimates returned by EstimateCardinality so
+// AnomalyScore can spot sudden changes
+func WithEstimateHistory(size int) Option {
+	return func(hll *HyperLogLog) {
+		if size > 0 {
+			hll.history = &estimateHistory{size: size}
+		}
+	}
+}
+
+// WithBucketLoadTracking counts the distinct hashes landing in each bucket for HotBuckets.
+// This keeps every distinct hash in memory so is only meant for diagnosing accuracy problems
+func WithBucketLoadTracking() Option {
+	return func(hll *HyperLogLog) {
+		hll.loads = &bucketLoads{
+			seen:   make(map[uint64]struct{}),
+			counts: make([]int64, hll.mBuckets),
+		}
+	}
+}
+
+// WithAsyncEstimate recomputes the estimate every interval on a background goroutine so Count
+// can return it straight away. Adds, merges, decoding and Reset are synchronised with the
+// recompute, which reads the sketch they were last called on, so the sketch shouldn't be
+// copied while it is running. Close stops it
+func WithAsyncEstimate(interval time.Duration) Option {
+	return func(hll *HyperLogLog) {
+		if interval > 0 {
+			hll.async = &asyncEstimate{interval: interval}
+		}
+	}
+}
+
+// WithExactThreshold keeps every distinct hash until there are more than threshold of them,
+// so tiny sets are counted exactly before falling back to the estimate
+func WithExactThreshold(threshold int) Option {
+	return func(hll *HyperLogLog) {
+		if threshold > 0 {
+			hll.exact = make(map[uint32]struct{}, threshold)
+			hll.exactThreshold = threshold
+		}
+	}
+}
+
+// WithUniverseCap stops the estimate going above n when there can't be more than n distinct
+// items, eg. the number of registered users. It only clamps the top of the estimate
+func WithUniverseCap(n int64) Option {
+	return func(hll *HyperLogLog) {
+		hll.universeCap = n
+	}
+}
+
+// WithInputLog writes every key added to w so it can be replayed into a fresh sketch with
+// Replay later. This writes out every single key, so is only meant for debugging
+func WithInputLog(w io.Writer) Option {
+	return func(hll *HyperLogLog) {
+		hll.inputLog = &inputLog{w: w}
+	}
+}
+
+// WithParallelEstimate splits the harmonic sum behind the estimate across workers goroutines
+// for sketches with at least 2^14 buckets, smaller sketches aren't worth the goroutines
+func WithParallelEstimate(workers int) Option {
+	return func(hll *HyperLogLog) {
+		hll.estimateWorkers = workers
+	}
+}
+
+// WithRegisterTimestamps records when each bucket was last raised for RegisterAges, taking the
+// time from now or time.Now if it is nil. This keeps a time.Time per bucket, many times the
+// size of the buckets themselves
+func WithRegisterTimestamps(now func() time.Time) Option {
+	return func(hll *HyperLogLog) {
+		if now == nil {
+			now = time.Now
+		}
+
+		hll.timestamps = &registerTimestamps{
+			now:      now,
+			raisedAt: make([]time.Time, hll.mBuckets),
+		}
+	}
+}
+
+// WithCorrectionHysteresis sticks with the last corrections used until the raw estimate is 2%
+// clear of the small range threshold, or of the large range one with 32 bit hashes, so
+// sketches sat right on either give steady estimates
+func WithCorrectionHysteresis() Option {
+	return func(hll *HyperLogLog) {
+		hll.hysteresis = &correctionHysteresis{}
+	}
+}
+
+// WithSaturationExtrapolation keeps estimating once enough buckets are pinned at the top of
+// their range by fitting the buckets that aren't, rather than flattening out. This is a
+// heuristic for a rough number past the range of 32 bit hashes, check CurrentError before
+// trusting it. It only replaces the HarmonicMean estimator, the others have their own
+// handling of full buckets
+func WithSaturationExtrapolation() Option {
+	return func(hll *HyperLogLog) {
+		hll.extrapolate = true
+	}
+}
+
+// WithSparseRepresentation starts the sketch off keeping only its non empty buckets, as
+// varint encoded pairs, and turns it dense once they take more than a quarter of a byte per
+// bucket. Small sketches take a fraction of the memory but adds are slower while sparse.
+// It has no effect alongside WithAsyncEstimate
+func WithSparseRepresentation() Option {
+	return func(hll *HyperLogLog) {
+		hll.sparse = &sparseRegisters{}
+		hll.bucketGroup = nil
+	}
+}
+
+// WithBiasCorrection corrects the estimate with the HyperLogLog++ empirical bias tables,
+// which are much more accurate between 2.5m and 5m where the plain estimate overshoots. It
+// takes the place of WithCorrectionHysteresis if both are given
+func WithBiasCorrection() Option {
+	return func(hll *HyperLogLog) {
+		hll.biasCorrect = true
+	}
+}
+
+// With64BitHash hashes keys into 64 bits with the hasher's Hash64, leaving 64 minus the index
+// bits to count zeros so estimates stay accurate well past the few billion items 32 bit
+// hashes top out at. Sketches only merge with others hashed the same way, and the hash width
+// is kept by the binary, JSON, gob, chunked, debug and protobuf encodings
+func With64BitHash() Option {
+	return func(hll *HyperLogLog) {
+		hll.wideHash = true
+	}
+}
+
+// WithRegisterStorage keeps the buckets in storage instead of on the heap, eg. a region of a
+// memory mapped file so many sketches can live outside the Go heap and survive restarts.
+// storage needs a byte for each bucket and anything already in it is picked up as the
+// sketch's buckets, so a zeroed region starts empty. Flush passes the buckets to flush to
+// persist them, eg. with msync, and flush can be nil when there's nothing to do. It takes the
+// place of WithSparseRepresentation, Compress can't be used on the sketch and decoding into
+// it writes the decoded buckets to storage
+func WithRegisterStorage(storage []byte, flush func([]byte) error) Option {
+	return func(hll *HyperLogLog) {
+		hll.storage = storage
+		hll.flushStorage = flush
+	}
+}
+
+// WithLogLogBeta makes EstimateCardinality use the LogLog-Beta estimator, which avoids the
+// jumps the harmonic mean has where it switches between corrections. Its polynomial is fitted
+// to simulated sketches by betadata_gen.go
+func WithLogLogBeta() Option {
+	return WithEstimator(LogLogBeta)
+}
@@ -0,0 +1,38 @@
+package pds
+
+import (
+	"sync"
+)
+
+// minParallelBuckets is the fewest buckets worth splitting the harmonic sum across goroutines for
+const minParallelBuckets = 1 << 14
+
+// parallelHarmonicSum works out the same sums as harmonicSum with the buckets split between
+// workers goroutines. Every term is a power of two no smaller than 2^-33 and there
+// are at most 2^16 of them, so the sums are exact and come out the same in any order
+func (bg bucketGroup) parallelHarmonicSum(workers int) (float64, float64) {
+	chunkSize := (len(bg) + workers - 1) / workers
+	totals := make([]float64, workers)
+	zeros := make([]float64, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		start := min(w*chunkSize, len(bg))
+		end := min(start+chunkSize, len(bg))
+
+		wg.Add(1)
+		go func(w int, chunk bucketGroup) {
+			defer wg.Done()
+			totals[w], zeros[w] = chunk.harmonicSum(1)
+		}(w, bg[start:end])
+	}
+	wg.Wait()
+
+	var total, zero float64
+	for w := range totals {
+		total += totals[w]
+		zero += zeros[w]
+	}
+
+	return total, zero
+}
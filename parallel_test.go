@@ -0,0 +1,47 @@
+package pds
+
+import (
+	"testing"
+)
+
+func TestParallelEstimateMatchesSerial(t *testing.T) {
+	for _, indexBits := range []uint32{14, 16} {
+		for _, options := range [][]Option{nil, {With64BitHash()}} {
+			serial := filledSketch(t, indexBits, 300000, options...)
+
+			for _, workers := range []int{2, 3, 8} {
+				parallel := filledSketch(t, indexBits, 300000, append(options, WithParallelEstimate(workers))...)
+
+				if parallel.EstimateCardinality() != serial.EstimateCardinality() {
+					t.Errorf("%d index bits with %d workers got %d, wanted the serial %d", indexBits, workers, parallel.EstimateCardinality(), serial.EstimateCardinality())
+				}
+			}
+		}
+	}
+
+	// Below the minimum the option is ignored rather than paying for goroutines
+	small := filledSketch(t, 12, 10000, WithParallelEstimate(4))
+	plain := filledSketch(t, 12, 10000)
+	if small.EstimateCardinality() != plain.EstimateCardinality() {
+		t.Errorf("got %d for a small sketch, wanted %d", small.EstimateCardinality(), plain.EstimateCardinality())
+	}
+}
+
+// benchmarkEstimate works out the full estimate of a sketch with 2^16 buckets every time. The
+// parallel sum only comes out ahead with GOMAXPROCS above 1
+func benchmarkEstimate(b *testing.B, options ...Option) {
+	hll := filledSketch(b, 16, 1000000, options...)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hll.EstimateWith(HarmonicMean)
+	}
+}
+
+func BenchmarkEstimateSerial16(b *testing.B) {
+	benchmarkEstimate(b)
+}
+
+func BenchmarkEstimateParallel16(b *testing.B) {
+	benchmarkEstimate(b, WithParallelEstimate(4))
+}
@@ -0,0 +1,94 @@
+package pds
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Pool hands out reusable HyperLogLogs that all share the same index bits and options
+type Pool struct {
+	template HyperLogLog
+	pool     sync.Pool
+}
+
+// NewPool builds a new Pool of HyperLogLogs built with the given index bits and options.
+// WithAsyncEstimate can't be used, as the pool drops sketches without closing them and their
+// background goroutines would run forever, and neither can WithInputLog, as every sketch the
+// pool hands out would log to the same writer
+func NewPool(indexBits uint32, options ...Option) (*Pool, error) {
+	hll, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if hll.async != nil {
+		hll.Close()
+		return nil, fmt.Errorf("pooled sketches cannot have a background estimate")
+	}
+
+	if hll.inputLog != nil {
+		return nil, fmt.Errorf("pooled sketches cannot log their input")
+	}
+
+	return &Pool{
+		template: hll,
+		pool: sync.Pool{
+			New: func() any {
+				hll, _ := NewHyperLogLog(indexBits, options...)
+				return &hll
+			},
+		},
+	}, nil
+}
+
+// Get returns an empty HyperLogLog from the pool
+func (p *Pool) Get() *HyperLogLog {
+	return p.pool.Get().(*HyperLogLog)
+}
+
+// Put empties the HyperLogLog and returns it to the pool. Sketches whose buckets don't line up
+// with the pool's, that were built with other options or that still have subscribers are
+// rejected and left as they are
+func (p *Pool) Put(hll *HyperLogLog) error {
+	if err := p.template.compatible(hll); err != nil {
+		return fmt.Errorf("cannot put into the pool: %w", err)
+	}
+
+	if !p.template.sameOptions(hll) {
+		return fmt.Errorf("cannot put a sketch built with other options into the pool")
+	}
+
+	if len(hll.subscribers) > 0 {
+		return fmt.Errorf("cannot put a sketch with subscribers into the pool, unsubscribe them first")
+	}
+
+	hll.Reset()
+	p.pool.Put(hll)
+
+	return nil
+}
+
+// sameOptions reports whether other looks built with the same options as the sketch, going by
+// the settings and optional parts the options leave behind. Hashers only need the same type
+// as functions can't be compared, and the sparse representation is left out as sketches leave
+// it once they fill up
+func (hll *HyperLogLog) sameOptions(other *HyperLogLog) bool {
+	return hll.estimator == other.estimator &&
+		reflect.TypeOf(hll.hasher) == reflect.TypeOf(other.hasher) &&
+		(hll.normalizer == nil) == (other.normalizer == nil) &&
+		(hll.history == nil) == (other.history == nil) &&
+		(hll.loads == nil) == (other.loads == nil) &&
+		(hll.async == nil) == (other.async == nil) &&
+		(hll.inputLog == nil) == (other.inputLog == nil) &&
+		(hll.timestamps == nil) == (other.timestamps == nil) &&
+		(hll.hysteresis == nil) == (other.hysteresis == nil) &&
+		(hll.storage == nil) == (other.storage == nil) &&
+		hll.universeCap == other.universeCap &&
+		hll.extrapolate == other.extrapolate &&
+		hll.biasCorrect == other.biasCorrect &&
+		hll.estimateWorkers == other.estimateWorkers &&
+		hll.exactThreshold == other.exactThreshold &&
+		hll.pinnedVersion == other.pinnedVersion &&
+		hll.subscribeDelta == other.subscribeDelta
+}
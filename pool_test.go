@@ -0,0 +1,102 @@
+package pds
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestPoolHandsOutEmptySketches(t *testing.T) {
+	pool, err := NewPool(10, WithExactThreshold(50))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for cycle := 0; cycle < 200; cycle++ {
+		hll := pool.Get()
+		if hll.EstimateCardinality() != 0 || hll.bucketGroup.countZeroBuckets() != hll.bucketGroup.Len() {
+			t.Fatalf("cycle %d got a sketch with estimate %d", cycle, hll.EstimateCardinality())
+		}
+
+		if hll.indexBits != 10 {
+			t.Fatalf("cycle %d got %d index bits, wanted 10", cycle, hll.indexBits)
+		}
+
+		for i := 0; i < 100*(cycle%5); i++ {
+			hll.Add(fmt.Sprintf("cycle-%d-item-%d", cycle, i))
+		}
+
+		if err := pool.Put(hll); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Reset puts exact counting back too, so a reused sketch counts small sets exactly again
+	hll := pool.Get()
+	hll.Add("one")
+	if _, exact := hll.ToExactSet(); !exact {
+		t.Fatalf("reused sketch stopped counting exactly")
+	}
+}
+
+func TestPoolRejectsMismatchedSketch(t *testing.T) {
+	pool, err := NewPool(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := filledSketch(t, 12, 100)
+	if err := pool.Put(&other); err == nil {
+		t.Fatalf("put a 12 index bit sketch into a pool of 10 index bits")
+	}
+
+	if other.EstimateCardinality() == 0 {
+		t.Fatalf("a rejected sketch was still reset")
+	}
+
+	for name, options := range map[string][]Option{
+		"64 bit hashes": {With64BitHash()},
+		"seed":          {WithSeed(1)},
+		"estimator":     {WithMLEstimator()},
+		"exact counts":  {WithExactThreshold(10)},
+		"hasher":        {WithHash(func(data []byte) uint64 { return fnv64(data, 0) })},
+	} {
+		other := filledSketch(t, 10, 100, options...)
+		if err := pool.Put(&other); err == nil {
+			t.Errorf("put a sketch with different %s into the pool", name)
+		}
+	}
+
+	if _, err := NewPool(3); err == nil {
+		t.Fatalf("wanted an error for 3 index bits")
+	}
+}
+
+func TestPoolRejectsAsyncEstimate(t *testing.T) {
+	if _, err := NewPool(10, WithAsyncEstimate(time.Millisecond)); err == nil {
+		t.Fatalf("wanted an error pooling sketches with a background estimate")
+	}
+
+	if _, err := NewPool(10, WithInputLog(io.Discard)); err == nil {
+		t.Fatalf("wanted an error pooling sketches that log their input")
+	}
+}
+
+func TestPoolRejectsSubscribedSketch(t *testing.T) {
+	pool, err := NewPool(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hll := pool.Get()
+	ch := hll.Subscribe()
+	if err := pool.Put(hll); err == nil {
+		t.Fatalf("put a sketch with a subscriber into the pool")
+	}
+
+	hll.Unsubscribe(ch)
+	if err := pool.Put(hll); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,87 @@
+package pds
+
+import (
+	"math"
+	"math/rand"
+)
+
+// privacySensitivity is how far adding or removing one item can move the bucket histogram.
+// The item lands in one bucket, which moves from one value to another, taking 1 from one
+// count and adding 1 to another whatever the sketch already holds
+const privacySensitivity = 2
+
+// PrivateCount returns an epsilon differentially private estimate: adding or removing any one
+// item changes the chance of any result by at most a factor of e^epsilon. Laplace noise scaled
+// to the histogram's global sensitivity over epsilon is added to each count of the bucket
+// histogram, which is then worked into an estimate with the configured estimator. That is
+// only post processing of the noisy histogram so keeps the guarantee, but means the noise on
+// the estimate grows with the number of values a bucket can take rather than being Laplace
+// itself. Exact counting is never used, as whether the sketch still counts exactly depends on
+// the items. It panics if epsilon isn't above 0
+func (hll *HyperLogLog) PrivateCount(epsilon float64, rng *rand.Rand) int64 {
+	if epsilon <= 0 {
+		panic("pds: PrivateCount needs an epsilon above 0")
+	}
+
+	counts := noisyHistogram(hll.histogram(), int(hll.mBuckets), privacySensitivity/epsilon, rng)
+
+	var total float64
+	for k, count := range counts {
+		total += math.Ldexp(float64(count), -k)
+	}
+
+	return max(hll.estimateFrom(hll.estimator, total, float64(counts[0]), counts, nil), 0)
+}
+
+// privacyFloor is how many times the noise scale a noisy count needs to reach to be kept.
+// Nearly every count under it is a value no bucket holds pushed up by the noise, and even a
+// few buckets wrongly at a low value would pull the estimate down
+const privacyFloor = 3
+
+// noisyHistogram adds Laplace noise of the given scale to every count and drops those under
+// the floor, then makes the counts sum to the number of buckets again by taking from or giving
+// to the largest, so the estimators see a histogram some sketch could have
+func noisyHistogram(counts []int, totalBuckets int, scale float64, rng *rand.Rand) []int {
+	noisy := make([]int, len(counts))
+	var sum int
+	for k, count := range counts {
+		noisy[k] = int(math.Round(float64(count) + laplaceNoise(scale, rng)))
+		if float64(noisy[k]) < privacyFloor*scale {
+			noisy[k] = 0
+		}
+		sum += noisy[k]
+	}
+
+	for sum != totalBuckets {
+		largest := 0
+		for k, count := range noisy {
+			if count > noisy[largest] {
+				largest = k
+			}
+		}
+
+		if sum < totalBuckets {
+			noisy[largest] += totalBuckets - sum
+			break
+		}
+
+		taken := min(noisy[largest], sum-totalBuckets)
+		noisy[largest] -= taken
+		sum -= taken
+	}
+
+	return noisy
+}
+
+// laplaceNoise draws from the Laplace distribution with a mean of 0 by inverse transform
+// sampling, with u kept off -0.5 where the log would be of 0
+func laplaceNoise(scale float64, rng *rand.Rand) float64 {
+	f := rng.Float64()
+	for f == 0 {
+		f = rng.Float64()
+	}
+
+	u := f - 0.5
+
+	return -scale * math.Copysign(1, u) * math.Log(1-2*math.Abs(u))
+}
@@ -0,0 +1,99 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestPrivateCountNoise(t *testing.T) {
+	for _, options := range [][]Option{nil, {WithMLEstimator()}} {
+		hll := filledSketch(t, 10, 20000, options...)
+		estimate := float64(hll.EstimateCardinality())
+
+		const draws = 500
+		rng := rand.New(rand.NewSource(1))
+
+		var sum float64
+		var changed int
+		for i := 0; i < draws; i++ {
+			count := hll.PrivateCount(1, rng)
+			if float64(count) != estimate {
+				changed++
+			}
+
+			sum += float64(count)
+		}
+
+		if changed < draws/2 {
+			t.Errorf("got %d of %d counts differing from the estimate, wanted most of them noisy", changed, draws)
+		}
+
+		if mean := sum / draws; math.Abs(mean-estimate)/estimate > 0.01 {
+			t.Errorf("got mean count %f, wanted about the estimate %f", mean, estimate)
+		}
+	}
+}
+
+func TestPrivateCountSensitivity(t *testing.T) {
+	// Every item, new or not and however full the sketch, moves the histogram by at most the
+	// sensitivity the noise is scaled to
+	hll := filledSketch(t, 6, 0)
+	previous := hll.histogram()
+	for i := 0; i < 5000; i++ {
+		hll.Add(fmt.Sprintf("item-%d", i%3000))
+		current := hll.histogram()
+
+		var moved int
+		for k := range current {
+			moved += max(current[k]-previous[k], previous[k]-current[k])
+		}
+
+		if moved > privacySensitivity {
+			t.Fatalf("got the histogram moving by %d after item %d, wanted at most %d", moved, i, privacySensitivity)
+		}
+
+		previous = current
+	}
+}
+
+func TestNoisyHistogramIsAHistogram(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, scale := range []float64{0.1, 2, 200} {
+		noisy := noisyHistogram([]int{200, 40, 10, 5, 1, 0}, 256, scale, rng)
+
+		var sum int
+		for _, count := range noisy {
+			if count < 0 {
+				t.Fatalf("got negative count %d at scale %f", count, scale)
+			}
+			sum += count
+		}
+
+		if sum != 256 {
+			t.Fatalf("got counts summing to %d at scale %f, wanted 256", sum, scale)
+		}
+	}
+}
+
+func TestPrivateCountClampsAtZero(t *testing.T) {
+	hll, err := NewHyperLogLog(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		if count := hll.PrivateCount(0.1, rng); count < 0 {
+			t.Fatalf("got negative count %d", count)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("wanted a panic for an epsilon of 0")
+		}
+	}()
+	hll.PrivateCount(0, rng)
+}
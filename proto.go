@@ -0,0 +1,140 @@
+package pds
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// protoVersion is the version field written by ToProto
+const protoVersion = 1
+
+// Field numbers of the HyperLogLog message in hyperloglog.proto
+const (
+	protoVersionField   = 1
+	protoIndexBitsField = 2
+	protoWideHashField  = 3
+	protoRegistersField = 4
+	protoSeedField      = 5
+)
+
+// Protobuf wire types used by the HyperLogLog message, plus the fixed width ones that need
+// skipping when reading messages from newer layouts
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// appendProtoTag appends the key of a field
+func appendProtoTag(data []byte, field int, wireType int) []byte {
+	return binary.AppendUvarint(data, uint64(field<<3|wireType))
+}
+
+// ToProto writes the HyperLogLog as the HyperLogLog message of hyperloglog.proto, so it can
+// be embedded in other protobuf messages through the generated type or a bytes field
+func (hll *HyperLogLog) ToProto() ([]byte, error) {
+	buckets := hll.registers()
+	data := make([]byte, 0, 32+len(buckets))
+
+	data = appendProtoTag(data, protoVersionField, protoVarint)
+	data = binary.AppendUvarint(data, protoVersion)
+	data = appendProtoTag(data, protoIndexBitsField, protoVarint)
+	data = binary.AppendUvarint(data, uint64(hll.indexBits))
+
+	if hll.wideHash {
+		data = appendProtoTag(data, protoWideHashField, protoVarint)
+		data = binary.AppendUvarint(data, 1)
+	}
+
+	data = appendProtoTag(data, protoRegistersField, protoBytes)
+	data = binary.AppendUvarint(data, uint64(len(buckets)))
+	data = append(data, buckets...)
+
+	if hll.seed != 0 {
+		data = appendProtoTag(data, protoSeedField, protoVarint)
+		data = binary.AppendUvarint(data, hll.seed)
+	}
+
+	return data, nil
+}
+
+// FromProto loads a sketch from a HyperLogLog message like UnmarshalBinary, skipping any
+// fields it doesn't know
+func (hll *HyperLogLog) FromProto(data []byte) error {
+	var version, indexBits, seed uint64
+	var wideHash bool
+	var registers []byte
+
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("malformed protobuf field key")
+		}
+		data = data[n:]
+
+		field, wireType := key>>3, key&7
+		switch wireType {
+		case protoVarint:
+			value, n := binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("malformed protobuf varint in field %d", field)
+			}
+			data = data[n:]
+
+			switch field {
+			case protoVersionField:
+				version = value
+			case protoIndexBitsField:
+				indexBits = value
+			case protoWideHashField:
+				wideHash = value != 0
+			case protoSeedField:
+				seed = value
+			}
+		case protoBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return fmt.Errorf("malformed protobuf bytes in field %d", field)
+			}
+			data = data[n:]
+
+			if field == protoRegistersField {
+				registers = data[:length]
+			}
+			data = data[length:]
+		case protoFixed64, protoFixed32:
+			width := 8
+			if wireType == protoFixed32 {
+				width = 4
+			}
+
+			if len(data) < width {
+				return fmt.Errorf("malformed protobuf fixed field %d", field)
+			}
+			data = data[width:]
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d in field %d", wireType, field)
+		}
+	}
+
+	if version != protoVersion {
+		return fmt.Errorf("%w %d", ErrUnsupportedVersion, version)
+	}
+
+	if indexBits > 32 {
+		return fmt.Errorf("index bits %d out of range", indexBits)
+	}
+
+	options := []Option{WithSeed(seed)}
+	if wideHash {
+		options = append(options, With64BitHash())
+	}
+
+	decoded, err := decodeBuckets(uint32(indexBits), registers, options...)
+	if err != nil {
+		return err
+	}
+
+	return hll.load(&decoded)
+}
@@ -0,0 +1,90 @@
+package pds
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestProtoRoundTrip(t *testing.T) {
+	for _, test := range encodingTests {
+		t.Run(test.name, func(t *testing.T) {
+			hll := filledSketch(t, 10, 5000, test.options...)
+
+			data, err := hll.ToProto()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded HyperLogLog
+			if err := decoded.FromProto(data); err != nil {
+				t.Fatal(err)
+			}
+
+			assertSameSketch(t, &decoded, &hll)
+		})
+	}
+}
+
+func TestFromProtoSkipsUnknownFields(t *testing.T) {
+	hll := filledSketch(t, 10, 100)
+	data, err := hll.ToProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A varint, fixed64, bytes and fixed32 field from some newer layout
+	extra := appendProtoTag(nil, 20, protoVarint)
+	extra = binary.AppendUvarint(extra, 300)
+	extra = appendProtoTag(extra, 21, protoFixed64)
+	extra = binary.LittleEndian.AppendUint64(extra, 1)
+	extra = appendProtoTag(extra, 22, protoBytes)
+	extra = binary.AppendUvarint(extra, 3)
+	extra = append(extra, "new"...)
+	extra = appendProtoTag(extra, 23, protoFixed32)
+	extra = binary.LittleEndian.AppendUint32(extra, 1)
+
+	var decoded HyperLogLog
+	if err := decoded.FromProto(append(extra, data...)); err != nil {
+		t.Fatal(err)
+	}
+
+	assertSameSketch(t, &decoded, &hll)
+}
+
+func TestFromProtoRejectsMalformedData(t *testing.T) {
+	hll := filledSketch(t, 4, 10)
+	data, err := hll.ToProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// message builds a message of the version and index bits followed by the registers
+	message := func(version, indexBits uint64, registers []byte) []byte {
+		data := appendProtoTag(nil, protoVersionField, protoVarint)
+		data = binary.AppendUvarint(data, version)
+		data = appendProtoTag(data, protoIndexBitsField, protoVarint)
+		data = binary.AppendUvarint(data, indexBits)
+		data = appendProtoTag(data, protoRegistersField, protoBytes)
+		data = binary.AppendUvarint(data, uint64(len(registers)))
+
+		return append(data, registers...)
+	}
+
+	for name, bad := range map[string][]byte{
+		"truncated registers": data[:len(data)-1],
+		"truncated key":       {0x80},
+		"truncated varint":    append(appendProtoTag(nil, protoIndexBitsField, protoVarint), 0x80),
+		"truncated fixed":     append(appendProtoTag(nil, 21, protoFixed64), 1, 2, 3),
+		"unknown wire type":   appendProtoTag(nil, 21, 3),
+		"missing version":     message(0, 4, make([]byte, 16))[2:],
+		"newer version":       message(protoVersion+1, 4, make([]byte, 16)),
+		"index bits":          message(protoVersion, 40, make([]byte, 16)),
+		"register count":      message(protoVersion, 4, make([]byte, 15)),
+		"register value":      message(protoVersion, 4, append(make([]byte, 15), 64)),
+	} {
+		var decoded HyperLogLog
+		if err := decoded.FromProto(bad); err == nil {
+			t.Errorf("decoded protobuf with a bad %s", name)
+		}
+	}
+}
@@ -0,0 +1,502 @@
+package pds
+
+import (
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"slices"
+)
+
+// Every quotient filter slot keeps three flags below its remainder
+const (
+	qfOccupied     = 1 << 0
+	qfContinuation = 1 << 1
+	qfShifted      = 1 << 2
+	qfFlagBits     = 3
+)
+
+// qfMaxLoad is how full a quotient filter gets before Add grows it
+const qfMaxLoad = 0.9
+
+// quotientBinaryVersion is the version byte written at the front of QuotientFilter.MarshalBinary
+// output
+const quotientBinaryVersion = 1
+
+// quotientHeaderSize is the version byte, the quotient and remainder bits, the count and the
+// seed
+const quotientHeaderSize = 19
+
+// qfEntry is a fingerprint split into its quotient, counted from the start of the region it
+// was read from, and its remainder
+type qfEntry struct {
+	quotient  uint64
+	remainder uint64
+}
+
+// QuotientFilter tests whether items might have been added like a BloomFilter, following
+// Bender et al.'s "Don't Thrash: How to Cache Your Hash on Flash". Each item's fingerprint is
+// split into a quotient picking its slot and a remainder stored there, with every slot's
+// remainder and three flags packed into one table. Items with the same quotient are kept as a
+// sorted run, pushed along into later slots when their own is taken, so lookups scan a
+// short stretch of adjacent slots. As the fingerprints themselves are kept the filter can
+// delete items, resize by moving bits between quotient and remainder, and merge with others
+// of the same fingerprint size, all without the original items
+type QuotientFilter struct {
+	table         []uint64
+	quotientBits  uint
+	remainderBits uint
+	count         uint64
+	keys          keyHasher
+
+	// Scratch space for reading regions, kept between calls
+	quotients []uint64
+	entries   []qfEntry
+}
+
+// NewQuotientFilter builds a new QuotientFilter of 2^quotientBits slots keeping remainderBits
+// bits of each fingerprint, for a false positive rate of about 2^-remainderBits while the
+// filter is not too full. Growing moves a bit from the remainder to the quotient each time.
+// Options set how keys are hashed
+func NewQuotientFilter(quotientBits uint, remainderBits uint, options ...KeyOption) (*QuotientFilter, error) {
+	if quotientBits < 1 || quotientBits > 40 {
+		return nil, fmt.Errorf("quotient bits need to be in interval 1>=x>=40")
+	}
+
+	if remainderBits < 1 || quotientBits+remainderBits > 64 || remainderBits+qfFlagBits > 64 {
+		return nil, fmt.Errorf("cannot keep %d bit remainders with %d bit quotients", remainderBits, quotientBits)
+	}
+
+	qf := newQuotientFilter(quotientBits, remainderBits)
+	qf.keys = newKeyHasher(options)
+
+	return qf, nil
+}
+
+// newQuotientFilter builds an empty QuotientFilter, with one spare word so slots can always be
+// read as a pair of words
+func newQuotientFilter(quotientBits uint, remainderBits uint) *QuotientFilter {
+	slotBits := uint64(remainderBits + qfFlagBits)
+
+	return &QuotientFilter{
+		table:         make([]uint64, (slotBits<<quotientBits+63)/64+1),
+		quotientBits:  quotientBits,
+		remainderBits: remainderBits,
+	}
+}
+
+// size returns the number of slots
+func (qf *QuotientFilter) size() uint64 {
+	return 1 << qf.quotientBits
+}
+
+// slot reads the remainder and flags packed into a slot
+func (qf *QuotientFilter) slot(index uint64) uint64 {
+	slotBits := uint64(qf.remainderBits + qfFlagBits)
+	bit := index * slotBits
+	word, offset := bit/64, bit%64
+
+	value := qf.table[word] >> offset
+	if offset+slotBits > 64 {
+		value |= qf.table[word+1] << (64 - offset)
+	}
+
+	return value & (1<<slotBits - 1)
+}
+
+// setSlot overwrites a slot's remainder and flags
+func (qf *QuotientFilter) setSlot(index uint64, value uint64) {
+	slotBits := uint64(qf.remainderBits + qfFlagBits)
+	mask := uint64(1)<<slotBits - 1
+	bit := index * slotBits
+	word, offset := bit/64, bit%64
+
+	qf.table[word] = qf.table[word]&^(mask<<offset) | value<<offset
+	if offset+slotBits > 64 {
+		qf.table[word+1] = qf.table[word+1]&^(mask>>(64-offset)) | value>>(64-offset)
+	}
+}
+
+// next and previous step through the slots, wrapping around the ends
+func (qf *QuotientFilter) next(index uint64) uint64 {
+	return (index + 1) & (qf.size() - 1)
+}
+
+func (qf *QuotientFilter) previous(index uint64) uint64 {
+	return (index - 1) & (qf.size() - 1)
+}
+
+// fingerprint takes the top quotient plus remainder bits of the item's hash
+func (qf *QuotientFilter) fingerprint(h uint64) uint64 {
+	return mix64(h) >> (64 - qf.quotientBits - qf.remainderBits)
+}
+
+// split divides a fingerprint into its quotient and remainder
+func (qf *QuotientFilter) split(fingerprint uint64) (uint64, uint64) {
+	return fingerprint >> qf.remainderBits, fingerprint & (1<<qf.remainderBits - 1)
+}
+
+// contains walks back to the start of the quotient's cluster, then forward counting runs to
+// find the quotient's own run and scans it for the remainder
+func (qf *QuotientFilter) contains(fingerprint uint64) bool {
+	quotient, remainder := qf.split(fingerprint)
+	if qf.slot(quotient)&qfOccupied == 0 {
+		return false
+	}
+
+	// Every occupied quotient between the cluster start and this one has a run before it
+	start := quotient
+	for qf.slot(start)&qfShifted != 0 {
+		start = qf.previous(start)
+	}
+
+	run := start
+	for start != quotient {
+		for {
+			run = qf.next(run)
+			if qf.slot(run)&qfContinuation == 0 {
+				break
+			}
+		}
+
+		for {
+			start = qf.next(start)
+			if qf.slot(start)&qfOccupied != 0 {
+				break
+			}
+		}
+	}
+
+	for {
+		stored := qf.slot(run) >> qfFlagBits
+		if stored == remainder {
+			return true
+		}
+
+		if stored > remainder {
+			return false
+		}
+
+		run = qf.next(run)
+		if qf.slot(run)&qfContinuation == 0 {
+			return false
+		}
+	}
+}
+
+// region reads every entry from the start of the block of filled slots around quotient up to
+// the empty slot after it, returning where the block starts. A quotient whose slot is empty
+// has nothing stored and starts a block of its own
+func (qf *QuotientFilter) region(quotient uint64) (uint64, []qfEntry) {
+	start := quotient
+	if qf.slot(start) == 0 {
+		return start, nil
+	}
+
+	for qf.slot(qf.previous(start)) != 0 {
+		start = qf.previous(start)
+	}
+
+	// Runs are in the same order as the occupied quotients they belong to, and never start
+	// before their quotient's slot so it has always been seen by then
+	quotients, entries := qf.quotients[:0], qf.entries[:0]
+	run := -1
+	for index, offset := start, uint64(0); qf.slot(index) != 0; index, offset = qf.next(index), offset+1 {
+		value := qf.slot(index)
+		if value&qfOccupied != 0 {
+			quotients = append(quotients, offset)
+		}
+
+		if value&qfContinuation == 0 {
+			run++
+		}
+		entries = append(entries, qfEntry{quotient: quotients[run], remainder: value >> qfFlagBits})
+	}
+	qf.quotients, qf.entries = quotients, entries
+
+	return start, entries
+}
+
+// layout clears the region's slots and writes the sorted entries back from start, each run
+// beginning at its quotient's slot or straight after the run before if that is further on
+func (qf *QuotientFilter) layout(start uint64, previousLength int, entries []qfEntry) {
+	for i, index := 0, start; i < previousLength; i, index = i+1, qf.next(index) {
+		qf.setSlot(index, 0)
+	}
+
+	position := uint64(0)
+	for i, entry := range entries {
+		flags := uint64(0)
+		if i > 0 && entries[i-1].quotient == entry.quotient {
+			flags |= qfContinuation
+		} else {
+			position = max(position, entry.quotient)
+			canonical := (start + entry.quotient) & (qf.size() - 1)
+			qf.setSlot(canonical, qf.slot(canonical)|qfOccupied)
+		}
+
+		if position != entry.quotient {
+			flags |= qfShifted
+		}
+
+		index := (start + position) & (qf.size() - 1)
+		qf.setSlot(index, qf.slot(index)&qfOccupied|flags|entry.remainder<<qfFlagBits)
+		position++
+	}
+}
+
+// compareEntries orders entries by quotient then remainder
+func compareEntries(a, b qfEntry) int {
+	if a.quotient != b.quotient {
+		return cmp.Compare(a.quotient, b.quotient)
+	}
+
+	return cmp.Compare(a.remainder, b.remainder)
+}
+
+// insert adds the fingerprint to its region, growing the filter first if it is too full.
+// It reports false if the filter is full and out of remainder bits to grow with
+func (qf *QuotientFilter) insert(fingerprint uint64) bool {
+	if float64(qf.count+1) > qfMaxLoad*float64(qf.size()) {
+		if qf.remainderBits > 1 && qf.quotientBits < 40 {
+			qf.resize(qf.quotientBits + 1)
+		} else if qf.count+1 >= qf.size() {
+			return false
+		}
+	}
+
+	qf.place(fingerprint)
+	qf.count++
+
+	return true
+}
+
+// place writes the fingerprint into its region in order
+func (qf *QuotientFilter) place(fingerprint uint64) {
+	quotient, remainder := qf.split(fingerprint)
+	start, entries := qf.region(quotient)
+	entry := qfEntry{quotient: (quotient - start) & (qf.size() - 1), remainder: remainder}
+
+	position, _ := slices.BinarySearchFunc(entries, entry, compareEntries)
+	previousLength := len(entries)
+	entries = slices.Insert(entries, position, entry)
+	qf.entries = entries
+
+	qf.layout(start, previousLength, entries)
+}
+
+// remove takes one copy of the fingerprint out of its region, reporting whether there was one
+func (qf *QuotientFilter) remove(fingerprint uint64) bool {
+	quotient, remainder := qf.split(fingerprint)
+	if qf.slot(quotient)&qfOccupied == 0 {
+		return false
+	}
+
+	start, entries := qf.region(quotient)
+	entry := qfEntry{quotient: (quotient - start) & (qf.size() - 1), remainder: remainder}
+
+	position, found := slices.BinarySearchFunc(entries, entry, compareEntries)
+	if !found {
+		return false
+	}
+
+	previousLength := len(entries)
+	entries = slices.Delete(entries, position, position+1)
+
+	qf.layout(start, previousLength, entries)
+	qf.count--
+
+	return true
+}
+
+// fingerprints returns every stored fingerprint, duplicates included, in no particular order
+func (qf *QuotientFilter) fingerprints() []uint64 {
+	fingerprints := make([]uint64, 0, qf.count)
+
+	// Start from an empty slot so no region is split across the wrap around
+	first := uint64(0)
+	for qf.slot(first) != 0 {
+		first = qf.next(first)
+	}
+
+	index := first
+	for {
+		if qf.slot(index) != 0 {
+			start, entries := qf.region(index)
+			for _, entry := range entries {
+				quotient := (start + entry.quotient) & (qf.size() - 1)
+				fingerprints = append(fingerprints, quotient<<qf.remainderBits|entry.remainder)
+			}
+			index = (start + uint64(len(entries)) - 1) & (qf.size() - 1)
+		}
+
+		index = qf.next(index)
+		if index == first {
+			return fingerprints
+		}
+	}
+}
+
+// resize rebuilds the filter into a new table with quotientBits bits of each fingerprint as
+// the quotient
+func (qf *QuotientFilter) resize(quotientBits uint) {
+	resized := newQuotientFilter(quotientBits, qf.quotientBits+qf.remainderBits-quotientBits)
+	for _, fingerprint := range qf.fingerprints() {
+		resized.place(fingerprint)
+	}
+	resized.count = qf.count
+	resized.keys = qf.keys
+
+	*qf = *resized
+}
+
+// Add hashes and puts some string into the filter, growing it once it is 90% full. It returns
+// false if the filter is full and has no remainder bits left to grow with
+func (qf *QuotientFilter) Add(s string) bool {
+	return qf.insert(qf.fingerprint(hashKey(&qf.keys, s)))
+}
+
+// AddBytes hashes and puts a byte slice into the filter like Add
+func (qf *QuotientFilter) AddBytes(b []byte) bool {
+	return qf.insert(qf.fingerprint(hashKey(&qf.keys, b)))
+}
+
+// Contains reports whether some string might be in the filter, false means it definitely isn't
+func (qf *QuotientFilter) Contains(s string) bool {
+	return qf.contains(qf.fingerprint(hashKey(&qf.keys, s)))
+}
+
+// ContainsBytes reports whether a byte slice might be in the filter
+func (qf *QuotientFilter) ContainsBytes(b []byte) bool {
+	return qf.contains(qf.fingerprint(hashKey(&qf.keys, b)))
+}
+
+// Delete takes one copy of some string back out of the filter, returning false if it
+// definitely wasn't there. Only delete items that were added
+func (qf *QuotientFilter) Delete(s string) bool {
+	return qf.remove(qf.fingerprint(hashKey(&qf.keys, s)))
+}
+
+// DeleteBytes takes one copy of a byte slice back out of the filter like Delete
+func (qf *QuotientFilter) DeleteBytes(b []byte) bool {
+	return qf.remove(qf.fingerprint(hashKey(&qf.keys, b)))
+}
+
+// Count returns how many items are in the filter
+func (qf *QuotientFilter) Count() uint64 {
+	return qf.count
+}
+
+// Resize moves bits between the quotient and the remainder of every fingerprint so the filter
+// has 2^quotientBits slots, growing to make room or shrinking to save it. The fingerprints
+// stay the same so nothing needs adding again and the false positive rate moves with the
+// remainder bits. It isn't done in place: the fingerprints are read out and laid into a new
+// table that then replaces the old one, so for a moment the filter needs the memory of both
+func (qf *QuotientFilter) Resize(quotientBits uint) error {
+	fingerprintBits := qf.quotientBits + qf.remainderBits
+	if quotientBits < 1 || quotientBits > 40 || quotientBits >= fingerprintBits || fingerprintBits-quotientBits+qfFlagBits > 64 {
+		return fmt.Errorf("cannot resize %d bit fingerprints to %d bit quotients", fingerprintBits, quotientBits)
+	}
+
+	if qf.count >= uint64(1)<<quotientBits {
+		return fmt.Errorf("cannot fit %d items in %d slots", qf.count, uint64(1)<<quotientBits)
+	}
+
+	qf.resize(quotientBits)
+
+	return nil
+}
+
+// Merge adds every item in other into this filter, growing it as needed. Both need the same
+// number of fingerprint bits, though they can be split differently, and the same seed
+func (qf *QuotientFilter) Merge(other *QuotientFilter) error {
+	if qf.quotientBits+qf.remainderBits != other.quotientBits+other.remainderBits {
+		return fmt.Errorf("cannot merge %d bit fingerprints into %d bit fingerprints", other.quotientBits+other.remainderBits, qf.quotientBits+qf.remainderBits)
+	}
+
+	if err := qf.keys.compatible(&other.keys); err != nil {
+		return err
+	}
+
+	for _, fingerprint := range other.fingerprints() {
+		if !qf.insert(fingerprint) {
+			return fmt.Errorf("filter is full")
+		}
+	}
+
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, writing a version byte, the quotient and
+// remainder bits, the count, the seed and then the packed slots as they are in memory, so
+// runs stay in order on disk and can be merge read a cluster at a time
+func (qf *QuotientFilter) MarshalBinary() ([]byte, error) {
+	data := make([]byte, quotientHeaderSize, quotientHeaderSize+8*len(qf.table))
+	data[0] = quotientBinaryVersion
+	data[1] = uint8(qf.quotientBits)
+	data[2] = uint8(qf.remainderBits)
+	binary.BigEndian.PutUint64(data[3:11], qf.count)
+	binary.BigEndian.PutUint64(data[11:19], qf.keys.seed)
+
+	for _, word := range qf.table {
+		data = binary.BigEndian.AppendUint64(data, word)
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing the filter with one read from
+// MarshalBinary output. The filter keeps its own Hasher, and once built its seed has to match
+func (qf *QuotientFilter) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return fmt.Errorf("binary data is too short")
+	}
+
+	if data[0] != quotientBinaryVersion {
+		return fmt.Errorf("%w %d", ErrUnsupportedVersion, data[0])
+	}
+
+	if len(data) < quotientHeaderSize {
+		return fmt.Errorf("binary data is too short")
+	}
+
+	quotientBits, remainderBits := uint(data[1]), uint(data[2])
+	if quotientBits < 1 || quotientBits > 40 || remainderBits < 1 || quotientBits+remainderBits > 64 || remainderBits+qfFlagBits > 64 {
+		return fmt.Errorf("cannot decode %d bit remainders with %d bit quotients", remainderBits, quotientBits)
+	}
+
+	decoded := newQuotientFilter(quotientBits, remainderBits)
+	decoded.count = binary.BigEndian.Uint64(data[3:11])
+	if decoded.count >= decoded.size() {
+		return fmt.Errorf("cannot fit %d items in %d slots", decoded.count, decoded.size())
+	}
+
+	words := data[quotientHeaderSize:]
+	if len(words) != 8*len(decoded.table) {
+		return fmt.Errorf("got %d bytes of slots but %d slots needs %d", len(words), decoded.size(), 8*len(decoded.table))
+	}
+
+	for i := range decoded.table {
+		decoded.table[i] = binary.BigEndian.Uint64(words[8*i:])
+	}
+
+	// Walking the slots relies on there always being an empty one
+	empty := false
+	for index := uint64(0); index < decoded.size() && !empty; index++ {
+		empty = decoded.slot(index) == 0
+	}
+
+	if !empty {
+		return fmt.Errorf("quotient filter has no empty slots")
+	}
+
+	seed := binary.BigEndian.Uint64(data[11:19])
+
+	decoded.keys = qf.keys
+	if err := decoded.keys.decodeSeed(seed, qf.table != nil); err != nil {
+		return err
+	}
+
+	*qf = *decoded
+
+	return nil
+}
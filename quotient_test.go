@@ -0,0 +1,96 @@
+package pds
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestQuotientFilterAddDeleteResize(t *testing.T) {
+	qf, err := NewQuotientFilter(8, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Adding ten times the starting slots grows the filter along the way
+	for i := 0; i < 2560; i++ {
+		if !qf.Add(fmt.Sprintf("item-%d", i)) {
+			t.Fatalf("filter filled up after %d items", i)
+		}
+	}
+
+	if qf.quotientBits <= 8 || qf.Count() != 2560 {
+		t.Fatalf("got %d quotient bits and count %d", qf.quotientBits, qf.Count())
+	}
+
+	for i := 0; i < 1280; i++ {
+		if !qf.DeleteBytes([]byte(fmt.Sprintf("item-%d", i))) {
+			t.Fatalf("item-%d couldn't be deleted", i)
+		}
+	}
+
+	if err := qf.Resize(qf.quotientBits - 1); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1280; i < 2560; i++ {
+		if !qf.Contains(fmt.Sprintf("item-%d", i)) {
+			t.Fatalf("item-%d went missing", i)
+		}
+	}
+}
+
+func TestQuotientFilterBinaryRoundTrip(t *testing.T) {
+	qf, err := NewQuotientFilter(10, 12, WithKeySeed(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 500; i++ {
+		qf.Add(fmt.Sprintf("item-%d", i))
+	}
+
+	data, err := qf.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded QuotientFilter
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Count() != 500 || decoded.keys.seed != 9 {
+		t.Fatalf("got count %d and seed %d", decoded.Count(), decoded.keys.seed)
+	}
+
+	for i := 0; i < 500; i++ {
+		if !decoded.ContainsBytes([]byte(fmt.Sprintf("item-%d", i))) {
+			t.Fatalf("item-%d went missing after decoding", i)
+		}
+	}
+
+	unseeded, _ := NewQuotientFilter(10, 12)
+	if err := unseeded.UnmarshalBinary(data); err == nil {
+		t.Fatalf("decoded a seeded filter into an unseeded one")
+	}
+}
+
+func TestQuotientFilterMerge(t *testing.T) {
+	a, _ := NewQuotientFilter(8, 12)
+	b, _ := NewQuotientFilter(9, 11)
+	a.Add("a")
+	b.Add("b")
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if !a.Contains("a") || !a.Contains("b") || a.Count() != 2 {
+		t.Fatalf("merged filter is missing items")
+	}
+
+	seeded, _ := NewQuotientFilter(8, 12, WithKeySeed(1))
+	if err := a.Merge(seeded); err == nil {
+		t.Fatalf("Merge accepted a differently seeded filter")
+	}
+}
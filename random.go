@@ -0,0 +1,28 @@
+package pds
+
+import (
+	"math/rand"
+)
+
+// maxRandomItemsPerBucket caps how many simulated items land in each bucket of a random HyperLogLog
+const maxRandomItemsPerBucket = 8
+
+// NewRandomHyperLogLog builds a HyperLogLog with every bucket filled as if a random handful
+// of items had landed in it, drawing only from rng so the same seed always gives the same
+// sketch. This is meant for generating repeatable test sketches
+func NewRandomHyperLogLog(indexBits uint32, rng *rand.Rand, options ...Option) (HyperLogLog, error) {
+	hll, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		return HyperLogLog{}, err
+	}
+
+	hll.denseRegisters()
+	for i := int64(0); i < hll.mBuckets; i++ {
+		items := rng.Intn(maxRandomItemsPerBucket + 1)
+		for j := 0; j < items; j++ {
+			hll.raiseRegister(uint32(i), hll.rank(uint64(rng.Uint32()>>hll.indexBits)))
+		}
+	}
+
+	return hll, nil
+}
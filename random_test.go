@@ -0,0 +1,37 @@
+package pds
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestNewRandomHyperLogLogRepeatable(t *testing.T) {
+	a, err := NewRandomHyperLogLog(10, rand.New(rand.NewSource(42)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewRandomHyperLogLog(10, rand.New(rand.NewSource(42)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !a.Equal(&b) {
+		t.Fatalf("sketches from the same seed differ")
+	}
+
+	c, err := NewRandomHyperLogLog(10, rand.New(rand.NewSource(43)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Equal(&c) {
+		t.Fatalf("sketches from different seeds are equal")
+	}
+
+	// Every bucket takes up to 8 items, about 4 per bucket on average, though spread evenly
+	// rather than the way real items would fall so the estimate only lands in the area
+	if estimate := a.EstimateCardinality(); estimate < 2000 || estimate > 6000 {
+		t.Fatalf("got estimate %d, wanted somewhere near 4096", estimate)
+	}
+}
@@ -0,0 +1,204 @@
+package pds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+//go:generate go run redisdata_gen.go
+
+// Redis only uses 14 index bits, with its 16384 registers stored 6 bits apiece
+const (
+	redisIndexBits    = 14
+	redisRegisters    = 1 << redisIndexBits
+	redisRegisterBits = 6
+	redisRegisterMask = 1<<redisRegisterBits - 1
+	redisHeaderSize   = 16
+	redisDenseSize    = redisRegisters*redisRegisterBits/8 + 1
+	redisDense        = 0
+	redisSparse       = 1
+	redisInvalidCache = 1 << 7
+	redisHashSeed     = 0xadc83b19
+)
+
+// redisMagic starts every Redis HyperLogLog string
+var redisMagic = []byte("HYLL")
+
+// RedisHasher hashes with MurmurHash64A seeded with 0xadc83b19 like Redis's PFADD, so a sketch
+// with 14 index bits, With64BitHash and WithHasher(RedisHasher{}) fills the same registers as
+// Redis does for the same items. Any seed is xored into Redis's
+type RedisHasher struct{}
+
+// Hash64 returns the MurmurHash64A hash of data Redis uses
+func (RedisHasher) Hash64(data []byte, seed uint64) uint64 {
+	return murmurHash64A(data, redisHashSeed^seed)
+}
+
+// Hash32 returns the 64 bit hash of data folded down to 32 bits
+func (r RedisHasher) Hash32(data []byte, seed uint32) uint32 {
+	h := r.Hash64(data, uint64(seed))
+
+	return uint32(h ^ h>>32)
+}
+
+// murmurHash64A is Austin Appleby's MurmurHash64A, reading blocks little endian like Redis
+// does on every platform
+func murmurHash64A(data []byte, seed uint64) uint64 {
+	const m = 0xc6a4a7935bd1e995
+	const r = 47
+
+	h := seed ^ uint64(len(data))*m
+	for ; len(data) >= 8; data = data[8:] {
+		k := binary.LittleEndian.Uint64(data)
+		k *= m
+		k ^= k >> r
+		k *= m
+
+		h ^= k
+		h *= m
+	}
+
+	if len(data) > 0 {
+		for i := len(data) - 1; i >= 0; i-- {
+			h ^= uint64(data[i]) << (8 * i)
+		}
+		h *= m
+	}
+
+	h ^= h >> r
+	h *= m
+	h ^= h >> r
+
+	return h
+}
+
+// MarshalRedis writes the HyperLogLog in Redis's dense HyperLogLog encoding so it can be SET
+// as a key and used with PFCOUNT and PFMERGE. Only 14 index bits are supported, matching
+// Redis. Unless the sketch hashes with RedisHasher and With64BitHash the same item added on
+// both sides lands in different buckets, so estimates carry over but merging sketches of
+// overlapping items will count the overlap twice
+func (hll *HyperLogLog) MarshalRedis() ([]byte, error) {
+	if hll.indexBits != redisIndexBits {
+		return nil, fmt.Errorf("redis needs %d index bits, not %d", redisIndexBits, hll.indexBits)
+	}
+
+	// The trailing byte lets the last register be written across a byte boundary like Redis
+	// does, it is dropped before returning
+	data := make([]byte, redisHeaderSize+redisDenseSize)
+	copy(data, redisMagic)
+	data[4] = redisDense
+
+	// Mark the cached cardinality as stale so Redis works it out itself
+	data[15] = redisInvalidCache
+
+	registers := data[redisHeaderSize:]
+	for i, value := range hll.registers() {
+		byteIndex := i * redisRegisterBits / 8
+		firstBit := uint(i * redisRegisterBits & 7)
+
+		registers[byteIndex] |= value << firstBit
+		registers[byteIndex+1] |= value >> (8 - firstBit)
+	}
+
+	return data[:len(data)-1], nil
+}
+
+// UnmarshalRedis loads a Redis HyperLogLog string like UnmarshalBinary, as returned by GET on
+// a key built with PFADD, in either the dense or sparse encoding. Redis hashes into 64 bits
+// so the result uses 64 bit hashes too, and a configured sketch needs 14 index bits and
+// With64BitHash. See RedisHasher for adding to it the way Redis does
+func (hll *HyperLogLog) UnmarshalRedis(data []byte) error {
+	if len(data) < redisHeaderSize || !bytes.Equal(data[:4], redisMagic) {
+		return fmt.Errorf("not a redis hyperloglog")
+	}
+
+	decoded, err := NewHyperLogLog(redisIndexBits, With64BitHash())
+	if err != nil {
+		return err
+	}
+
+	var values []byte
+	switch data[4] {
+	case redisDense:
+		values, err = redisDenseValues(data[redisHeaderSize:])
+	case redisSparse:
+		values, err = redisSparseValues(data[redisHeaderSize:])
+	default:
+		err = fmt.Errorf("unknown redis hyperloglog encoding %d", data[4])
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for i, value := range values {
+		if value > maxWideBucketValue {
+			return fmt.Errorf("redis register %d holds %d, more than a 64 bit hash can give", i, value)
+		}
+
+		decoded.bucketGroup[i] = value
+	}
+
+	return hll.load(&decoded)
+}
+
+// redisDenseValues unpacks the 6 bit registers of Redis's dense encoding
+func redisDenseValues(registers []byte) ([]byte, error) {
+	if len(registers) != redisDenseSize-1 {
+		return nil, fmt.Errorf("dense redis hyperloglog has %d bytes of registers, expected %d", len(registers), redisDenseSize-1)
+	}
+
+	// Pad so the last register can be read across a byte boundary
+	registers = append(registers, 0)
+
+	values := make([]byte, redisRegisters)
+	for i := range values {
+		byteIndex := i * redisRegisterBits / 8
+		firstBit := uint(i * redisRegisterBits & 7)
+
+		values[i] = (registers[byteIndex]>>firstBit | registers[byteIndex+1]<<(8-firstBit)) & redisRegisterMask
+	}
+
+	return values, nil
+}
+
+// redisSparseValues expands the ZERO, XZERO and VAL opcodes of Redis's sparse encoding
+func redisSparseValues(opcodes []byte) ([]byte, error) {
+	values := make([]byte, 0, redisRegisters)
+
+	for i := 0; i < len(opcodes); i++ {
+		op := opcodes[i]
+
+		var value byte
+		var run int
+		switch {
+		case op&0xc0 == 0x00: // ZERO 00xxxxxx
+			run = int(op&0x3f) + 1
+		case op&0xc0 == 0x40: // XZERO 01xxxxxx yyyyyyyy
+			if i+1 >= len(opcodes) {
+				return nil, fmt.Errorf("truncated sparse redis hyperloglog")
+			}
+
+			i++
+			run = (int(op&0x3f)<<8 | int(opcodes[i])) + 1
+		default: // VAL 1vvvvvxx
+			value = (op>>2)&0x1f + 1
+			run = int(op&0x03) + 1
+		}
+
+		if len(values)+run > redisRegisters {
+			return nil, fmt.Errorf("sparse redis hyperloglog has too many registers")
+		}
+
+		for j := 0; j < run; j++ {
+			values = append(values, value)
+		}
+	}
+
+	if len(values) != redisRegisters {
+		return nil, fmt.Errorf("sparse redis hyperloglog has %d registers, expected %d", len(values), redisRegisters)
+	}
+
+	return values, nil
+}
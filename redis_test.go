@@ -0,0 +1,156 @@
+package pds
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+)
+
+// redisSketch adds the items the Redis fixtures in testdata were built from, hashing them the
+// way Redis does
+func redisSketch(t *testing.T, items int) HyperLogLog {
+	hll, err := NewHyperLogLog(redisIndexBits, With64BitHash(), WithHasher(RedisHasher{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < items; i++ {
+		hll.Add(fmt.Sprintf("item-%d", i))
+	}
+
+	return hll
+}
+
+func TestRedisRoundTrip(t *testing.T) {
+	hll := filledSketch(t, redisIndexBits, 5000)
+
+	data, err := hll.MarshalRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data[:4], redisMagic) || len(data) != redisHeaderSize+redisDenseSize-1 {
+		t.Fatalf("got a %d byte string starting %q, wanted a dense redis hyperloglog", len(data), data[:4])
+	}
+
+	var decoded HyperLogLog
+	if err := decoded.UnmarshalRedis(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(decoded.bucketGroup, hll.bucketGroup) {
+		t.Fatalf("buckets differ after decoding")
+	}
+}
+
+func TestMarshalRedisRejectsOtherIndexBits(t *testing.T) {
+	hll := filledSketch(t, 10, 10)
+
+	if _, err := hll.MarshalRedis(); err == nil {
+		t.Fatalf("wanted an error marshalling 10 index bits for redis")
+	}
+}
+
+func TestUnmarshalRedisSparse(t *testing.T) {
+	// XZERO over the first 100 registers, VAL 3 for the next two, then XZERO for the rest
+	rest := redisRegisters - 102 - 1
+	opcodes := []byte{0x40, 99, 0x80 | 2<<2 | 1, 0x40 | byte(rest>>8), byte(rest)}
+
+	data := make([]byte, redisHeaderSize, redisHeaderSize+len(opcodes))
+	copy(data, redisMagic)
+	data[4] = redisSparse
+	data = append(data, opcodes...)
+
+	var hll HyperLogLog
+	if err := hll.UnmarshalRedis(data); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, b := range hll.bucketGroup {
+		var want uint8
+		if i == 100 || i == 101 {
+			want = 3
+		}
+
+		if b != want {
+			t.Fatalf("got %d in bucket %d, wanted %d", b, i, want)
+		}
+	}
+
+	if err := hll.UnmarshalRedis(data[:len(data)-1]); err == nil {
+		t.Fatalf("decoded a truncated sparse hyperloglog")
+	}
+}
+
+func TestUnmarshalRedisFixtures(t *testing.T) {
+	// Generated by redisdata_gen.go's port of PFADD rather than captured from a server
+	tests := []struct {
+		path  string
+		items int
+	}{
+		{"testdata/redis_sparse.hll", 300},
+		{"testdata/redis_dense.hll", 20000},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			data, err := os.ReadFile(test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded HyperLogLog
+			if err := decoded.UnmarshalRedis(data); err != nil {
+				t.Fatal(err)
+			}
+
+			want := redisSketch(t, test.items)
+			if !slices.Equal(decoded.bucketGroup, want.bucketGroup) {
+				t.Fatalf("decoded registers differ from adding the same items with RedisHasher")
+			}
+
+			// Redis leaves the cached cardinality invalid after PFADD, as MarshalRedis does
+			if data[4] == redisDense {
+				marshalled, err := want.MarshalRedis()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !bytes.Equal(marshalled, data) {
+					t.Fatalf("marshalled sketch differs from the redis string")
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarshalRedisRejectsLargeRegisters(t *testing.T) {
+	hll := redisSketch(t, 10)
+
+	data, err := hll.MarshalRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One more than the 51 a 64 bit hash with 14 index bits can give, in the first register
+	registers := data[redisHeaderSize:]
+	registers[0] = registers[0]&^redisRegisterMask | maxWideBucketValue + 1
+
+	var decoded HyperLogLog
+	if err := decoded.UnmarshalRedis(data); err == nil {
+		t.Fatalf("decoded a register of %d", maxWideBucketValue+1)
+	}
+}
+
+func TestRedisHasherSeeds(t *testing.T) {
+	// Seed 0 is what Redis hashes with, see TestUnmarshalRedisFixtures, and other seeds move
+	// every tail length and a full block
+	var hasher RedisHasher
+	for _, key := range []string{"", "a", "abc", "abcdefg", "abcdefgh", "abcdefghijk"} {
+		if hasher.Hash64([]byte(key), 0) == hasher.Hash64([]byte(key), 1) {
+			t.Errorf("seed 1 didn't change the hash of %q", key)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+//go:build ignore
+
+// redisdata_gen.go writes the Redis HyperLogLog strings in testdata that redis_test.go decodes,
+// run it with go generate. There is no Redis server involved: it is a port of PFADD from
+// Redis's hyperloglog.c, hashing, sparse updates, merging of adjacent opcodes and promotion to
+// the dense encoding included, so the strings are what GET returns after the same PFADDs on a
+// new key as long as the port is faithful
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+const (
+	hllP             = 14
+	hllQ             = 64 - hllP
+	hllRegisters     = 1 << hllP
+	hllPMask         = hllRegisters - 1
+	hllBits          = 6
+	hllRegisterMax   = 1<<hllBits - 1
+	hllHdrSize       = 16
+	hllDenseSize     = hllHdrSize + (hllRegisters*hllBits+7)/8
+	hllDense         = 0
+	hllSparse        = 1
+	sparseValMaxVal  = 32
+	sparseValMaxLen  = 4
+	sparseZeroMaxLen = 64
+	sparseXZeroMax   = 16384
+
+	// sparseMaxBytes is Redis's default hll-sparse-max-bytes
+	sparseMaxBytes = 3000
+)
+
+// murmurHash64A mirrors MurmurHash64A in hyperloglog.c
+func murmurHash64A(key []byte, seed uint64) uint64 {
+	const m = 0xc6a4a7935bd1e995
+	const r = 47
+
+	h := seed ^ uint64(len(key))*m
+	end := len(key) - len(key)&7
+	for i := 0; i < end; i += 8 {
+		var k uint64
+		for j := 7; j >= 0; j-- {
+			k = k<<8 | uint64(key[i+j])
+		}
+
+		k *= m
+		k ^= k >> r
+		k *= m
+		h ^= k
+		h *= m
+	}
+
+	data := key[end:]
+	switch len(key) & 7 {
+	case 7:
+		h ^= uint64(data[6]) << 48
+		fallthrough
+	case 6:
+		h ^= uint64(data[5]) << 40
+		fallthrough
+	case 5:
+		h ^= uint64(data[4]) << 32
+		fallthrough
+	case 4:
+		h ^= uint64(data[3]) << 24
+		fallthrough
+	case 3:
+		h ^= uint64(data[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint64(data[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint64(data[0])
+		h *= m
+	}
+
+	h ^= h >> r
+	h *= m
+	h ^= h >> r
+
+	return h
+}
+
+// patLen mirrors hllPatLen, returning the register and the length of the 000..1 pattern
+func patLen(ele []byte) (int, int) {
+	hash := murmurHash64A(ele, 0xadc83b19)
+	index := int(hash & hllPMask)
+	hash >>= hllP
+	hash |= 1 << hllQ
+
+	count := 1
+	for bit := uint64(1); hash&bit == 0; bit <<= 1 {
+		count++
+	}
+
+	return index, count
+}
+
+// Sparse opcode helpers, the HLL_SPARSE_* macros
+func isZero(b byte) bool  { return b&0xc0 == 0 }
+func isXZero(b byte) bool { return b&0xc0 == 0x40 }
+func zeroLen(b byte) int  { return int(b&0x3f) + 1 }
+func xzeroLen(p []byte) int {
+	return (int(p[0]&0x3f)<<8 | int(p[1])) + 1
+}
+func valValue(b byte) int { return int(b>>2&0x1f) + 1 }
+func valLen(b byte) int   { return int(b&0x3) + 1 }
+func valSet(value, length int) byte {
+	return byte(0x80 | (value-1)<<2 | (length - 1))
+}
+func zeroSet(length int) byte { return byte(length - 1) }
+func xzeroSet(length int) []byte {
+	length--
+	return []byte{byte(0x40 | length>>8), byte(length & 0xff)}
+}
+
+// hll is a Redis HyperLogLog string
+type hll []byte
+
+// newHLL mirrors createHLLObject, a sparse string of one XZERO over every register
+func newHLL() hll {
+	h := make(hll, hllHdrSize)
+	copy(h, "HYLL")
+	h[4] = hllSparse
+
+	return append(h, xzeroSet(sparseXZeroMax)...)
+}
+
+// invalidateCache mirrors HLL_INVALIDATE_CACHE
+func (h hll) invalidateCache() {
+	h[15] |= 1 << 7
+}
+
+// denseRegister mirrors HLL_DENSE_GET_REGISTER
+func (h hll) denseRegister(regnum int) int {
+	b := regnum * hllBits / 8
+	fb := uint(regnum * hllBits & 7)
+	fb8 := 8 - fb
+
+	var next byte
+	if b+1 < len(h[hllHdrSize:]) {
+		next = h[hllHdrSize+b+1]
+	}
+
+	return int((h[hllHdrSize+b]>>fb | next<<fb8) & hllRegisterMax)
+}
+
+// setDenseRegister mirrors HLL_DENSE_SET_REGISTER
+func (h hll) setDenseRegister(regnum, value int) {
+	p := h[hllHdrSize:]
+	b := regnum * hllBits / 8
+	fb := uint(regnum * hllBits & 7)
+	fb8 := 8 - fb
+	v := byte(value)
+
+	p[b] &^= hllRegisterMax << fb
+	p[b] |= v << fb
+
+	// The sds string's terminator takes the spill of the last register in Redis
+	if b+1 < len(p) {
+		p[b+1] &^= hllRegisterMax >> fb8
+		p[b+1] |= v >> fb8
+	}
+}
+
+// sparseToDense mirrors hllSparseToDense, keeping the magic and cached cardinality
+func (h hll) sparseToDense() hll {
+	dense := make(hll, hllDenseSize)
+	copy(dense, h[:hllHdrSize])
+	dense[4] = hllDense
+
+	idx := 0
+	p := h[hllHdrSize:]
+	for len(p) > 0 {
+		switch {
+		case isZero(p[0]):
+			idx += zeroLen(p[0])
+			p = p[1:]
+		case isXZero(p[0]):
+			idx += xzeroLen(p)
+			p = p[2:]
+		default:
+			runlen, value := valLen(p[0]), valValue(p[0])
+			for ; runlen > 0; runlen-- {
+				dense.setDenseRegister(idx, value)
+				idx++
+			}
+			p = p[1:]
+		}
+	}
+
+	if idx != hllRegisters {
+		log.Fatalf("sparse string covers %d registers", idx)
+	}
+
+	return dense
+}
+
+// sparseSet mirrors hllSparseSet, promoting to dense where Redis would and returning whether
+// a register changed
+func (h hll) sparseSet(index, count int) (hll, bool) {
+	if count > sparseValMaxVal {
+		return h.promote(index, count), true
+	}
+
+	sparse := hllHdrSize
+	end := len(h)
+
+	// Step 1: find the opcode covering the register
+	p, first, span, prev := sparse, 0, 0, -1
+	for p < end {
+		oplen := 1
+		switch {
+		case isZero(h[p]):
+			span = zeroLen(h[p])
+		case isXZero(h[p]):
+			span = xzeroLen(h[p:])
+			oplen = 2
+		default:
+			span = valLen(h[p])
+		}
+
+		if index <= first+span-1 {
+			break
+		}
+
+		prev = p
+		p += oplen
+		first += span
+	}
+	if span == 0 || p >= end {
+		log.Fatalf("invalid sparse string")
+	}
+
+	// Step 2: work out what the opcode is
+	var isZ, isX, isVal bool
+	var runlen int
+	switch {
+	case isZero(h[p]):
+		isZ, runlen = true, zeroLen(h[p])
+	case isXZero(h[p]):
+		isX, runlen = true, xzeroLen(h[p:])
+	default:
+		isVal, runlen = true, valLen(h[p])
+	}
+
+	// Step 3: cases A and B update the opcode in place
+	switch {
+	case isVal && valValue(h[p]) >= count:
+		return h, false
+	case isVal && runlen == 1, isZ && runlen == 1:
+		h[p] = valSet(count, 1)
+		return h.merge(sparse, prev), true
+	}
+
+	// Case C replaces the opcode with up to three
+	var seq []byte
+	last := first + span - 1
+	if isZ || isX {
+		if index != first {
+			seq = appendZeros(seq, index-first)
+		}
+		seq = append(seq, valSet(count, 1))
+		if index != last {
+			seq = appendZeros(seq, last-index)
+		}
+	} else {
+		curval := valValue(h[p])
+		if index != first {
+			seq = append(seq, valSet(curval, index-first))
+		}
+		seq = append(seq, valSet(count, 1))
+		if index != last {
+			seq = append(seq, valSet(curval, last-index))
+		}
+	}
+
+	oldlen := 1
+	if isX {
+		oldlen = 2
+	}
+	if deltalen := len(seq) - oldlen; deltalen > 0 && len(h)+deltalen > sparseMaxBytes {
+		return h.promote(index, count), true
+	}
+
+	updated := make(hll, 0, len(h)+len(seq)-oldlen)
+	updated = append(updated, h[:p]...)
+	updated = append(updated, seq...)
+	updated = append(updated, h[p+oldlen:]...)
+
+	return updated.merge(sparse, prev), true
+}
+
+// appendZeros writes a ZERO or XZERO opcode the way case C does
+func appendZeros(seq []byte, length int) []byte {
+	if length > sparseZeroMaxLen {
+		return append(seq, xzeroSet(length)...)
+	}
+
+	return append(seq, zeroSet(length))
+}
+
+// merge mirrors step 4 of hllSparseSet, scanning five opcodes from the one before the update
+// and joining adjacent VAL opcodes of the same value
+func (h hll) merge(sparse, prev int) hll {
+	p := sparse
+	if prev >= 0 {
+		p = prev
+	}
+
+	for scanlen := 5; p < len(h) && scanlen > 0; scanlen-- {
+		switch {
+		case isXZero(h[p]):
+			p += 2
+			continue
+		case isZero(h[p]):
+			p++
+			continue
+		}
+
+		if p+1 < len(h) && !isZero(h[p+1]) && !isXZero(h[p+1]) {
+			v1, v2 := valValue(h[p]), valValue(h[p+1])
+			if v1 == v2 {
+				if length := valLen(h[p]) + valLen(h[p+1]); length <= sparseValMaxLen {
+					h[p+1] = valSet(v1, length)
+					h = append(h[:p], h[p+1:]...)
+					continue
+				}
+			}
+		}
+		p++
+	}
+
+	return h
+}
+
+// promote converts to dense and sets the register there, the promote label of hllSparseSet
+func (h hll) promote(index, count int) hll {
+	dense := h.sparseToDense()
+	dense.denseSet(index, count)
+
+	return dense
+}
+
+// denseSet mirrors hllDenseSet, returning whether the register changed
+func (h hll) denseSet(index, count int) bool {
+	if count <= h.denseRegister(index) {
+		return false
+	}
+
+	h.setDenseRegister(index, count)
+
+	return true
+}
+
+// pfadd adds the element like PFADD, invalidating the cache when a register changes
+func (h hll) pfadd(ele string) hll {
+	index, count := patLen([]byte(ele))
+
+	var updated bool
+	if h[4] == hllSparse {
+		h, updated = h.sparseSet(index, count)
+	} else {
+		updated = h.denseSet(index, count)
+	}
+
+	if updated {
+		h.invalidateCache()
+	}
+
+	return h
+}
+
+func main() {
+	fixtures := []struct {
+		path  string
+		items int
+		dense bool
+	}{
+		{"testdata/redis_sparse.hll", 300, false},
+		{"testdata/redis_dense.hll", 20000, true},
+	}
+
+	for _, fixture := range fixtures {
+		h := newHLL()
+		for i := 0; i < fixture.items; i++ {
+			h = h.pfadd(fmt.Sprintf("item-%d", i))
+		}
+
+		if (h[4] == hllDense) != fixture.dense {
+			log.Fatalf("%s came out with encoding %d", fixture.path, h[4])
+		}
+
+		if err := os.WriteFile(fixture.path, h, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package pds
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Ribbon filters band keys into rows of ribbonWidth coefficients, starting out with
+// ribbonOverhead times as many slots as keys and growing by ribbonGrowth each time banding
+// fails, for up to ribbonMaxAttempts attempts
+const (
+	ribbonWidth       = 64
+	ribbonOverhead    = 1.1
+	ribbonGrowth      = 1.05
+	ribbonMaxAttempts = 20
+)
+
+// RibbonFilter is a static membership filter built once from every key it will hold, like
+// Xor8 but closer to the smallest possible size, about 1.1 times resultBits bits per key for a
+// false positive rate of 2^-resultBits. It follows Dillinger and Walzer's "Ribbon filter:
+// practically smaller than Bloom and Xor": each key has a run of 64 coefficients starting
+// at a slot picked by its hash, and a solution is found whose bits under the coefficients
+// xor to the key's fingerprint. Keys are already hashed uint64s like for Xor8
+type RibbonFilter struct {
+	seed       uint64
+	slots      uint64
+	resultBits uint
+
+	// columns holds each bit of the solution for every slot as its own bitmap, so a lookup
+	// reads the 64 slots under the coefficients from each with a pair of words
+	columns [][]uint64
+}
+
+// BuildRibbon builds a RibbonFilter holding keys with resultBits bit fingerprints, from 1 to 16.
+// Rows are banded one key at a time as they are hashed, eliminating against the rows already
+// placed, so building takes about 10 bytes a slot on top of the filter
+func BuildRibbon(keys []uint64, resultBits uint) (*RibbonFilter, error) {
+	if resultBits < 1 || resultBits > 16 {
+		return nil, fmt.Errorf("result bits need to be in interval 1>=x>=16")
+	}
+
+	rf := &RibbonFilter{resultBits: resultBits}
+	slots := uint64(float64(len(keys))*ribbonOverhead) + ribbonWidth
+
+	var rng splitMix64
+	for attempt := 0; attempt < ribbonMaxAttempts; attempt++ {
+		rf.seed = rng.next()
+		rf.slots = slots
+		if rf.build(keys) {
+			return rf, nil
+		}
+
+		slots = uint64(float64(slots) * ribbonGrowth)
+	}
+
+	return nil, fmt.Errorf("could not build a ribbon filter of %d keys in %d attempts", len(keys), ribbonMaxAttempts)
+}
+
+// row returns the key's first slot, coefficients and fingerprint. The lowest coefficient is
+// always set so the row is pinned to its first slot
+func (rf *RibbonFilter) row(key uint64) (uint64, uint64, uint16) {
+	h := mix64(key ^ rf.seed)
+	start, _ := bits.Mul64(h, rf.slots-ribbonWidth+1)
+	g := mix64(h + 0x9e3779b97f4a7c15)
+
+	return start, g | 1, uint16(mix64(g)) & (1<<rf.resultBits - 1)
+}
+
+// build bands every key then solves for the columns, reporting false if two rows cancelled
+// out with different fingerprints so the keys need another seed
+func (rf *RibbonFilter) build(keys []uint64) bool {
+	coefficients := make([]uint64, rf.slots)
+	results := make([]uint16, rf.slots)
+
+	for _, key := range keys {
+		start, coefficient, result := rf.row(key)
+
+		// Eliminate against the rows already placed until landing on an empty slot
+		for {
+			if coefficients[start] == 0 {
+				coefficients[start] = coefficient
+				results[start] = result
+				break
+			}
+
+			coefficient ^= coefficients[start]
+			result ^= results[start]
+			if coefficient == 0 {
+				// Duplicate keys cancel out to nothing, anything else is a conflict
+				if result != 0 {
+					return false
+				}
+				break
+			}
+
+			shift := uint64(bits.TrailingZeros64(coefficient))
+			start += shift
+			coefficient >>= shift
+		}
+	}
+
+	// Solve from the last slot back, each bit of a slot's solution is whatever makes its row
+	// come out to its fingerprint given the slots after it. Empty slots can be anything and
+	// are left as 0. A trailing word means windows never need bounds checks
+	words := (rf.slots+63)/64 + 1
+	rf.columns = make([][]uint64, rf.resultBits)
+	for b := range rf.columns {
+		rf.columns[b] = make([]uint64, words)
+	}
+
+	for slot := int64(rf.slots) - 1; slot >= 0; slot-- {
+		coefficient := coefficients[slot]
+		if coefficient == 0 {
+			continue
+		}
+
+		for b, column := range rf.columns {
+			bit := uint64(results[slot]>>b&1) ^ uint64(bits.OnesCount64(coefficient&window(column, uint64(slot)))&1)
+			column[slot/64] |= bit << (slot % 64)
+		}
+	}
+
+	return true
+}
+
+// window returns the 64 bits of a column starting at slot
+func window(column []uint64, slot uint64) uint64 {
+	word, offset := slot/64, slot%64
+	if offset == 0 {
+		return column[word]
+	}
+
+	return column[word]>>offset | column[word+1]<<(64-offset)
+}
+
+// Contains reports whether the key might be in the filter, false means it definitely isn't
+func (rf *RibbonFilter) Contains(key uint64) bool {
+	start, coefficient, result := rf.row(key)
+	for b, column := range rf.columns {
+		if uint16(bits.OnesCount64(coefficient&window(column, start))&1) != result>>b&1 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,57 @@
+package pds
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRibbonFilter(t *testing.T) {
+	for _, resultBits := range []uint{1, 7, 12} {
+		t.Run(fmt.Sprintf("%d bits", resultBits), func(t *testing.T) {
+			keys := xorKeys("ribbon", 50000)
+			rf, err := BuildRibbon(keys, resultBits)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, key := range keys {
+				if !rf.Contains(key) {
+					t.Fatalf("key %d went missing from the filter", i)
+				}
+			}
+
+			if bitsPerKey := float64(rf.slots*uint64(resultBits)) / float64(len(keys)); bitsPerKey > 1.2*float64(resultBits) {
+				t.Fatalf("got %.2f bits per key, wanted about %.2f", bitsPerKey, ribbonOverhead*float64(resultBits))
+			}
+
+			falsePositives := 0
+			for _, key := range xorKeys("other", 100000) {
+				if rf.Contains(key) {
+					falsePositives++
+				}
+			}
+
+			want := 1 / float64(uint(1)<<resultBits)
+			if rate := float64(falsePositives) / 110000; rate > 1.5*want+0.0005 {
+				t.Fatalf("got false positive rate %.5f, wanted about %.5f", rate, want)
+			}
+		})
+	}
+}
+
+func TestBuildRibbonRejectsResultBits(t *testing.T) {
+	for _, resultBits := range []uint{0, 17} {
+		if _, err := BuildRibbon([]uint64{1, 2, 3}, resultBits); err == nil {
+			t.Errorf("built a ribbon filter with %d result bits", resultBits)
+		}
+	}
+
+	rf, err := BuildRibbon(nil, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rf.Contains(42) && rf.Contains(43) && rf.Contains(44) {
+		t.Fatalf("an empty filter claimed every key")
+	}
+}
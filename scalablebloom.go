@@ -0,0 +1,115 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+)
+
+// Scalable Bloom filters grow each new filter by scalableGrowth times the capacity of the last
+// with scalableTightening times its false positive rate, the values Almeida et al. suggest
+const (
+	scalableGrowth     = 2
+	scalableTightening = 0.9
+)
+
+// scalableSlice is one of the filters of a ScalableBloomFilter and how many items it holds
+type scalableSlice struct {
+	filter   *BloomFilter
+	capacity uint
+	count    uint
+}
+
+// ScalableBloomFilter is a BloomFilter that keeps its false positive rate however many items
+// are added, following Almeida et al.'s "Scalable Bloom Filters". Once its newest filter is
+// full another twice its size is started, each with a tighter false positive rate than the
+// last so the rates of all of them sum to no more than the target
+type ScalableBloomFilter struct {
+	slices []scalableSlice
+	fp     float64
+	keys   keyHasher
+}
+
+// NewScalableBloomFilter builds a new ScalableBloomFilter starting with room for n items, with
+// an overall false positive rate of fp. Options set how keys are hashed, once for all filters
+func NewScalableBloomFilter(n uint, fp float64, options ...KeyOption) (*ScalableBloomFilter, error) {
+	if fp <= 0 || fp >= 1 {
+		return nil, fmt.Errorf("false positive rate needs to be in interval 0<x<1")
+	}
+
+	sbf := &ScalableBloomFilter{fp: fp, keys: newKeyHasher(options)}
+	if err := sbf.grow(n); err != nil {
+		return nil, err
+	}
+
+	return sbf, nil
+}
+
+// grow starts a new filter with room for n items. The rates fp (1 - r) r^i sum to fp
+func (sbf *ScalableBloomFilter) grow(n uint) error {
+	fp := sbf.fp * (1 - scalableTightening) * math.Pow(scalableTightening, float64(len(sbf.slices)))
+
+	filter, err := NewBloomFilter(n, fp)
+	if err != nil {
+		return err
+	}
+
+	sbf.slices = append(sbf.slices, scalableSlice{filter: filter, capacity: n})
+
+	return nil
+}
+
+// add puts the item into the newest filter unless some filter might have it already, so
+// repeats don't use up room
+func (sbf *ScalableBloomFilter) add(h uint64) {
+	if sbf.contains(h) {
+		return
+	}
+
+	newest := &sbf.slices[len(sbf.slices)-1]
+	if newest.count >= newest.capacity {
+		// Sizing only fails once the doubled capacity overflows, in which case the newest
+		// filter just keeps filling
+		if sbf.grow(newest.capacity*scalableGrowth) == nil {
+			newest = &sbf.slices[len(sbf.slices)-1]
+		}
+	}
+
+	newest.filter.add(h)
+	newest.count++
+}
+
+// contains reports whether any of the filters might have the item
+func (sbf *ScalableBloomFilter) contains(h uint64) bool {
+	for _, slice := range sbf.slices {
+		if slice.filter.contains(h) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Add hashes and puts some string into the filter
+func (sbf *ScalableBloomFilter) Add(s string) {
+	sbf.add(hashKey(&sbf.keys, s))
+}
+
+// AddBytes hashes and puts a byte slice into the filter
+func (sbf *ScalableBloomFilter) AddBytes(b []byte) {
+	sbf.add(hashKey(&sbf.keys, b))
+}
+
+// Contains reports whether some string might have been added, false means it definitely wasn't
+func (sbf *ScalableBloomFilter) Contains(s string) bool {
+	return sbf.contains(hashKey(&sbf.keys, s))
+}
+
+// ContainsBytes reports whether a byte slice might have been added
+func (sbf *ScalableBloomFilter) ContainsBytes(b []byte) bool {
+	return sbf.contains(hashKey(&sbf.keys, b))
+}
+
+// Filters returns how many filters have been started, one more each time the filter grows
+func (sbf *ScalableBloomFilter) Filters() int {
+	return len(sbf.slices)
+}
@@ -0,0 +1,55 @@
+package pds
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestScalableBloomFilterGrows(t *testing.T) {
+	sbf, err := NewScalableBloomFilter(100, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		sbf.Add(fmt.Sprintf("item-%d", i))
+	}
+
+	if sbf.Filters() < 5 {
+		t.Fatalf("got %d filters for 100 times the starting capacity", sbf.Filters())
+	}
+
+	for i := 0; i < 10000; i++ {
+		if !sbf.ContainsBytes([]byte(fmt.Sprintf("item-%d", i))) {
+			t.Fatalf("item-%d went missing", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < 100000; i++ {
+		if sbf.Contains(fmt.Sprintf("other-%d", i)) {
+			falsePositives++
+		}
+	}
+
+	if rate := float64(falsePositives) / 100000; rate > 0.015 {
+		t.Fatalf("got false positive rate %.4f, wanted at most about 0.01", rate)
+	}
+}
+
+func TestScalableBloomFilterKeyOptions(t *testing.T) {
+	calls := 0
+	sbf, err := NewScalableBloomFilter(10, 0.01, WithKeyHasher(countingHasher{calls: &calls}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each key is hashed once whichever filters it is looked up in
+	for i := 0; i < 100; i++ {
+		sbf.Add(fmt.Sprintf("item-%d", i))
+	}
+
+	if sbf.Filters() < 2 || calls != 100 {
+		t.Fatalf("got %d calls to the hasher across %d filters, wanted 100", calls, sbf.Filters())
+	}
+}
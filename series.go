@@ -0,0 +1,137 @@
+package pds
+
+import (
+	"fmt"
+	"time"
+)
+
+// HLLSeries keeps one sketch per interval of time, eg. an hour, so the distinct count of any
+// run of intervals can be got by merging them. Intervals that end before the retention
+// horizon are dropped as new items come in
+type HLLSeries struct {
+	config    HyperLogLog
+	interval  time.Duration
+	retention time.Duration
+	now       func() time.Time
+	sketches  map[int64]*HyperLogLog
+}
+
+// NewHLLSeries builds a new HLLSeries with a sketch for every interval, keeping those within
+// retention of the current time. The time comes from now, or time.Now if it is nil. Like
+// ConcurrentHyperLogLog only options about hashing and estimating apply
+func NewHLLSeries(indexBits uint32, interval time.Duration, retention time.Duration, now func() time.Time, options ...Option) (*HLLSeries, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval needs to be positive")
+	}
+
+	if retention < interval {
+		return nil, fmt.Errorf("cannot retain %v with an interval of %v", retention, interval)
+	}
+
+	hll, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		return nil, err
+	}
+	hll.Close()
+
+	if now == nil {
+		now = time.Now
+	}
+
+	return &HLLSeries{
+		config:    hll.emptyCopy(),
+		interval:  interval,
+		retention: retention,
+		now:       now,
+		sketches:  make(map[int64]*HyperLogLog),
+	}, nil
+}
+
+// intervalStart returns the start of the interval holding at, as nanoseconds since the epoch
+func (s *HLLSeries) intervalStart(at time.Time) int64 {
+	return at.Truncate(s.interval).UnixNano()
+}
+
+// Add hashes and puts some string into the interval holding the current time
+func (s *HLLSeries) Add(key string) {
+	// The current interval is always within the retention so this can't fail
+	_ = s.AddAt(key, s.now())
+}
+
+// AddAt hashes and puts some string into the interval holding at, eg. for backfilling. Times
+// past the retention horizon are rejected
+func (s *HLLSeries) AddAt(key string, at time.Time) error {
+	return s.addHashAt(s.config.hash(s.config.normalize(key)), at)
+}
+
+// addHashAt puts an already hashed item into the interval holding at
+func (s *HLLSeries) addHashAt(h uint64, at time.Time) error {
+	start := s.intervalStart(at)
+	if start+int64(s.interval) <= s.horizon() {
+		return fmt.Errorf("%v is past the retention of %v", at, s.retention)
+	}
+
+	sketch, ok := s.sketches[start]
+	if !ok {
+		// Old intervals only need dropping as often as new ones start
+		s.evict()
+
+		fresh := s.config.emptyCopy()
+		sketch = &fresh
+		s.sketches[start] = sketch
+	}
+
+	sketch.addHash(h)
+
+	return nil
+}
+
+// horizon returns the time, as nanoseconds since the epoch, before which intervals are dropped
+func (s *HLLSeries) horizon() int64 {
+	return s.now().Add(-s.retention).UnixNano()
+}
+
+// evict drops the sketches of intervals that ended before the retention horizon
+func (s *HLLSeries) evict() {
+	horizon := s.horizon()
+	for start := range s.sketches {
+		if start+int64(s.interval) <= horizon {
+			delete(s.sketches, start)
+		}
+	}
+}
+
+// Rollup merges the sketches of every retained interval overlapping from up to to, eg. the
+// 24 hourly intervals of a day, into a new sketch. Intervals are only ever taken whole
+func (s *HLLSeries) Rollup(from time.Time, to time.Time) (HyperLogLog, error) {
+	if to.Before(from) {
+		return HyperLogLog{}, fmt.Errorf("range ends at %v before it starts at %v", to, from)
+	}
+
+	s.evict()
+
+	first, end := s.intervalStart(from), to.UnixNano()
+	rollup := s.config.emptyCopy()
+	for start, sketch := range s.sketches {
+		if start < first || start >= end {
+			continue
+		}
+
+		if err := rollup.Merge(*sketch); err != nil {
+			return HyperLogLog{}, err
+		}
+	}
+
+	return rollup, nil
+}
+
+// EstimateRange returns the estimated number of distinct items added in the intervals
+// overlapping from up to to
+func (s *HLLSeries) EstimateRange(from time.Time, to time.Time) (int64, error) {
+	rollup, err := s.Rollup(from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	return rollup.EstimateCardinality(), nil
+}
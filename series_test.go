@@ -0,0 +1,113 @@
+package pds
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// hourSketch builds a sketch of the 1000 items added in hour h of TestHLLSeriesRollup
+func hourSketch(t *testing.T, h int) HyperLogLog {
+	t.Helper()
+
+	hll := filledSketch(t, 12, 0)
+	for i := 0; i < 1000; i++ {
+		hll.Add(fmt.Sprintf("hour-%d-%d", h, i))
+	}
+
+	return hll
+}
+
+func TestHLLSeriesRollup(t *testing.T) {
+	start := time.Unix(1000000, 0).Truncate(time.Hour)
+	now := start
+	series, err := NewHLLSeries(12, time.Hour, 48*time.Hour, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for h := 0; h < 6; h++ {
+		for i := 0; i < 1000; i++ {
+			if err := series.AddAt(fmt.Sprintf("hour-%d-%d", h, i), start.Add(time.Duration(h)*time.Hour+time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	now = start.Add(6 * time.Hour)
+
+	// From half way through hour 1 up to the start of hour 4 takes hours 1, 2 and 3 whole
+	from, to := start.Add(90*time.Minute), start.Add(4*time.Hour)
+	rollup, err := series.Rollup(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := hourSketch(t, 1)
+	for _, h := range []int{2, 3} {
+		if err := want.Merge(hourSketch(t, h)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !rollup.Equal(&want) {
+		t.Fatalf("rollup of hours 1 to 3 differs from merging their sketches")
+	}
+
+	estimate, err := series.EstimateRange(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if estimate != want.EstimateCardinality() {
+		t.Fatalf("got estimate %d for hours 1 to 3, wanted %d", estimate, want.EstimateCardinality())
+	}
+
+	// A range inside one interval takes all of it, and one before the first item takes nothing
+	inside, err := series.EstimateRange(start.Add(5*time.Hour+30*time.Minute), start.Add(5*time.Hour+40*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if last := hourSketch(t, 5); inside != last.EstimateCardinality() {
+		t.Fatalf("got estimate %d inside hour 5, wanted the whole hour's %d", inside, last.EstimateCardinality())
+	}
+
+	if empty, err := series.EstimateRange(start.Add(-3*time.Hour), start); err != nil || empty != 0 {
+		t.Fatalf("got estimate %d and error %v before any items, wanted 0", empty, err)
+	}
+
+	if _, err := series.Rollup(to, from); err == nil {
+		t.Fatalf("wanted an error for a range ending before it starts")
+	}
+}
+
+func TestHLLSeriesRetention(t *testing.T) {
+	start := time.Unix(1000000, 0).Truncate(time.Hour)
+	now := start
+	series, err := NewHLLSeries(12, time.Hour, 3*time.Hour, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	series.Add("early")
+	now = start.Add(5 * time.Hour)
+	series.Add("late")
+
+	// The first hour has left the retention so only the latest item is left
+	all, err := series.EstimateRange(start, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if all != 1 {
+		t.Fatalf("got estimate %d after the first hour was dropped, wanted 1", all)
+	}
+
+	if err := series.AddAt("backfill", start); err == nil {
+		t.Fatalf("wanted an error adding past the retention")
+	}
+
+	if _, err := NewHLLSeries(12, time.Hour, time.Minute, nil); err == nil {
+		t.Fatalf("wanted an error retaining less than one interval")
+	}
+}
@@ -0,0 +1,112 @@
+package pds
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// cacheLineSize pads shards apart so goroutines locking neighbouring shards don't fight over
+// the same cache line
+const cacheLineSize = 64
+
+// hllShard is one lock protected set of buckets in a ShardedHyperLogLog
+type hllShard struct {
+	mu  sync.Mutex
+	hll HyperLogLog
+	_   [cacheLineSize]byte
+}
+
+// ShardedHyperLogLog spreads adds across several independently locked HyperLogLogs so many
+// goroutines can add at once without queueing on one lock, folding them together when
+// estimating. Adds go to the shard of whichever processor the caller runs on rather than of
+// the item, so a hot key doesn't pile every goroutine onto one shard, and as taking the
+// largest value of every bucket across the shards gives back exactly the buckets of a single
+// sketch whichever shards an item landed in, sharding costs memory but no accuracy
+type ShardedHyperLogLog struct {
+	config HyperLogLog
+	shards []hllShard
+
+	// slots hands out shard indices, and as a sync.Pool keeps a cache per processor, a
+	// goroutine usually gets back the index last used on its processor
+	slots sync.Pool
+	next  atomic.Uint64
+}
+
+// NewShardedHyperLogLog builds a new ShardedHyperLogLog of shards HyperLogLogs, around
+// GOMAXPROCS shards is a good start. Like ConcurrentHyperLogLog only options about hashing
+// and estimating apply
+func NewShardedHyperLogLog(indexBits uint32, shards int, options ...Option) (*ShardedHyperLogLog, error) {
+	if shards < 1 {
+		return nil, fmt.Errorf("need at least one shard")
+	}
+
+	hll, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		return nil, err
+	}
+	hll.Close()
+
+	sharded := &ShardedHyperLogLog{
+		config: hll.emptyCopy(),
+		shards: make([]hllShard, shards),
+	}
+
+	for i := range sharded.shards {
+		sharded.shards[i].hll = hll.emptyCopy()
+	}
+
+	sharded.slots.New = func() any {
+		slot := int(sharded.next.Add(1)-1) % len(sharded.shards)
+		return &slot
+	}
+
+	return sharded, nil
+}
+
+// Add hashes and puts some string into the data structure
+func (s *ShardedHyperLogLog) Add(key string) {
+	s.addHash(s.config.hash(s.config.normalize(key)))
+}
+
+// AddBytes hashes and puts a byte slice into the data structure
+func (s *ShardedHyperLogLog) AddBytes(b []byte) {
+	s.addHash(s.config.hashBytes(b))
+}
+
+// addHash puts the hash into the shard of the caller's processor. Slots made while the pool
+// was drained can share a shard with another processor, so the shard is still locked
+func (s *ShardedHyperLogLog) addHash(h uint64) {
+	slot := s.slots.Get().(*int)
+	shard := &s.shards[*slot]
+
+	shard.mu.Lock()
+	shard.hll.addHash(h)
+	shard.mu.Unlock()
+
+	s.slots.Put(slot)
+}
+
+// Snapshot folds the shards into a plain HyperLogLog configured the same way, eg. for merging
+// or serializing. Adds running alongside may or may not make it into the copy
+func (s *ShardedHyperLogLog) Snapshot() HyperLogLog {
+	snapshot := s.config.emptyCopy()
+	for i := range s.shards {
+		shard := &s.shards[i]
+
+		shard.mu.Lock()
+		for j, bucket := range shard.hll.bucketGroup {
+			snapshot.raiseRegister(uint32(j), int(bucket))
+		}
+		shard.mu.Unlock()
+	}
+
+	return snapshot
+}
+
+// EstimateCardinality returns the cardinality estimate of everything added to any shard
+func (s *ShardedHyperLogLog) EstimateCardinality() int64 {
+	snapshot := s.Snapshot()
+
+	return snapshot.bucketEstimate()
+}
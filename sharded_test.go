@@ -0,0 +1,75 @@
+package pds
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestShardedMatchesSingleSketch(t *testing.T) {
+	sharded, err := NewShardedHyperLogLog(12, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	single := filledSketch(t, 12, 20000)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < 20000; i += 16 {
+				sharded.Add(fmt.Sprintf("item-%d", i))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	snapshot := sharded.Snapshot()
+	if !slices.Equal(snapshot.registers(), single.registers()) {
+		t.Fatalf("folded shards differ from a single sketch of the same items")
+	}
+
+	if got, want := sharded.EstimateCardinality(), single.EstimateCardinality(); got != want {
+		t.Fatalf("got estimate %d, wanted %d", got, want)
+	}
+}
+
+func TestShardedSpreadsOneHotKey(t *testing.T) {
+	sharded, err := NewShardedHyperLogLog(8, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every goroutine adding the same key still counts it once
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				sharded.Add("hot")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := sharded.EstimateCardinality(); got != 1 {
+		t.Fatalf("got estimate %d for one key, wanted 1", got)
+	}
+}
+
+func BenchmarkShardedHotKey(b *testing.B) {
+	sharded, err := NewShardedHyperLogLog(14, 8)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			sharded.Add("hot")
+		}
+	})
+}
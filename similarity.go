@@ -0,0 +1,85 @@
+package pds
+
+import (
+	"math"
+)
+
+// EstimateIntersection estimates how many items both sketches have seen by inclusion-exclusion,
+// |A| + |B| - |A∪B|. Its absolute error is around that of the union estimate,
+// 1.04/sqrt(m) * |A∪B|, so it is only useful when the overlap is a decent share of the
+// union and the relative error blows up as the overlap shrinks. All three terms are estimated
+// with a's estimator and corrections, and both sketches need the same index bits, hash width
+// and seed
+func EstimateIntersection(a, b HyperLogLog) (int64, error) {
+	union, err := EstimateUnionCardinality([]*HyperLogLog{&a, &b})
+	if err != nil {
+		return 0, err
+	}
+
+	// Every term is estimated the same way as the union so their errors line up
+	countA, countB := a.estimateBuckets(a.registers()), a.estimateBuckets(b.registers())
+	intersection := countA + countB - union
+
+	return min(max(intersection, 0), countA, countB), nil
+}
+
+// Containment estimates what fraction of this sketch's items are also in the other sketch,
+// |A∩B| / |A|, using EstimateIntersection. Both sketches need the same index bits,
+// hash width and seed
+func (hll *HyperLogLog) Containment(other *HyperLogLog) (float64, error) {
+	intersection, err := EstimateIntersection(*hll, *other)
+	if err != nil {
+		return 0, err
+	}
+
+	count := hll.estimateBuckets(hll.registers())
+	if count == 0 {
+		return 0, nil
+	}
+
+	return min(float64(intersection)/float64(count), 1), nil
+}
+
+// JaccardEstimate estimates the Jaccard similarity of the sets behind both sketches,
+// |A∩B| / |A∪B|, from EstimateIntersection and the union estimate, or 0 when both are empty.
+// It carries the intersection's error so is rough for sets that barely overlap, a
+// SimilaritySketch does better there. Both sketches need the same index bits,
+// hash width and seed
+func JaccardEstimate(a, b HyperLogLog) (float64, error) {
+	union, err := EstimateUnionCardinality([]*HyperLogLog{&a, &b})
+	if err != nil {
+		return 0, err
+	}
+
+	if union == 0 {
+		return 0, nil
+	}
+
+	intersection, err := EstimateIntersection(a, b)
+	if err != nil {
+		return 0, err
+	}
+
+	return min(float64(intersection)/float64(union), 1), nil
+}
+
+// ProbablySame reports whether both sketches likely counted the same set, meaning their
+// estimated Jaccard similarity is above 1 - tolerance and their estimates are within
+// tolerance of each other. Very similar sketches can still come from different sets, this
+// is only good for spotting likely duplicates. Both sketches need the same index bits,
+// hash width and seed
+func (hll *HyperLogLog) ProbablySame(other *HyperLogLog, tolerance float64) (bool, error) {
+	jaccard, err := JaccardEstimate(*hll, *other)
+	if err != nil {
+		return false, err
+	}
+
+	a, b := hll.estimateBuckets(hll.registers()), hll.estimateBuckets(other.registers())
+	if a == 0 && b == 0 {
+		return true, nil
+	}
+
+	countDifference := math.Abs(float64(a-b)) / float64(max(a, b))
+
+	return jaccard > 1-tolerance && countDifference <= tolerance, nil
+}
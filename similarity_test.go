@@ -0,0 +1,182 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// overlappingSketches builds sketches of items [0, aEnd) and [bStart, bEnd)
+func overlappingSketches(t *testing.T, aEnd, bStart, bEnd int, options ...Option) (HyperLogLog, HyperLogLog) {
+	t.Helper()
+
+	a, err := NewHyperLogLog(14, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewHyperLogLog(14, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < aEnd; i++ {
+		a.Add(fmt.Sprint(i))
+	}
+
+	for i := bStart; i < bEnd; i++ {
+		b.Add(fmt.Sprint(i))
+	}
+
+	return a, b
+}
+
+func TestEstimateIntersection(t *testing.T) {
+	a, b := overlappingSketches(t, 100000, 50000, 150000)
+
+	intersection, err := EstimateIntersection(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The error is around that of the union, 1.04/sqrt(m) of 150000
+	if math.Abs(float64(intersection)-50000) > 3*1.04/128*150000 {
+		t.Fatalf("got intersection %d, wanted about 50000", intersection)
+	}
+}
+
+func TestSimilarityUsesSketchEstimator(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		options []Option
+	}{
+		{name: "harmonic mean"},
+		{name: "maximum likelihood", options: []Option{WithMLEstimator()}},
+		{name: "loglog beta", options: []Option{WithLogLogBeta()}},
+		{name: "bias corrected", options: []Option{WithBiasCorrection()}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			// Mid range, where estimators and corrections disagree the most
+			a, b := overlappingSketches(t, 40000, 0, 40000, test.options...)
+
+			union, err := EstimateUnionCardinality([]*HyperLogLog{&a, &b})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if union != a.EstimateCardinality() {
+				t.Errorf("got union %d of identical sketches, wanted their estimate %d", union, a.EstimateCardinality())
+			}
+
+			intersection, err := EstimateIntersection(a, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if intersection != a.EstimateCardinality() {
+				t.Errorf("got intersection %d of identical sketches, wanted their estimate %d", intersection, a.EstimateCardinality())
+			}
+
+			jaccard, err := JaccardEstimate(a, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if jaccard != 1 {
+				t.Errorf("got Jaccard %f for identical sketches, wanted 1", jaccard)
+			}
+
+			containment, err := a.Containment(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if containment != 1 {
+				t.Errorf("got containment %f for identical sketches, wanted 1", containment)
+			}
+		})
+	}
+}
+
+func TestContainment(t *testing.T) {
+	for _, test := range []struct {
+		name              string
+		aEnd, bStart, end int
+		want              float64
+	}{
+		{name: "subset", aEnd: 20000, bStart: 0, end: 100000, want: 1},
+		{name: "disjoint", aEnd: 50000, bStart: 50000, end: 100000, want: 0},
+		{name: "partial", aEnd: 100000, bStart: 50000, end: 150000, want: 0.5},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := overlappingSketches(t, test.aEnd, test.bStart, test.end)
+
+			containment, err := a.Containment(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if containment < 0 || containment > 1 || math.Abs(containment-test.want) > 0.1 {
+				t.Fatalf("got containment %f, wanted about %f", containment, test.want)
+			}
+		})
+	}
+
+	a := filledSketch(t, 14, 10)
+	b := filledSketch(t, 12, 10)
+	if _, err := a.Containment(&b); err == nil {
+		t.Fatalf("wanted an error comparing 14 and 12 index bits")
+	}
+}
+
+func TestJaccardEstimate(t *testing.T) {
+	a, b := overlappingSketches(t, 100000, 50000, 150000)
+
+	jaccard, err := JaccardEstimate(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(jaccard-1.0/3) > 0.05 {
+		t.Fatalf("got Jaccard %f, wanted about 0.33", jaccard)
+	}
+
+	empty, _ := overlappingSketches(t, 0, 0, 0)
+	if jaccard, err := JaccardEstimate(empty, empty); err != nil || jaccard != 0 {
+		t.Fatalf("got Jaccard %f and error %v for empty sketches, wanted 0", jaccard, err)
+	}
+}
+
+func TestProbablySame(t *testing.T) {
+	for _, test := range []struct {
+		name              string
+		aEnd, bStart, end int
+		want              bool
+	}{
+		{name: "identical", aEnd: 100000, bStart: 0, end: 100000, want: true},
+		{name: "near identical", aEnd: 100000, bStart: 0, end: 100500, want: true},
+		{name: "clearly different", aEnd: 100000, bStart: 40000, end: 140000, want: false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := overlappingSketches(t, test.aEnd, test.bStart, test.end)
+
+			same, err := a.ProbablySame(&b, 0.05)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if same != test.want {
+				t.Fatalf("got %t, wanted %t", same, test.want)
+			}
+		})
+	}
+}
+
+func TestProbablySameRejectsDifferentIndexBits(t *testing.T) {
+	a := filledSketch(t, 14, 10)
+	b := filledSketch(t, 12, 10)
+
+	if _, err := a.ProbablySame(&b, 0.05); err == nil {
+		t.Fatalf("wanted an error comparing 14 and 12 index bits")
+	}
+}
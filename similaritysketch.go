@@ -0,0 +1,126 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+)
+
+// minHashSeed keeps the MinHash hashes apart from the HyperLogLog's unseeded ones
+const minHashSeed = 0x9e3779b97f4a7c15
+
+// mix64 is the murmur3 64 bit finalizer, used to spread out the derived MinHash hashes
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+
+	return h
+}
+
+// splitMix64 generates a repeatable sequence of random numbers for the structures that need
+// to make random choices, by running mix64 over a counter
+type splitMix64 uint64
+
+// next returns the next number in the sequence
+func (s *splitMix64) next() uint64 {
+	*s += 0x9e3779b97f4a7c15
+
+	return mix64(uint64(*s))
+}
+
+// SimilaritySketch keeps a MinHash signature and a HyperLogLog of the same stream, giving
+// set similarity and cardinality estimates from one Add
+type SimilaritySketch struct {
+	hll       HyperLogLog
+	signature []uint64
+}
+
+// NewSimilaritySketch builds a new SimilaritySketch with a MinHash signature of signatureSize hashes
+func NewSimilaritySketch(indexBits uint32, signatureSize int, options ...Option) (SimilaritySketch, error) {
+	if signatureSize < 1 {
+		return SimilaritySketch{}, fmt.Errorf("signature size needs to be at least 1")
+	}
+
+	hll, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		return SimilaritySketch{}, err
+	}
+
+	signature := make([]uint64, signatureSize)
+	for i := range signature {
+		signature[i] = math.MaxUint64
+	}
+
+	return SimilaritySketch{
+		hll:       hll,
+		signature: signature,
+	}, nil
+}
+
+// Add puts s into both the HyperLogLog and the MinHash signature. The signature's hashes are
+// all derived from one seeded 64 bit hash, kept apart from the HyperLogLog's by the seed, with
+// any seed given by WithSeed mixed in
+func (ss *SimilaritySketch) Add(s string) {
+	s = ss.hll.normalize(s)
+	ss.hll.addNormalized(s)
+
+	h := ss.hll.hasher.Hash64([]byte(s), minHashSeed^ss.hll.seed)
+	h1, h2 := h, mix64(h)|1
+	for i := range ss.signature {
+		if derived := mix64(h1 + uint64(i)*h2); derived < ss.signature[i] {
+			ss.signature[i] = derived
+		}
+	}
+}
+
+// Count returns the estimated number of distinct items added
+func (ss *SimilaritySketch) Count() int64 {
+	return ss.hll.EstimateCardinality()
+}
+
+// Jaccard estimates |A∩B| / |A∪B| from the share of MinHash signature slots both sketches
+// agree on. The sketches need to be compatible, as differently seeded ones hash the same item
+// to different signature values
+func (ss *SimilaritySketch) Jaccard(other *SimilaritySketch) (float64, error) {
+	if err := ss.hll.compatible(&other.hll); err != nil {
+		return 0, fmt.Errorf("cannot compare sketches: %w", err)
+	}
+
+	if len(ss.signature) != len(other.signature) {
+		return 0, fmt.Errorf("cannot compare signatures of %d and %d hashes", len(ss.signature), len(other.signature))
+	}
+
+	// Two empty sketches agree everywhere without sharing anything
+	if ss.signature[0] == math.MaxUint64 && other.signature[0] == math.MaxUint64 {
+		return 0, nil
+	}
+
+	var matches int
+	for i, value := range ss.signature {
+		if value == other.signature[i] {
+			matches++
+		}
+	}
+
+	return float64(matches) / float64(len(ss.signature)), nil
+}
+
+// Containment estimates |A∩B| / |A| by combining the MinHash Jaccard with both HyperLogLog
+// counts, since |A∩B| = J * |A∪B| and |A∪B| = (|A| + |B|) / (1 + J)
+func (ss *SimilaritySketch) Containment(other *SimilaritySketch) (float64, error) {
+	jaccard, err := ss.Jaccard(other)
+	if err != nil {
+		return 0, err
+	}
+
+	a, b := float64(ss.hll.estimate()), float64(other.hll.estimate())
+	if a == 0 {
+		return 0, nil
+	}
+
+	containment := jaccard * (a + b) / ((1 + jaccard) * a)
+
+	return min(max(containment, 0), 1), nil
+}
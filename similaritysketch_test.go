@@ -0,0 +1,136 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// minHashJaccard is a plain MinHash with one independently seeded hash per slot, to check the
+// derived hashes of SimilaritySketch against
+func minHashJaccard(a, b []string, size int) float64 {
+	signature := func(keys []string) []uint64 {
+		sig := make([]uint64, size)
+		for i := range sig {
+			sig[i] = math.MaxUint64
+			for _, key := range keys {
+				sig[i] = min(sig[i], mix64(FNVHasher{}.Hash64([]byte(key), mix64(uint64(i)+1))))
+			}
+		}
+
+		return sig
+	}
+
+	sigA, sigB := signature(a), signature(b)
+
+	var matches int
+	for i := range sigA {
+		if sigA[i] == sigB[i] {
+			matches++
+		}
+	}
+
+	return float64(matches) / float64(size)
+}
+
+func TestSimilaritySketchMatchesStandalone(t *testing.T) {
+	const size = 256
+
+	a, err := NewSimilaritySketch(12, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewSimilaritySketch(12, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hll, err := NewHyperLogLog(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A is items [0, 3000) and B is [1000, 4000), so J = 2000/4000 and A∩B/A = 2/3
+	var keysA, keysB []string
+	for i := 0; i < 4000; i++ {
+		key := fmt.Sprintf("item-%d", i)
+		if i < 3000 {
+			a.Add(key)
+			hll.Add(key)
+			keysA = append(keysA, key)
+		}
+
+		if i >= 1000 {
+			b.Add(key)
+			keysB = append(keysB, key)
+		}
+	}
+
+	if a.Count() != hll.EstimateCardinality() {
+		t.Fatalf("got count %d, wanted the standalone estimate %d", a.Count(), hll.EstimateCardinality())
+	}
+
+	jaccard, err := a.Jaccard(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each stays within about three standard errors, sqrt(J(1-J)/k) or around 0.03, of the
+	// truth and so of each other
+	standalone := minHashJaccard(keysA, keysB, size)
+	if math.Abs(jaccard-0.5) > 0.1 || math.Abs(standalone-0.5) > 0.1 || math.Abs(jaccard-standalone) > 0.15 {
+		t.Fatalf("got Jaccard %f against %f from a standalone MinHash, wanted both about 0.5", jaccard, standalone)
+	}
+
+	containment, err := a.Containment(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(containment-2.0/3) > 0.1 {
+		t.Fatalf("got containment %f, wanted about 0.67", containment)
+	}
+}
+
+func TestSimilaritySketchRejectsMismatches(t *testing.T) {
+	small, err := NewSimilaritySketch(12, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	large, err := NewSimilaritySketch(12, 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := small.Jaccard(&large); err == nil {
+		t.Errorf("compared signatures of 64 and 128 hashes")
+	}
+
+	other, err := NewSimilaritySketch(10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := small.Jaccard(&other); err == nil {
+		t.Errorf("compared sketches of 12 and 10 index bits")
+	}
+
+	if _, err := small.Containment(&other); err == nil {
+		t.Errorf("compared sketches of 12 and 10 index bits")
+	}
+
+	seeded, err := NewSimilaritySketch(12, 64, WithSeed(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := small.Jaccard(&seeded); err == nil {
+		t.Errorf("compared differently seeded sketches")
+	}
+
+	if _, err := NewSimilaritySketch(12, 0); err == nil {
+		t.Errorf("wanted an error for an empty signature")
+	}
+}
@@ -0,0 +1,64 @@
+package pds
+
+// SingletonEstimator estimates how many distinct items were seen exactly once, eg. one-off
+// visitors or cards used a single time, which a HyperLogLog alone can't tell apart from the
+// rest. Every item goes into a HyperLogLog of all distinct items and a CountMinSketch, and an
+// item the sketch has already counted also goes into a second HyperLogLog of repeated items,
+// the singletons being the difference between the two estimates
+//
+// The errors compound. Both estimates are off by about 1.04/sqrt(m) of their own counts, so
+// the difference is off by about 1.04/sqrt(m) * sqrt(distinct^2 + repeated^2), large next to
+// the singleton count when most items repeat. On top of that a first sighting colliding in
+// every row of the CountMinSketch with items already counted is taken for a repeat, so the
+// count leans low as the sketch fills, by at most about epsilon times the items added
+type SingletonEstimator struct {
+	distinct HyperLogLog
+	repeated HyperLogLog
+	counts   *CountMinSketch
+}
+
+// NewSingletonEstimator builds a new SingletonEstimator with HyperLogLogs of indexBits index
+// bits and a CountMinSketch sized by epsilon and delta like NewCountMinSketch. Like
+// ConcurrentHyperLogLog only options about hashing and estimating apply
+func NewSingletonEstimator(indexBits uint32, epsilon float64, delta float64, options ...Option) (*SingletonEstimator, error) {
+	hll, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		return nil, err
+	}
+	hll.Close()
+
+	counts, err := NewCountMinSketch(epsilon, delta)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SingletonEstimator{
+		distinct: hll.emptyCopy(),
+		repeated: hll.emptyCopy(),
+		counts:   counts,
+	}, nil
+}
+
+// Add counts an occurrence of s, marking it as repeated if it was probably seen before
+func (se *SingletonEstimator) Add(s string) {
+	key := se.distinct.normalize(s)
+	h := se.distinct.hash(key)
+
+	if se.counts.Estimate(key) > 0 {
+		se.repeated.addHash(h)
+	}
+
+	se.distinct.addHash(h)
+	se.counts.Add(key, 1)
+}
+
+// Distinct returns the estimated number of distinct items added
+func (se *SingletonEstimator) Distinct() int64 {
+	return se.distinct.EstimateCardinality()
+}
+
+// SingletonCount returns the estimated number of distinct items added exactly once, never
+// below zero
+func (se *SingletonEstimator) SingletonCount() int64 {
+	return max(se.distinct.EstimateCardinality()-se.repeated.EstimateCardinality(), 0)
+}
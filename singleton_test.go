@@ -0,0 +1,73 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestSingletonCountTracksSingletons(t *testing.T) {
+	for _, test := range []struct {
+		singletons int
+		repeats    int
+	}{
+		{singletons: 20000, repeats: 5000},
+		{singletons: 10000, repeats: 10000},
+		{singletons: 5000, repeats: 20000},
+	} {
+		t.Run(fmt.Sprintf("%d singletons %d repeats", test.singletons, test.repeats), func(t *testing.T) {
+			se, err := NewSingletonEstimator(14, 0.0001, 0.01)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Random keys keep the test about the singleton estimate rather than how well
+			// FNV-1a spreads similar keys
+			rng := rand.New(rand.NewPCG(1, 2))
+			for i := 0; i < test.singletons; i++ {
+				se.Add(fmt.Sprintf("once-%x", rng.Uint64()))
+			}
+
+			repeats := make([]string, test.repeats)
+			for i := range repeats {
+				repeats[i] = fmt.Sprintf("again-%x", rng.Uint64())
+			}
+
+			// Repeats are seen two to four times, interleaved with each other
+			for round := 0; round < 4; round++ {
+				for i, key := range repeats {
+					if round < 2 || i%(round+1) == 0 {
+						se.Add(key)
+					}
+				}
+			}
+
+			distinct := float64(test.singletons + test.repeats)
+			if got := float64(se.Distinct()); math.Abs(got-distinct)/distinct > 0.03 {
+				t.Fatalf("got %.0f distinct, wanted about %.0f", got, distinct)
+			}
+
+			// Within three standard errors of the difference of the two estimates
+			tolerance := 3 * 1.04 / math.Sqrt(1<<14) * math.Hypot(distinct, float64(test.repeats))
+			if got := float64(se.SingletonCount()); math.Abs(got-float64(test.singletons)) > tolerance {
+				t.Fatalf("got %.0f singletons, wanted %d within %.0f", got, test.singletons, tolerance)
+			}
+		})
+	}
+}
+
+func TestSingletonCountNeverNegative(t *testing.T) {
+	se, err := NewSingletonEstimator(4, 0.01, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		se.Add(fmt.Sprintf("item-%d", i%100))
+	}
+
+	if se.SingletonCount() < 0 {
+		t.Fatalf("got %d singletons", se.SingletonCount())
+	}
+}
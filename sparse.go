@@ -0,0 +1,230 @@
+package pds
+
+import (
+	"encoding/binary"
+	"math"
+	"slices"
+)
+
+const (
+	// sparseValueBits is how many low bits of each encoded pair hold the bucket value
+	sparseValueBits = 6
+	sparseValueMask = 1<<sparseValueBits - 1
+
+	// sparsePendingSize is how many pairs are buffered before being merged into the encoding
+	sparsePendingSize = 64
+
+	// sparseDenseFraction converts to dense buckets once the encoding takes more than a
+	// quarter of a byte per bucket, past that lookups through it cost more than it saves
+	sparseDenseFraction = 4
+)
+
+// sparseRegisters holds only the non empty buckets, sorted by index with each encoded as a
+// uvarint of the gap from the previous index shifted over the value. Adds are buffered
+// unsorted in pending and merged in once enough have built up
+type sparseRegisters struct {
+	data    []byte
+	pairs   int
+	pending []uint32
+}
+
+// pack puts a bucket index and value into a single pending pair
+func pack(index uint32, value int) uint32 {
+	return index<<sparseValueBits | uint32(value)
+}
+
+// decode returns every encoded pair in index order
+func (sr *sparseRegisters) decode() []uint32 {
+	pairs := make([]uint32, 0, sr.pairs+len(sr.pending))
+
+	var index uint32
+	for data := sr.data; len(data) > 0; {
+		encoded, n := binary.Uvarint(data)
+		data = data[n:]
+
+		index += uint32(encoded >> sparseValueBits)
+		pairs = append(pairs, pack(index, int(encoded&sparseValueMask)))
+	}
+
+	return pairs
+}
+
+// flush merges the pending pairs into the encoding, keeping the largest value for each index
+func (sr *sparseRegisters) flush() {
+	if len(sr.pending) == 0 {
+		return
+	}
+
+	// Sorting the packed pairs orders them by index then value, so the last pair of each
+	// index holds its largest value
+	pairs := append(sr.decode(), sr.pending...)
+	slices.Sort(pairs)
+
+	sr.data = sr.data[:0]
+	sr.pairs = 0
+
+	var previous uint32
+	for i, pair := range pairs {
+		index := pair >> sparseValueBits
+		if i+1 < len(pairs) && pairs[i+1]>>sparseValueBits == index {
+			continue
+		}
+
+		sr.data = binary.AppendUvarint(sr.data, uint64(index-previous)<<sparseValueBits|uint64(pair&sparseValueMask))
+		sr.pairs++
+		previous = index
+	}
+
+	sr.pending = sr.pending[:0]
+}
+
+// get returns the value of the bucket at index
+func (sr *sparseRegisters) get(index uint32) int {
+	var value int
+	for _, pair := range sr.pending {
+		if pair>>sparseValueBits == index {
+			value = max(value, int(pair&sparseValueMask))
+		}
+	}
+
+	var current uint32
+	for data := sr.data; len(data) > 0; {
+		encoded, n := binary.Uvarint(data)
+		data = data[n:]
+
+		current += uint32(encoded >> sparseValueBits)
+		if current >= index {
+			if current == index {
+				value = max(value, int(encoded&sparseValueMask))
+			}
+
+			break
+		}
+	}
+
+	return value
+}
+
+// raise sets the bucket at index to value if that is larger, returning whether it was
+func (sr *sparseRegisters) raise(index uint32, value int) bool {
+	if value <= 0 || sr.get(index) >= value {
+		return false
+	}
+
+	sr.pending = append(sr.pending, pack(index, value))
+	if len(sr.pending) >= sparsePendingSize {
+		sr.flush()
+	}
+
+	return true
+}
+
+// size returns roughly how many bytes the registers take up
+func (sr *sparseRegisters) size() int {
+	return len(sr.data) + bytesIn32Bits*len(sr.pending)
+}
+
+// harmonicSum adds up 2^-value over all mBuckets buckets along with how many are empty,
+// without expanding them
+func (sr *sparseRegisters) harmonicSum(mBuckets int64) (float64, float64) {
+	sr.flush()
+
+	zeros := float64(mBuckets - int64(sr.pairs))
+	total := zeros
+	for _, pair := range sr.decode() {
+		total += math.Pow(2, -float64(pair&sparseValueMask))
+	}
+
+	return total, zeros
+}
+
+// reset empties the registers while keeping their buffers
+func (sr *sparseRegisters) reset() {
+	sr.data = sr.data[:0]
+	sr.pairs = 0
+	sr.pending = sr.pending[:0]
+}
+
+// registers returns the buckets, expanding them into a new group if the sketch is still
+// sparse. Changes to the result only stick for dense sketches, see denseRegisters
+func (hll *HyperLogLog) registers() bucketGroup {
+	if hll.sparse == nil {
+		return hll.bucketGroup
+	}
+
+	hll.sparse.flush()
+
+	bg := newBucketGroup(hll.mBuckets)
+	for _, pair := range hll.sparse.decode() {
+		bg[pair>>sparseValueBits] = uint8(pair & sparseValueMask)
+	}
+
+	return bg
+}
+
+// denseRegisters converts a sparse sketch to dense buckets for good and returns them
+func (hll *HyperLogLog) denseRegisters() bucketGroup {
+	if hll.sparse != nil {
+		hll.bucketGroup = hll.registers()
+		hll.sparse = nil
+	}
+
+	return hll.bucketGroup
+}
+
+// register returns the value of the bucket at index
+func (hll *HyperLogLog) register(index uint32) int {
+	if hll.sparse != nil {
+		return hll.sparse.get(index)
+	}
+
+	return int(hll.bucketGroup[index])
+}
+
+// raiseRegister sets the bucket at index to value if that is larger, returning whether it
+// was. Sparse sketches turn dense once they grow too big
+func (hll *HyperLogLog) raiseRegister(index uint32, value int) bool {
+	if hll.sparse == nil {
+		previous := int(hll.bucketGroup[index])
+		if previous >= value {
+			return false
+		}
+
+		hll.bucketGroup[index] = uint8(value)
+		hll.cacheValid = false
+		hll.moveLiveHistogram(previous, value)
+		return true
+	}
+
+	var previous int
+	if hll.liveHistogram != nil {
+		previous = hll.sparse.get(index)
+	}
+
+	if !hll.sparse.raise(index, value) {
+		return false
+	}
+
+	hll.cacheValid = false
+	hll.moveLiveHistogram(previous, value)
+
+	if int64(hll.sparse.size()) > hll.mBuckets/sparseDenseFraction {
+		hll.denseRegisters()
+	}
+
+	return true
+}
+
+// harmonicSum adds up 2^-value over every bucket along with how many are empty, split across
+// goroutines if WithParallelEstimate asked for it
+func (hll *HyperLogLog) harmonicSum() (float64, float64) {
+	if hll.sparse != nil {
+		return hll.sparse.harmonicSum(hll.mBuckets)
+	}
+
+	if hll.estimateWorkers > 1 && len(hll.bucketGroup) >= minParallelBuckets {
+		return hll.bucketGroup.parallelHarmonicSum(hll.estimateWorkers)
+	}
+
+	return hll.bucketGroup.harmonicSum(1)
+}
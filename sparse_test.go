@@ -0,0 +1,98 @@
+package pds
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSparseMatchesDense(t *testing.T) {
+	sparse, err := NewHyperLogLog(12, WithSparseRepresentation())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dense, err := NewHyperLogLog(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Checked along the way while sparse and again once it has turned dense
+	added := 0
+	for _, n := range []int{10, 100, 500, 20000} {
+		for ; added < n; added++ {
+			item := fmt.Sprintf("item-%d", added)
+			sparse.Add(item)
+			dense.Add(item)
+		}
+
+		if !slices.Equal(sparse.registers(), dense.registers()) {
+			t.Fatalf("buckets differ after %d items", n)
+		}
+
+		if sparse.EstimateCardinality() != dense.EstimateCardinality() {
+			t.Fatalf("got estimate %d after %d items, wanted %d", sparse.EstimateCardinality(), n, dense.EstimateCardinality())
+		}
+	}
+
+	if sparse.sparse != nil {
+		t.Fatalf("still sparse after filling most buckets")
+	}
+}
+
+func TestSparseStaysSmall(t *testing.T) {
+	hll := filledSketch(t, 14, 100, WithSparseRepresentation())
+
+	if hll.sparse == nil {
+		t.Fatalf("turned dense after 100 items")
+	}
+
+	if size := hll.sparse.size(); int64(size) > hll.mBuckets/sparseDenseFraction {
+		t.Fatalf("sparse buckets take %d bytes, more than dense would allow", size)
+	}
+}
+
+func TestSparseEncodingAndMerge(t *testing.T) {
+	hll := filledSketch(t, 10, 50, WithSparseRepresentation())
+	want := filledSketch(t, 10, 50)
+
+	data, err := hll.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded HyperLogLog
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(decoded.registers(), want.registers()) {
+		t.Fatalf("buckets differ after decoding a sparse sketch")
+	}
+
+	merged, err := NewHyperLogLog(10, WithSparseRepresentation())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := merged.Merge(hll); err != nil {
+		t.Fatal(err)
+	}
+
+	if !merged.Equal(&want) {
+		t.Fatalf("buckets differ after merging into a sparse sketch")
+	}
+}
+
+func TestSparseWithAsyncEstimateIsDense(t *testing.T) {
+	hll, err := NewHyperLogLog(10, WithSparseRepresentation(), WithAsyncEstimate(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hll.Close()
+
+	if hll.sparse != nil || len(hll.bucketGroup) != int(hll.mBuckets) {
+		t.Fatalf("kept a sparse representation alongside the background estimate")
+	}
+}
@@ -0,0 +1,123 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+)
+
+// StableBloomFilter spots duplicates in an endless stream in fixed memory, following Deng and
+// Rafiei's "Approximately Detecting Duplicates for Streaming Data using Stable Bloom Filters".
+// Every add first decrements a few cells chosen at random, then sets the item's cells to the
+// largest value. Old items fade out so the share of set cells, and with it the false positive
+// rate, settles at a stable level, at the cost of sometimes forgetting items that were added
+type StableBloomFilter struct {
+	cells      []uint8
+	kHashes    uint32
+	maxValue   uint8
+	decrements uint
+	rng        splitMix64
+	keys       keyHasher
+}
+
+// NewStableBloomFilter builds a new StableBloomFilter of cells cells, setting hashes of them
+// to maxValue for every item and decrementing decrements of them before each add. More
+// decrements forget items sooner but keep fewer cells set, StableDecrements works out how
+// many give a target false positive rate. Options set how keys are hashed
+func NewStableBloomFilter(cells uint, hashes uint32, maxValue uint8, decrements uint, options ...KeyOption) (*StableBloomFilter, error) {
+	if cells == 0 || hashes == 0 || maxValue == 0 {
+		return nil, fmt.Errorf("need at least one cell, one hash and a max value of at least 1")
+	}
+
+	if decrements > cells {
+		return nil, fmt.Errorf("cannot decrement %d of %d cells", decrements, cells)
+	}
+
+	return &StableBloomFilter{
+		cells:      make([]uint8, cells),
+		kHashes:    hashes,
+		maxValue:   maxValue,
+		decrements: decrements,
+		keys:       newKeyHasher(options),
+	}, nil
+}
+
+// StableDecrements returns how many cells to decrement per add for a StableBloomFilter to settle
+// at a false positive rate of fp, from the paper's solution of its stable point
+func StableDecrements(cells uint, hashes uint32, maxValue uint8, fp float64) (uint, error) {
+	if fp <= 0 || fp >= 1 {
+		return 0, fmt.Errorf("false positive rate needs to be in interval 0<x<1")
+	}
+
+	if cells == 0 || hashes == 0 || maxValue == 0 {
+		return 0, fmt.Errorf("need at least one cell, one hash and a max value of at least 1")
+	}
+
+	k, m := float64(hashes), float64(cells)
+	denominator := (math.Pow(1/(1-math.Pow(fp, 1/k)), 1/float64(maxValue)) - 1) * (1/k - 1/m)
+	decrements := math.Ceil(1 / denominator)
+
+	if denominator <= 0 || decrements > m {
+		return 0, fmt.Errorf("no number of decrements reaches a false positive rate of %v", fp)
+	}
+
+	return uint(max(decrements, 1)), nil
+}
+
+// decay decrements a run of cells starting at a random one, which the paper shows behaves
+// like picking each independently
+func (sbf *StableBloomFilter) decay() {
+	start := sbf.rng.next() % uint64(len(sbf.cells))
+	for i := uint64(0); i < uint64(sbf.decrements); i++ {
+		cell := &sbf.cells[(start+i)%uint64(len(sbf.cells))]
+		if *cell > 0 {
+			*cell--
+		}
+	}
+}
+
+// contains reports whether every one of the item's cells is above zero
+func (sbf *StableBloomFilter) contains(h uint64) bool {
+	h1, h2 := bloomHashes(h)
+	for i := uint64(0); i < uint64(sbf.kHashes); i++ {
+		if sbf.cells[(h1+i*h2)%uint64(len(sbf.cells))] == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// testAndAdd reports whether the item was probably seen recently, then decays the filter and
+// adds the item
+func (sbf *StableBloomFilter) testAndAdd(h uint64) bool {
+	seen := sbf.contains(h)
+	sbf.decay()
+
+	h1, h2 := bloomHashes(h)
+	for i := uint64(0); i < uint64(sbf.kHashes); i++ {
+		sbf.cells[(h1+i*h2)%uint64(len(sbf.cells))] = sbf.maxValue
+	}
+
+	return seen
+}
+
+// Add hashes and puts some string into the filter
+func (sbf *StableBloomFilter) Add(s string) {
+	sbf.testAndAdd(hashKey(&sbf.keys, s))
+}
+
+// Contains reports whether some string was probably added recently
+func (sbf *StableBloomFilter) Contains(s string) bool {
+	return sbf.contains(hashKey(&sbf.keys, s))
+}
+
+// TestAndAdd reports whether some string is a probable duplicate of one added recently and
+// adds it, the usual way to deduplicate a stream
+func (sbf *StableBloomFilter) TestAndAdd(s string) bool {
+	return sbf.testAndAdd(hashKey(&sbf.keys, s))
+}
+
+// TestAndAddBytes reports whether a byte slice is a probable duplicate and adds it
+func (sbf *StableBloomFilter) TestAndAddBytes(b []byte) bool {
+	return sbf.testAndAdd(hashKey(&sbf.keys, b))
+}
@@ -0,0 +1,52 @@
+package pds
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStableBloomFilterSpotsRecentDuplicates(t *testing.T) {
+	decrements, err := StableDecrements(10000, 3, 3, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sbf, err := NewStableBloomFilter(10000, 3, 3, decrements)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Items repeated straight away are all caught
+	for i := 0; i < 100000; i++ {
+		key := fmt.Sprintf("item-%d", i)
+		sbf.Add(key)
+		if !sbf.TestAndAddBytes([]byte(key)) {
+			t.Fatalf("%s was missed straight after adding it", key)
+		}
+	}
+
+	// Once the stream has run a long while the false positive rate stays near its target
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if sbf.TestAndAdd(fmt.Sprintf("other-%d", i)) {
+			falsePositives++
+		}
+	}
+
+	if rate := float64(falsePositives) / 10000; rate > 0.02 {
+		t.Fatalf("got false positive rate %.4f, wanted about 0.01", rate)
+	}
+}
+
+func TestStableBloomFilterKeyOptions(t *testing.T) {
+	calls := 0
+	sbf, err := NewStableBloomFilter(100, 2, 1, 1, WithKeyHasher(countingHasher{calls: &calls}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sbf.Add("a")
+	if !sbf.Contains("a") || calls != 2 {
+		t.Fatalf("got %d calls to the hasher, wanted 2", calls)
+	}
+}
@@ -0,0 +1,38 @@
+package pds
+
+import (
+	"fmt"
+)
+
+// useStorage moves the buckets into the storage given to WithRegisterStorage, checking it
+// holds only valid bucket values
+func (hll *HyperLogLog) useStorage() error {
+	if int64(len(hll.storage)) < hll.mBuckets {
+		return fmt.Errorf("register storage has %d bytes but %d buckets are needed", len(hll.storage), hll.mBuckets)
+	}
+
+	buckets := bucketGroup(hll.storage[:hll.mBuckets:hll.mBuckets])
+	for i, value := range buckets {
+		if int(value) > int(hll.runBits())+1 {
+			return fmt.Errorf("register storage holds value %d out of range in bucket %d", value, i)
+		}
+	}
+
+	hll.bucketGroup = buckets
+	hll.sparse = nil
+
+	return nil
+}
+
+// Flush hands the buckets to the flush function given to WithRegisterStorage so they can be
+// persisted. It does nothing for sketches kept on the heap
+func (hll *HyperLogLog) Flush() error {
+	if hll.flushStorage == nil {
+		return nil
+	}
+
+	hll.lockAsync()
+	defer hll.unlockAsync()
+
+	return hll.flushStorage(hll.bucketGroup)
+}
@@ -0,0 +1,84 @@
+package pds
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestRegisterStorageSurvivesReopening(t *testing.T) {
+	// persisted stands in for the file behind a memory mapped region
+	storage := make([]byte, 1<<10)
+	var persisted []byte
+	flush := func(buckets []byte) error {
+		persisted = bytes.Clone(buckets)
+		return nil
+	}
+
+	hll, err := NewHyperLogLog(10, WithRegisterStorage(storage, flush))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5000; i++ {
+		hll.Add(fmt.Sprintf("item-%d", i))
+	}
+
+	want := filledSketch(t, 10, 5000)
+	if !bytes.Equal(storage, want.registers()) {
+		t.Fatalf("adds didn't land in the register storage")
+	}
+
+	if err := hll.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewHyperLogLog(10, WithRegisterStorage(persisted, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reopened.Equal(&want) || reopened.EstimateCardinality() != want.EstimateCardinality() {
+		t.Fatalf("reopened storage got estimate %d, wanted %d", reopened.EstimateCardinality(), want.EstimateCardinality())
+	}
+
+	// Decoding into it writes the decoded buckets to storage
+	other := filledSketch(t, 10, 100)
+	data, err := other.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reopened.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(persisted, other.registers()) {
+		t.Fatalf("decoding didn't write the decoded buckets to storage")
+	}
+}
+
+func TestRegisterStorageRejectsBadStorage(t *testing.T) {
+	if _, err := NewHyperLogLog(10, WithRegisterStorage(make([]byte, 1<<10-1), nil)); err == nil {
+		t.Fatalf("wanted an error for storage a byte short")
+	}
+
+	storage := make([]byte, 1<<10)
+	storage[3] = 64
+	if _, err := NewHyperLogLog(10, WithRegisterStorage(storage, nil)); err == nil {
+		t.Fatalf("wanted an error for storage holding an impossible bucket value")
+	}
+
+	hll, err := NewHyperLogLog(10, WithRegisterStorage(make([]byte, 1<<10), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := hll.Flush(); err != nil {
+		t.Fatalf("got %v flushing without a flush function", err)
+	}
+
+	if err := hll.Compress(8); err == nil {
+		t.Fatalf("wanted an error compressing a sketch in register storage")
+	}
+}
@@ -0,0 +1,88 @@
+package pds
+
+import (
+	"math"
+)
+
+// defaultSubscribeDelta is the relative change in estimate needed before subscribers hear about it
+const defaultSubscribeDelta = 0.01
+
+// Subscribe returns a channel that receives the new estimate whenever an Add moves it by more
+// than the subscribe delta. Only the latest estimate is kept if the reader falls behind
+func (hll *HyperLogLog) Subscribe() <-chan int64 {
+	ch := make(chan int64, 1)
+	hll.subscribers = append(hll.subscribers, ch)
+
+	return ch
+}
+
+// Unsubscribe stops and closes a channel returned by Subscribe
+func (hll *HyperLogLog) Unsubscribe(ch <-chan int64) {
+	for i, subscriber := range hll.subscribers {
+		if subscriber == ch {
+			close(subscriber)
+			hll.subscribers = append(hll.subscribers[:i], hll.subscribers[i+1:]...)
+			if len(hll.subscribers) == 0 {
+				hll.liveHistogram = nil
+			}
+			return
+		}
+	}
+}
+
+// moveLiveHistogram moves a bucket from one value to another in the histogram kept for
+// subscribers, if there is one
+func (hll *HyperLogLog) moveLiveHistogram(from int, to int) {
+	if hll.liveHistogram != nil {
+		hll.liveHistogram[from]--
+		hll.liveHistogram[to]++
+	}
+}
+
+// liveEstimate works out the estimate from the histogram kept for subscribers, which every
+// raised bucket keeps up to date, so it costs a pass over the bucket values rather than the
+// buckets. The histogram is built from the buckets the first time, and again after anything
+// that replaces them wholesale drops it. The estimate is cached like any other
+func (hll *HyperLogLog) liveEstimate() int64 {
+	if hll.exact != nil {
+		return int64(len(hll.exact))
+	}
+
+	if hll.cacheValid {
+		return hll.cachedEstimate
+	}
+
+	if hll.liveHistogram == nil {
+		hll.liveHistogram = hll.histogram()
+	}
+
+	var total float64
+	for value, count := range hll.liveHistogram {
+		total += math.Ldexp(float64(count), -value)
+	}
+
+	hll.cachedEstimate = hll.estimateFrom(hll.estimator, total, float64(hll.liveHistogram[0]), hll.liveHistogram, hll.hysteresis)
+	hll.cacheValid = true
+
+	return hll.cachedEstimate
+}
+
+// publish sends the current estimate to every subscriber if it has changed enough
+func (hll *HyperLogLog) publish() {
+	estimate := hll.liveEstimate()
+	change := math.Abs(float64(estimate - hll.lastPublished))
+	if change == 0 || change <= hll.subscribeDelta*float64(hll.lastPublished) {
+		return
+	}
+
+	hll.lastPublished = estimate
+	for _, subscriber := range hll.subscribers {
+		// Swap out any estimate the subscriber hasn't read yet for the newer one
+		select {
+		case <-subscriber:
+		default:
+		}
+
+		subscriber <- estimate
+	}
+}
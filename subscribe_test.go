@@ -0,0 +1,128 @@
+package pds
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSubscribe(t *testing.T) {
+	hll, err := NewHyperLogLog(10, WithSubscribeDelta(0.05))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := hll.Subscribe()
+
+	// Reading after every Add sees every estimate sent
+	var estimates []int64
+	for i := 0; i < 20000; i++ {
+		hll.Add(fmt.Sprintf("item-%d", i))
+
+		select {
+		case estimate := <-ch:
+			estimates = append(estimates, estimate)
+		default:
+		}
+	}
+
+	// Growing 5% at a time from 1 to about 20000 takes around 200 steps, sending on every
+	// Add would be 20000
+	if len(estimates) < 100 || len(estimates) > 400 {
+		t.Fatalf("got %d estimates, wanted around 200", len(estimates))
+	}
+
+	drops := 0
+	for i := 1; i < len(estimates); i++ {
+		change := float64(estimates[i]-estimates[i-1]) / float64(estimates[i-1])
+		if change > -0.05 && change < 0.05 {
+			t.Fatalf("estimate went from %d to %d, within the 5%% delta", estimates[i-1], estimates[i])
+		}
+
+		if change < 0 {
+			drops++
+		}
+	}
+
+	if drops > len(estimates)/20 {
+		t.Fatalf("estimate fell %d times out of %d", drops, len(estimates))
+	}
+
+	hll.Unsubscribe(ch)
+	hll.Add("after unsubscribing")
+	if _, open := <-ch; open {
+		t.Fatalf("channel still open after unsubscribing")
+	}
+}
+
+func TestSubscribeKeepsHistogramInStep(t *testing.T) {
+	for _, options := range [][]Option{nil, {WithSparseRepresentation()}, {WithMLEstimator()}} {
+		hll := filledSketch(t, 10, 0, options...)
+		ch := hll.Subscribe()
+
+		// checkHistogram compares the live histogram and estimate with ones worked out afresh
+		checkHistogram := func(stage string) {
+			t.Helper()
+
+			if hll.liveHistogram == nil {
+				return
+			}
+
+			want := hll.histogram()
+			for value, count := range hll.liveHistogram {
+				if count != want[value] {
+					t.Fatalf("%s got %d buckets at %d in the live histogram, wanted %d", stage, count, value, want[value])
+				}
+			}
+
+			if estimate, want := hll.liveEstimate(), hll.computeEstimate(); estimate != want {
+				t.Fatalf("%s got live estimate %d, wanted %d", stage, estimate, want)
+			}
+		}
+
+		for i := 0; i < 20000; i++ {
+			hll.Add(fmt.Sprintf("item-%d", i))
+			// More often early on while a sparse sketch is still sparse
+			if i%1000 == 0 || i < 1000 && i%50 == 0 {
+				checkHistogram("adding")
+			}
+		}
+
+		other := filledSketch(t, 10, 30000, options...)
+		if err := hll.Merge(other); err != nil {
+			t.Fatal(err)
+		}
+		hll.Add("after merging")
+		checkHistogram("merging")
+
+		hll.Reset()
+		hll.Add("after resetting")
+		checkHistogram("resetting")
+
+		if err := hll.Compress(8); err != nil {
+			t.Fatal(err)
+		}
+		hll.Add("after compressing")
+		checkHistogram("compressing")
+
+		hll.Unsubscribe(ch)
+	}
+}
+
+func BenchmarkAddSubscribed(b *testing.B) {
+	hll, err := NewHyperLogLog(16)
+	if err != nil {
+		b.Fatal(err)
+	}
+	hll.Subscribe()
+
+	keys := make([]string, 1<<20)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("item-%d", i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hll.Add(keys[i%len(keys)])
+	}
+}
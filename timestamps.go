@@ -0,0 +1,28 @@
+package pds
+
+import (
+	"time"
+)
+
+// registerTimestamps records when each bucket was last raised
+type registerTimestamps struct {
+	now      func() time.Time
+	raisedAt []time.Time
+}
+
+// RegisterAges returns how long before now each bucket was last raised, or 0 for buckets that
+// never have been. It needs WithRegisterTimestamps and returns nil otherwise
+func (hll *HyperLogLog) RegisterAges(now time.Time) []time.Duration {
+	if hll.timestamps == nil {
+		return nil
+	}
+
+	ages := make([]time.Duration, len(hll.timestamps.raisedAt))
+	for i, raisedAt := range hll.timestamps.raisedAt {
+		if !raisedAt.IsZero() {
+			ages[i] = now.Sub(raisedAt)
+		}
+	}
+
+	return ages
+}
@@ -0,0 +1,54 @@
+package pds
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegisterAges(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := start
+	hll, err := NewHyperLogLog(4, WithRegisterTimestamps(func() time.Time { return clock }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Hashes with bit 4 set give bucket h&15 a run of 1, bit 5 alone a run of 2
+	hll.addHash(0x10 | 1)
+	hll.addHash(0x10 | 2)
+
+	clock = start.Add(time.Hour)
+	hll.addHash(0x20 | 2)
+	hll.addHash(0x10 | 3)
+
+	// A hash that doesn't raise its bucket leaves the time alone
+	clock = start.Add(2 * time.Hour)
+	hll.addHash(0x10 | 1)
+
+	ages := hll.RegisterAges(start.Add(3 * time.Hour))
+	if len(ages) != 16 {
+		t.Fatalf("got %d ages, wanted 16", len(ages))
+	}
+
+	want := map[int]time.Duration{1: 3 * time.Hour, 2: 2 * time.Hour, 3: 2 * time.Hour}
+	for i, age := range ages {
+		if age != want[i] {
+			t.Errorf("bucket %d got age %v, wanted %v", i, age, want[i])
+		}
+	}
+
+	hll.Reset()
+	for i, age := range hll.RegisterAges(start) {
+		if age != 0 {
+			t.Fatalf("bucket %d still has age %v after a reset", i, age)
+		}
+	}
+}
+
+func TestRegisterAgesNeedsTimestamps(t *testing.T) {
+	hll := filledSketch(t, 4, 10)
+
+	if ages := hll.RegisterAges(time.Now()); ages != nil {
+		t.Fatalf("got ages without WithRegisterTimestamps")
+	}
+}
@@ -0,0 +1,55 @@
+package pds
+
+// KeyEncoder turns values of some type into the bytes that get hashed for them, appending
+// them to dst. Equal values need to encode the same and different values differently
+type KeyEncoder[T any] interface {
+	AppendKey(dst []byte, v T) []byte
+}
+
+// KeyEncoderFunc lets a plain function be used as a KeyEncoder
+type KeyEncoderFunc[T any] func(dst []byte, v T) []byte
+
+// AppendKey calls the function
+func (f KeyEncoderFunc[T]) AppendKey(dst []byte, v T) []byte {
+	return f(dst, v)
+}
+
+// AddValue encodes v with encoder and adds the bytes to the HyperLogLog, for counting structs,
+// integers or UUIDs without formatting them as strings first. Use TypedHyperLogLog to reuse
+// the encoding buffer between adds
+func AddValue[T any](hll *HyperLogLog, encoder KeyEncoder[T], v T) {
+	hll.AddBytes(encoder.AppendKey(nil, v))
+}
+
+// TypedHyperLogLog counts distinct values of one type, encoding each with its KeyEncoder
+type TypedHyperLogLog[T any] struct {
+	hll     HyperLogLog
+	encoder KeyEncoder[T]
+	buf     []byte
+}
+
+// NewTypedHyperLogLog builds a new TypedHyperLogLog encoding values with encoder
+func NewTypedHyperLogLog[T any](indexBits uint32, encoder KeyEncoder[T], options ...Option) (*TypedHyperLogLog[T], error) {
+	hll, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedHyperLogLog[T]{hll: hll, encoder: encoder}, nil
+}
+
+// Add encodes v and puts it into the HyperLogLog
+func (t *TypedHyperLogLog[T]) Add(v T) {
+	t.buf = t.encoder.AppendKey(t.buf[:0], v)
+	t.hll.AddBytes(t.buf)
+}
+
+// EstimateCardinality returns the estimated number of distinct values added
+func (t *TypedHyperLogLog[T]) EstimateCardinality() int64 {
+	return t.hll.EstimateCardinality()
+}
+
+// Sketch returns the underlying HyperLogLog, eg. for merging or serializing it
+func (t *TypedHyperLogLog[T]) Sketch() *HyperLogLog {
+	return &t.hll
+}
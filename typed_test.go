@@ -0,0 +1,41 @@
+package pds
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// uint64Encoder encodes integers as their eight big endian bytes
+var uint64Encoder = KeyEncoderFunc[uint64](func(dst []byte, v uint64) []byte {
+	return binary.BigEndian.AppendUint64(dst, v)
+})
+
+func TestTypedHyperLogLogMatchesAddBytes(t *testing.T) {
+	typed, err := NewTypedHyperLogLog[uint64](12, uint64Encoder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	viaValue := filledSketch(t, 12, 0)
+	viaBytes := filledSketch(t, 12, 0)
+	for i := uint64(0); i < 5000; i++ {
+		// Every value is added twice, the repeat mustn't count again
+		typed.Add(i)
+		typed.Add(i)
+		AddValue(&viaValue, uint64Encoder, i)
+		AddValue(&viaValue, uint64Encoder, i)
+		viaBytes.AddBytes(binary.BigEndian.AppendUint64(nil, i))
+	}
+
+	if !typed.Sketch().Equal(&viaBytes) || !viaValue.Equal(&viaBytes) {
+		t.Fatalf("typed adds filled different buckets than adding the encoded bytes")
+	}
+
+	if typed.EstimateCardinality() != viaBytes.EstimateCardinality() {
+		t.Fatalf("got estimate %d, wanted %d", typed.EstimateCardinality(), viaBytes.EstimateCardinality())
+	}
+
+	if _, err := NewTypedHyperLogLog[uint64](3, uint64Encoder); err == nil {
+		t.Fatalf("wanted an error for 3 index bits")
+	}
+}
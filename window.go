@@ -0,0 +1,156 @@
+package pds
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// windowEntry is a bucket value along with when it was seen
+type windowEntry struct {
+	at    time.Time
+	value int
+}
+
+// SlidingWindowHLL counts the distinct items seen within a recent window of time. Each bucket
+// keeps the values that could still be its largest for some part of the window, oldest
+// first with every later value smaller than the ones before it, so a bucket only ever holds
+// a handful of entries
+type SlidingWindowHLL struct {
+	config  HyperLogLog
+	window  time.Duration
+	now     func() time.Time
+	buckets [][]windowEntry
+
+	// Sketches of each interval of the window, when built with NewBucketedSlidingWindowHLL
+	series *HLLSeries
+}
+
+// BucketEstimate is the estimated number of distinct items added in one interval of a
+// SlidingWindowHLL, counted on its own
+type BucketEstimate struct {
+	Start    time.Time
+	Estimate int64
+}
+
+// NewSlidingWindowHLL builds a new SlidingWindowHLL able to answer for any span up to window,
+// taking the time from now or time.Now if it is nil. Like ConcurrentHyperLogLog only options
+// about hashing and estimating apply
+func NewSlidingWindowHLL(indexBits uint32, window time.Duration, now func() time.Time, options ...Option) (*SlidingWindowHLL, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("window needs to be positive")
+	}
+
+	hll, err := NewHyperLogLog(indexBits, options...)
+	if err != nil {
+		return nil, err
+	}
+	hll.Close()
+
+	if now == nil {
+		now = time.Now
+	}
+
+	return &SlidingWindowHLL{
+		config:  hll.emptyCopy(),
+		window:  window,
+		now:     now,
+		buckets: make([][]windowEntry, hll.mBuckets),
+	}, nil
+}
+
+// NewBucketedSlidingWindowHLL builds a new SlidingWindowHLL that also keeps a sketch of every
+// interval of bucket within the window, eg. a minute, for PerBucketEstimates. That takes a
+// HyperLogLog's memory for each interval on top of the window itself
+func NewBucketedSlidingWindowHLL(indexBits uint32, window time.Duration, bucket time.Duration, now func() time.Time, options ...Option) (*SlidingWindowHLL, error) {
+	sw, err := NewSlidingWindowHLL(indexBits, window, now, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	sw.series, err = NewHLLSeries(indexBits, bucket, window, sw.now, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return sw, nil
+}
+
+// Add hashes and puts some string into the data structure at the current time
+func (sw *SlidingWindowHLL) Add(s string) {
+	h := sw.config.hash(sw.config.normalize(s))
+	binaryIndex, unusedBinary := sw.config.splitBinary(h)
+	value := sw.config.rank(unusedBinary)
+
+	at := sw.now()
+	cutoff := at.Add(-sw.window)
+
+	// The current interval is always within the window so this can't fail
+	if sw.series != nil {
+		_ = sw.series.addHashAt(h, at)
+	}
+
+	// Drop entries that have left the window from the front, and entries the new value
+	// outlasts and outranks from the back
+	entries := sw.buckets[binaryIndex]
+	for len(entries) > 0 && entries[0].at.Before(cutoff) {
+		entries = entries[1:]
+	}
+	for len(entries) > 0 && entries[len(entries)-1].value <= value {
+		entries = entries[:len(entries)-1]
+	}
+
+	sw.buckets[binaryIndex] = append(entries, windowEntry{at: at, value: value})
+}
+
+// EstimateCardinalitySince returns the estimated number of distinct items added within the
+// last d, which can't be longer than the window
+func (sw *SlidingWindowHLL) EstimateCardinalitySince(d time.Duration) (int64, error) {
+	if d > sw.window {
+		return 0, fmt.Errorf("cannot estimate over %v with a window of %v", d, sw.window)
+	}
+
+	cutoff := sw.now().Add(-d)
+
+	// The first entry inside the span has the largest value seen during it
+	snapshot := sw.config.emptyCopy()
+	for i, entries := range sw.buckets {
+		for _, entry := range entries {
+			if !entry.at.Before(cutoff) {
+				snapshot.bucketGroup[i] = uint8(entry.value)
+				break
+			}
+		}
+	}
+
+	return snapshot.bucketEstimate(), nil
+}
+
+// EstimateCardinality returns the estimated number of distinct items added within the window
+func (sw *SlidingWindowHLL) EstimateCardinality() int64 {
+	estimate, _ := sw.EstimateCardinalitySince(sw.window)
+
+	return estimate
+}
+
+// PerBucketEstimates returns the estimate of every interval still within the window on its
+// own, oldest first, eg. for graphing the distinct count per minute. Items seen in several
+// intervals count once in each. It returns nil unless built with NewBucketedSlidingWindowHLL
+func (sw *SlidingWindowHLL) PerBucketEstimates() []BucketEstimate {
+	if sw.series == nil {
+		return nil
+	}
+
+	sw.series.evict()
+
+	estimates := make([]BucketEstimate, 0, len(sw.series.sketches))
+	for start, sketch := range sw.series.sketches {
+		estimates = append(estimates, BucketEstimate{Start: time.Unix(0, start), Estimate: sketch.EstimateCardinality()})
+	}
+
+	slices.SortFunc(estimates, func(a, b BucketEstimate) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	return estimates
+}
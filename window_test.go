@@ -0,0 +1,117 @@
+package pds
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// filledKeys builds a 12 index bit sketch of n keys formatted from pattern
+func filledKeys(t *testing.T, pattern string, n int) HyperLogLog {
+	t.Helper()
+
+	hll, err := NewHyperLogLog(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < n; i++ {
+		hll.Add(fmt.Sprintf(pattern, i))
+	}
+
+	return hll
+}
+
+func TestSlidingWindowHLLKeepsRecentItems(t *testing.T) {
+	now := time.Unix(1000000, 0)
+	sw, err := NewSlidingWindowHLL(12, time.Hour, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5000; i++ {
+		sw.Add(fmt.Sprintf("old-%d", i))
+	}
+
+	now = now.Add(45 * time.Minute)
+	for i := 0; i < 1000; i++ {
+		sw.Add(fmt.Sprintf("new-%d", i))
+	}
+
+	since, err := sw.EstimateCardinalitySince(30 * time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each span gives the estimate of a plain sketch of the items added within it
+	recent, all := filledKeys(t, "new-%d", 1000), filledKeys(t, "old-%d", 5000)
+	if err := all.Merge(recent); err != nil {
+		t.Fatal(err)
+	}
+
+	if since != recent.EstimateCardinality() || sw.EstimateCardinality() != all.EstimateCardinality() {
+		t.Fatalf("got %d in the last 30 minutes and %d in the hour, wanted %d and %d", since, sw.EstimateCardinality(), recent.EstimateCardinality(), all.EstimateCardinality())
+	}
+
+	if _, err := sw.EstimateCardinalitySince(2 * time.Hour); err == nil {
+		t.Fatalf("estimated past the window")
+	}
+}
+
+func TestPerBucketEstimates(t *testing.T) {
+	now := time.Unix(1000000, 0).Truncate(time.Minute)
+	sw, err := NewBucketedSlidingWindowHLL(12, 5*time.Minute, time.Minute, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every minute repeats the keys of the minute before and adds as many new ones, so each
+	// minute on its own sees twice as many distinct items as were new in it
+	start := now
+	for minute := 0; minute < 8; minute++ {
+		now = start.Add(time.Duration(minute) * time.Minute)
+		for i := 0; i < 2000; i++ {
+			sw.Add(fmt.Sprintf("item-%d", minute*1000+i))
+		}
+	}
+
+	// The window reaches back into the interval starting 5 minutes ago
+	estimates := sw.PerBucketEstimates()
+	if len(estimates) != 6 {
+		t.Fatalf("got %d buckets, wanted the 6 overlapping the window", len(estimates))
+	}
+
+	for i, estimate := range estimates {
+		minute := 2 + i
+		if want := start.Add(time.Duration(minute) * time.Minute); !estimate.Start.Equal(want) {
+			t.Fatalf("bucket %d starts at %v, wanted %v", i, estimate.Start, want)
+		}
+
+		want, err := NewHyperLogLog(12)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 2000; j++ {
+			want.Add(fmt.Sprintf("item-%d", minute*1000+j))
+		}
+
+		if estimate.Estimate != want.EstimateCardinality() {
+			t.Fatalf("got %d for bucket %d, wanted %d counted on its own", estimate.Estimate, i, want.EstimateCardinality())
+		}
+
+		if math.Abs(float64(estimate.Estimate)-2000)/2000 > 0.1 {
+			t.Fatalf("got %d for bucket %d, wanted about 2000", estimate.Estimate, i)
+		}
+	}
+
+	// The window as a whole counts the repeated keys once
+	if total := sw.EstimateCardinality(); math.Abs(float64(total)-7000)/7000 > 0.1 {
+		t.Fatalf("got %d over the window, wanted about 7000", total)
+	}
+
+	plain, _ := NewSlidingWindowHLL(12, time.Hour, nil)
+	if plain.PerBucketEstimates() != nil {
+		t.Fatalf("got bucket estimates without buckets")
+	}
+}
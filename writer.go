@@ -0,0 +1,72 @@
+package pds
+
+import (
+	"bytes"
+)
+
+// HyperLogLogWriter is an io.Writer adding each delimited item written to it to a HyperLogLog,
+// so a stream of ids can be counted with io.Copy. Empty items are skipped, and an item that
+// is cut off at the end of a write is held until the rest of it arrives
+type HyperLogLogWriter struct {
+	hll       *HyperLogLog
+	delimiter byte
+	pending   []byte
+}
+
+// NewHyperLogLogWriter builds a new HyperLogLogWriter adding items split on delimiter to hll,
+// eg. '\n' for one id per line
+func NewHyperLogLogWriter(hll *HyperLogLog, delimiter byte) *HyperLogLogWriter {
+	return &HyperLogLogWriter{hll: hll, delimiter: delimiter}
+}
+
+// Write adds every complete item in p, it never fails
+func (w *HyperLogLogWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for {
+		end := bytes.IndexByte(p, w.delimiter)
+		if end < 0 {
+			break
+		}
+
+		if len(w.pending) > 0 {
+			w.pending = append(w.pending, p[:end]...)
+			w.add(w.pending)
+			w.pending = w.pending[:0]
+		} else {
+			w.add(p[:end])
+		}
+
+		p = p[end+1:]
+	}
+
+	w.pending = append(w.pending, p...)
+
+	return n, nil
+}
+
+// Flush adds the item left over after the last delimiter, for streams that don't end in one
+func (w *HyperLogLogWriter) Flush() {
+	w.add(w.pending)
+	w.pending = w.pending[:0]
+}
+
+// Close flushes the last item, it never fails
+func (w *HyperLogLogWriter) Close() error {
+	w.Flush()
+
+	return nil
+}
+
+// add puts an item into the HyperLogLog, as a string when there's a normalizer to run over it
+func (w *HyperLogLogWriter) add(item []byte) {
+	if len(item) == 0 {
+		return
+	}
+
+	if w.hll.normalizer != nil {
+		w.hll.Add(string(item))
+		return
+	}
+
+	w.hll.AddBytes(item)
+}
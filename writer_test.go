@@ -0,0 +1,57 @@
+package pds
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHyperLogLogWriterMatchesAdd(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		options []Option
+	}{
+		{name: "bytes"},
+		{name: "normalized", options: []Option{WithKeyNormalizer(strings.ToLower)}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			want := filledSketch(t, 12, 0, test.options...)
+			var stream strings.Builder
+			for i := 0; i < 5000; i++ {
+				item := fmt.Sprintf("Item-%d", i)
+				want.Add(item)
+
+				// Blank lines in between are skipped
+				stream.WriteString(item + "\n")
+				if i%100 == 0 {
+					stream.WriteString("\n")
+				}
+			}
+
+			// The last item has no delimiter and only arrives on Close
+			want.Add("last")
+			stream.WriteString("last")
+
+			hll := filledSketch(t, 12, 0, test.options...)
+			w := NewHyperLogLogWriter(&hll, '\n')
+
+			// Odd sized writes cut items off part way through
+			data := []byte(stream.String())
+			for len(data) > 0 {
+				n := min(7, len(data))
+				if written, err := w.Write(data[:n]); err != nil || written != n {
+					t.Fatalf("wrote %d of %d bytes with error %v", written, n, err)
+				}
+				data = data[n:]
+			}
+
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if !hll.Equal(&want) {
+				t.Fatalf("writing the stream filled different buckets than adding its items")
+			}
+		})
+	}
+}
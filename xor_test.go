@@ -0,0 +1,101 @@
+package pds
+
+import (
+	"testing"
+)
+
+// xorKeys hashes n distinct items into keys, with every tenth added a second time
+func xorKeys(prefix string, n int) []uint64 {
+	keys := make([]uint64, 0, n+n/10)
+	for i := 0; i < n; i++ {
+		keys = append(keys, mix64(uint64(i))^fnv64(prefix, 0))
+		if i%10 == 0 {
+			keys = append(keys, keys[len(keys)-1])
+		}
+	}
+
+	return keys
+}
+
+func TestXor8(t *testing.T) {
+	keys := xorKeys("xor8", 50000)
+	xf, err := BuildXor8(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, key := range keys {
+		if !xf.Contains(key) {
+			t.Fatalf("key %d went missing from the filter", i)
+		}
+	}
+
+	// Duplicates take no room, so there are about 1.23 fingerprints per distinct key
+	if got := len(xf.fingerprints); got > 32+int(1.23*50000) {
+		t.Fatalf("got %d fingerprints for 50000 distinct keys", got)
+	}
+
+	falsePositives := 0
+	for _, key := range xorKeys("other", 100000) {
+		if xf.Contains(key) {
+			falsePositives++
+		}
+	}
+
+	if rate := float64(falsePositives) / 110000; rate > 2.0/256 {
+		t.Fatalf("got false positive rate %.5f, wanted about %.5f", rate, 1.0/256)
+	}
+}
+
+func TestXor16(t *testing.T) {
+	keys := xorKeys("xor16", 50000)
+	xf, err := BuildXor16(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, key := range keys {
+		if !xf.Contains(key) {
+			t.Fatalf("key %d went missing from the filter", i)
+		}
+	}
+
+	falsePositives := 0
+	for _, key := range xorKeys("other", 1000000) {
+		if xf.Contains(key) {
+			falsePositives++
+		}
+	}
+
+	// About 17 of the 1.1 million are expected
+	if falsePositives > 60 {
+		t.Fatalf("got %d false positives in 1100000 lookups, wanted about 17", falsePositives)
+	}
+}
+
+func TestXorFilterEdgeCases(t *testing.T) {
+	empty, err := BuildXor8(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hits := 0
+	for _, key := range xorKeys("other", 10000) {
+		if empty.Contains(key) {
+			hits++
+		}
+	}
+
+	if hits > 100 {
+		t.Fatalf("empty filter claimed %d of 11000 keys", hits)
+	}
+
+	one, err := BuildXor16([]uint64{42, 42, 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !one.Contains(42) {
+		t.Fatalf("a key added three times went missing")
+	}
+}